@@ -15,47 +15,61 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/song": {
+        "/api/admin/keys": {
             "get": {
-                "description": "Возвращает список песен, соответствующих фильтрам",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Возвращает все ключи, включая отозванные, без секретов: prefix - начало ключа, last_used_at - последнее использование с точностью до минуты",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
-                ],
-                "summary": "Получить песни с фильтрацией",
-                "parameters": [
-                    {
-                        "description": "Параметры фильтрации",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.FilteredRequest"
-                        }
-                    }
+                    "admin"
                 ],
+                "summary": "Список API-ключей",
                 "responses": {
                     "200": {
-                        "description": "Список найденных песен",
+                        "description": "Ключи",
                         "schema": {
-                            "$ref": "#/definitions/dto.SongsResponse"
+                            "$ref": "#/definitions/dto.ApiKeysResponse"
                         }
                     },
-                    "400": {
-                        "description": "Ошибка в запросе",
+                    "401": {
+                        "description": "Нет действующего токена",
                         "schema": {
-                            "$ref": "#/definitions/dto.SongsResponse"
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нужна роль admin",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Создает новую песню в базе данных",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт ключ с правами songs:read (роль viewer), songs:write (editor) или admin. Секрет возвращается в поле token только в этом ответе",
                 "consumes": [
                     "application/json"
                 ],
@@ -63,51 +77,86 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
+                    "admin"
                 ],
-                "summary": "Создать новую песню",
+                "summary": "Выпустить API-ключ",
                 "parameters": [
                     {
-                        "description": "Данные для создания песни",
+                        "description": "Имя и права ключа",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.CreateSongRequest"
+                            "$ref": "#/definitions/dto.IssueApiKeyRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Песня успешно создана",
+                    "201": {
+                        "description": "Ключ выпущен",
                         "schema": {
-                            "$ref": "#/definitions/dto.StandartResponse"
+                            "$ref": "#/definitions/dto.ApiKeyResponse"
                         }
                     },
                     "400": {
-                        "description": "Ошибка в запросе",
+                        "description": "Некорректный запрос",
                         "schema": {
-                            "$ref": "#/definitions/dto.StandartResponse"
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет действующего токена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нужна роль admin",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/song/{id}": {
-            "get": {
-                "description": "Возвращает данные песни по её ID",
+        "/api/admin/keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ключ перестаёт работать и остаётся в списке с revoked_at",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
+                    "admin"
                 ],
-                "summary": "Получить песню по ID",
+                "summary": "Отозвать API-ключ",
                 "parameters": [
                     {
                         "type": "string",
                         "format": "uuid",
-                        "description": "ID песни",
+                        "description": "ID ключа",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -115,181 +164,3855 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Данные песни",
+                        "description": "Ключ отозван",
                         "schema": {
-                            "$ref": "#/definitions/dto.StandartResponse"
+                            "$ref": "#/definitions/dto.ApiKeyResponse"
                         }
                     },
                     "400": {
-                        "description": "Ошибка в запросе",
+                        "description": "Некорректный запрос",
                         "schema": {
-                            "$ref": "#/definitions/dto.StandartResponse"
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет действующего токена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нужна роль admin",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Ключ не найден или уже отозван",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Обновляет данные существующей песни",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/api/admin/keys/{id}/rotate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Выпускает новый секрет для ключа с теми же именем и правами. Старый секрет перестаёт работать сразу",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
+                    "admin"
                 ],
-                "summary": "Обновить песню",
+                "summary": "Ротация API-ключа",
                 "parameters": [
                     {
                         "type": "string",
                         "format": "uuid",
-                        "description": "ID песни",
+                        "description": "ID ключа",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Новый секрет ключа",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ApiKeyResponse"
+                        }
                     },
-                    {
-                        "description": "Данные для обновления песни",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                    "400": {
+                        "description": "Некорректный запрос",
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdateSongRequest"
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Нет действующего токена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нужна роль admin",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Ключ не найден или отозван",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
+                }
+            }
+        },
+        "/api/admin/verses/rebuild": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Административная операция: заново разбивает на куплеты тексты всех песен библиотеки. Ход пересборки отдаётся потоком NDJSON: после каждой пачки песен строка {\"processed\": N, \"failed\": M} с накопленными счётчиками, последняя строка {\"summary\": {...}} с id песен, которые пересобрать не удалось. Если обход прерван (клиент отключился или база недоступна), в summary заполнено поле error. Разрыв соединения останавливает пересборку перед следующей песней",
+                "produces": [
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Пересобрать куплеты всех песен",
                 "responses": {
                     "200": {
-                        "description": "Песня успешно обновлена",
+                        "description": "Поток строк хода пересборки, последняя строка - dto.RebuildSummaryLine",
                         "schema": {
-                            "$ref": "#/definitions/dto.StandartResponse"
+                            "$ref": "#/definitions/dto.RebuildProgressLine"
                         }
                     },
-                    "400": {
-                        "description": "Ошибка в запросе",
+                    "429": {
+                        "description": "Превышен лимит запросов",
                         "schema": {
-                            "$ref": "#/definitions/dto.StandartResponse"
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Удаляет песню из базы данных",
+            }
+        },
+        "/api/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Выгружает группы, песни и, по запросу, куплеты потоком, без загрузки всей библиотеки в память. Принимает те же фильтры, что и поиск песен (кроме limit и cursor). ndjson - строки {\"type\": \"group\"|\"song\", ...}; json - документ {\"groups\": [...], \"songs\": [...]}; csv - строка на песню или, с include_verses, на куплет. Если ошибка случилась после начала выгрузки, соединение обрывается",
                 "produces": [
-                    "application/json"
+                    "application/x-ndjson",
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
-                    "songs"
+                    "export"
                 ],
-                "summary": "Удалить песню",
+                "summary": "Экспорт библиотеки",
                 "parameters": [
                     {
+                        "enum": [
+                            "ndjson",
+                            "csv",
+                            "json"
+                        ],
                         "type": "string",
-                        "format": "uuid",
-                        "description": "ID песни",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Формат выгрузки (по умолчанию ndjson)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Выгрузить куплеты песен",
+                        "name": "include_verses",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Название песни",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Исполнитель, можно указать несколько раз",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "contains",
+                            "prefix",
+                            "exact"
+                        ],
+                        "type": "string",
+                        "description": "Режим сравнения title и group",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата релиза от (YYYY-MM-DD)",
+                        "name": "released_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата релиза до (YYYY-MM-DD)",
+                        "name": "released_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока в названии или исполнителе",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока в тексте песни",
+                        "name": "text",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ссылка на песню",
+                        "name": "link",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Сортировка песен, например group_name,-release_date",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Песня успешно удалена",
+                        "description": "Поток записей экспорта",
                         "schema": {
-                            "$ref": "#/definitions/dto.StandartResponse"
+                            "$ref": "#/definitions/dto.ExportRecord"
                         }
                     },
-                    "400": {
-                        "description": "Ошибка в запросе",
+                    "422": {
+                        "description": "Ошибка валидации",
                         "schema": {
-                            "$ref": "#/definitions/dto.StandartResponse"
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/verses/{id}": {
+        "/api/groups": {
             "get": {
-                "description": "Возвращает куплеты песни с указанной пагинацией",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Возвращает исполнителей по алфавиту с количеством песен",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "verses"
+                    "groups"
                 ],
-                "summary": "Получить куплеты песни с пагинацией",
+                "summary": "Получить список групп",
                 "parameters": [
                     {
-                        "type": "string",
-                        "format": "uuid",
-                        "description": "ID песни",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "Номер страницы (по умолчанию 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Групп на странице (по умолчанию 20, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список групп",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GroupsResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
                     },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает нового исполнителя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Создать группу",
+                "parameters": [
                     {
-                        "description": "Параметры пагинации",
+                        "description": "Название группы",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.PaginatedVersesRequest"
+                            "$ref": "#/definitions/dto.CreateGroupRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Список куплетов песни",
+                    "201": {
+                        "description": "Группа создана",
                         "schema": {
-                            "$ref": "#/definitions/dto.PaginatedVersesResponse"
+                            "$ref": "#/definitions/dto.GroupResponse"
                         }
                     },
                     "400": {
-                        "description": "Ошибка в запросе",
+                        "description": "Некорректный запрос",
                         "schema": {
-                            "$ref": "#/definitions/dto.StandartResponse"
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Группа уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.CreateSongRequest": {
-            "type": "object",
+        },
+        "/api/groups/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает группу, количество её песен и дискографию по дате релиза",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Получить группу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID группы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Данные группы",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GroupDetailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Группа не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Меняет название группы и обновляет его у всех её песен",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Переименовать группу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID группы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новое название",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RenameGroupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Группа переименована",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GroupResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Группа не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Название уже занято",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет группу. Если у группы есть песни, в том числе в корзине, удаление выполняется только с cascade=true: живые песни уходят в корзину, а при восстановлении группа создаётся заново по названию",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Удалить группу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID группы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Перенести песни группы в корзину",
+                        "name": "cascade",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Группа удалена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GroupResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Группа не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "У группы есть песни или песни в корзине",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/groups/{id}/merge": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Переносит все песни исходных групп в целевую в одной транзакции и удаляет исходные группы. Их названия сохраняются как алиасы целевой группы, поэтому новые песни с этими названиями попадут в неё",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "groups"
+                ],
+                "summary": "Объединить группы",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID целевой группы",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ID объединяемых групп",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MergeGroupsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Группы объединены",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MergeGroupsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Группа не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search/lyrics": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ищет песни по тексту с учётом русской и английской морфологии. Возвращает песни по убыванию релевантности, номера совпавших куплетов и фрагменты с подсветкой",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "search"
+                ],
+                "summary": "Полнотекстовый поиск по текстам песен",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Поисковый запрос, поддерживается синтаксис websearch (\\",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "ru",
+                            "en"
+                        ],
+                        "type": "string",
+                        "description": "Язык морфологии: ru или en. По умолчанию оба",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество песен (по умолчанию 20, максимум 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Найденные песни",
+                        "schema": {
+                            "$ref": "#/definitions/dto.LyricsSearchResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает список песен, соответствующих фильтрам. Название и исполнитель сравниваются без учёта регистра",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Получить песни с фильтрацией",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Название песни",
+                        "name": "title",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Исполнитель, можно указать несколько раз",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "contains",
+                            "prefix",
+                            "exact"
+                        ],
+                        "type": "string",
+                        "description": "Режим сравнения title и group: contains (по умолчанию), prefix, exact",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата релиза не раньше (YYYY-MM-DD)",
+                        "name": "released_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата релиза не позже (YYYY-MM-DD)",
+                        "name": "released_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поиск подстроки в названии и исполнителе",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поиск подстроки в тексте песни",
+                        "name": "text",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ссылка",
+                        "name": "link",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество песен на странице (по умолчанию 20, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы из next_cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поля сортировки через запятую: title, release_date, created_at, group_name; минус - по убыванию",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список найденных песен",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SongsResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создает новую песню в базе данных. С заголовком Idempotency-Key повтор запроса с тем же ключом и телом возвращает сохранённый ответ (с заголовком Idempotent-Replayed), а тот же ключ с другим телом отклоняется",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Создать новую песню",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ключ идемпотентности, до 255 символов",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные для создания песни",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateSongRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Песня успешно создана",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StandartResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена в сервисе метаданных",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Песня уже существует или запрос с этим ключом ещё выполняется",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации или ключ использован с другим телом",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "Сервис метаданных недоступен",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает данные песни по её ID. Если есть перевод на язык из lang или Accept-Language, название и текст отдаются в переводе, иначе в оригинале. В заголовке ETag - версия песни, её нужно передать в If-Match при изменении и удалении",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Получить песню по ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Язык перевода, иначе берётся из Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Предпочитаемый язык перевода",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag сохранённой копии",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Данные песни",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StandartResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "Песня не изменилась"
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Обновляет данные существующей песни. Требует If-Match с ETag из GET; если песню успели изменить, возвращается 412 с её текущей версией",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Обновить песню",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные для обновления песни",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateSongRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня успешно обновлена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StandartResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Песню успели изменить, в ответе текущая версия",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StandartResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Нет заголовка If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Переносит песню в корзину: до очистки её можно восстановить через POST /api/song/{id}/restore. Требует If-Match с ETag из GET; если песню успели изменить, возвращается 412 с её текущей версией",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Удалить песню",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня успешно удалена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StandartResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Песню успели изменить, в ответе текущая версия",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StandartResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Нет заголовка If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Применяет JSON Merge Patch (RFC 7386): переданные поля заменяются, null очищает поле, отсутствующие поля не меняются. Очистить можно release_date, text, link и split_strategy. Требует If-Match с ETag из GET",
+                "consumes": [
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Частично обновить песню",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Изменяемые поля песни",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateSongRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня успешно обновлена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StandartResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Песню успели изменить, в ответе текущая версия",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StandartResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Неподдерживаемый Content-Type",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "428": {
+                        "description": "Нет заголовка If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/lyrics": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает строки текста песни с таймингами в миллисекундах. У строк, тайминг которых потерялся после правки текста, time_ms отсутствует",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lyrics"
+                ],
+                "summary": "Получить строки с таймингами",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Строки с таймингами",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TimedLyricsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или тайминги не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/lyrics/at": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает строку, которая звучит в момент t, и next следующих строк. Если t раньше первой строки, current пустой",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lyrics"
+                ],
+                "summary": "Строка на позиции воспроизведения",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Позиция воспроизведения в секундах",
+                        "name": "t",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Сколько следующих строк вернуть (по умолчанию 3, максимум 20)",
+                        "name": "next",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Текущая и следующие строки",
+                        "schema": {
+                            "$ref": "#/definitions/dto.LyricsAtResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или тайминги не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/lyrics/lrc": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает тайминги песни в формате LRC. Строки без тайминга пропускаются",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "lyrics"
+                ],
+                "summary": "Экспортировать LRC",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "LRC",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или тайминги не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Привязывает тайминги из LRC к строкам текста песни. Принимает JSON или LRC как есть с Content-Type text/plain (тогда replace_text передаётся в query). Если у песни нет текста или replace_text=true, текст и куплеты заменяются текстом из LRC",
+                "consumes": [
+                    "application/json",
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lyrics"
+                ],
+                "summary": "Импортировать LRC",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "LRC",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ImportLrcRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Заменить текст песни (для text/plain)",
+                        "name": "replace_text",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Тайминги импортированы",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TimedLyricsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Текст песни изменился во время импорта",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает песню из корзины вместе с куплетами, таймингами и переводами. Если за это время создали такую же песню, возвращается 409",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Восстановить песню",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня восстановлена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StandartResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песни нет в корзине",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Такая песня уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/revisions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает ревизии песни, начиная с последней. Ревизия создаётся при каждом изменении полей или текста песни и хранит снимок полей, список изменённых полей, время и автора, если он известен",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "История песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы (по умолчанию 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ревизий на странице (по умолчанию 20, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ревизии песни",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RevisionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/revisions/diff": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Построчный дифф текста и список изменённых полей между ревизиями from и to. Без to сравнивается последняя ревизия, без from - предыдущая перед to. from может быть больше to, тогда дифф показывает откат",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Сравнить ревизии песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Исходная ревизия",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Конечная ревизия",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Изменения между ревизиями",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RevisionDiffResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или ревизия не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/revisions/{rev}/revert": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает песне поля и текст из ревизии и пересобирает куплеты. Откат создаёт новую ревизию. Требует If-Match с ETag из GET; если песню успели изменить, возвращается 412 с её текущей версией",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Откатить песню к ревизии",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер ревизии",
+                        "name": "rev",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag песни",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песня откачена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StandartResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или ревизия не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Песню успели изменить, в ответе текущая версия",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StandartResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Нет заголовка If-Match",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/translations": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает языки, на которые переведена песня, и количество переведённых куплетов",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "translations"
+                ],
+                "summary": "Получить список переводов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Переводы",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TranslationsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/translations/{lang}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает перевод песни и её куплетов на язык lang",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "translations"
+                ],
+                "summary": "Получить перевод",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Язык перевода, например en или pt-br",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Перевод",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TranslationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или перевод не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт или заменяет перевод песни. Текст перевода разбивается на куплеты той же стратегией, что и оригинал, куплеты можно передать и явно. Количество куплетов должно совпадать с оригиналом",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "translations"
+                ],
+                "summary": "Сохранить перевод",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Язык перевода, например en или pt-br",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Перевод",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SaveTranslationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Перевод сохранён",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TranslationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет перевод песни и её куплетов на язык lang",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "translations"
+                ],
+                "summary": "Удалить перевод",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Язык перевода",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Перевод удалён",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TranslationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Перевод не найден",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/verses": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Вставляет куплет на позицию position, последующие куплеты сдвигаются. Без position куплет добавляется в конец",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "verses"
+                ],
+                "summary": "Добавить куплет",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Текст и позиция куплета",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.InsertVerseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Куплет добавлен",
+                        "schema": {
+                            "$ref": "#/definitions/dto.VerseResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/verses/rebuild": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Заново разбивает текст песни на куплеты и заменяет ими сохранённые",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "verses"
+                ],
+                "summary": "Пересобрать куплеты песни",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Куплеты пересобраны",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RebuildVersesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/verses/{n}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает один куплет песни по номеру",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "verses"
+                ],
+                "summary": "Получить куплет",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер куплета",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Язык перевода, иначе берётся из Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть перевод рядом с оригиналом",
+                        "name": "side_by_side",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Предпочитаемый язык перевода",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Куплет",
+                        "schema": {
+                            "$ref": "#/definitions/dto.VerseResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или куплет не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Заменяет текст куплета, текст песни пересобирается из куплетов",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "verses"
+                ],
+                "summary": "Изменить куплет",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер куплета",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый текст куплета",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateVerseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Куплет обновлён",
+                        "schema": {
+                            "$ref": "#/definitions/dto.VerseResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или куплет не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет куплет, последующие куплеты сдвигаются на его место",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "verses"
+                ],
+                "summary": "Удалить куплет",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер куплета",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Куплет удалён",
+                        "schema": {
+                            "$ref": "#/definitions/dto.VerseResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или куплет не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/song/{id}/verses/{n}/move": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Переносит куплет на позицию to, куплеты между старой и новой позицией сдвигаются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "verses"
+                ],
+                "summary": "Переместить куплет",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер куплета",
+                        "name": "n",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новая позиция",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MoveVerseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Куплет перемещён",
+                        "schema": {
+                            "$ref": "#/definitions/dto.VerseResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня или куплет не найдены",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/songs/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Создаёт песни из NDJSON (строки {\"group\": \"...\", \"title\": \"...\"}) или CSV (колонки group,title, заголовок необязателен). Метаданные запрашиваются параллельно. Отчёт отдаётся потоком NDJSON по мере обработки: по строке на каждую строку файла (статус created, duplicate, metadata_missing, invalid, failed или rate_limited, порядок не сохраняется) и последняя строка {\"summary\": {...}}. Каждая строка, которой нужен сервис метаданных, расходует лимит RATE_LIMIT_UPSTREAM; строки сверх лимита получают статус rate_limited",
+                "consumes": [
+                    "application/x-ndjson",
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Импорт песен",
+                "parameters": [
+                    {
+                        "description": "Строки group/title",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Поток результатов по строкам, последняя строка - dto.ImportSummaryLine",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ImportRowResult"
+                        }
+                    },
+                    "415": {
+                        "description": "Неподдерживаемый Content-Type",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/trash": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает удалённые песни, начиная с удалённых последними. purge_at - момент, после которого песня будет удалена окончательно",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Корзина",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы (по умолчанию 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Песен на странице (по умолчанию 20, максимум 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Песни в корзине",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TrashResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/verses/preview": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Показывает, как текст будет разбит на куплеты, ничего не сохраняя. Стратегии: blank_line, section_headers, fixed_lines:N. Без strategy используется глобальная из VERSE_SPLIT_STRATEGY",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "verses"
+                ],
+                "summary": "Предпросмотр разбиения на куплеты",
+                "parameters": [
+                    {
+                        "description": "Текст и стратегия",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PreviewVersesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Куплеты",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PreviewVersesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/verses/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает куплеты песни с указанной пагинацией. У каждого куплета есть тип секции (verse, chorus, pre_chorus, bridge, intro, outro) и repeat_of - id куплета, который он повторяет. section_type фильтрует куплеты по типу. Страница за пределами списка возвращается пустой",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "verses"
+                ],
+                "summary": "Получить куплеты песни с пагинацией",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID песни",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Номер страницы (по умолчанию 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Куплетов на странице (по умолчанию 10, максимум 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "verse",
+                            "chorus",
+                            "pre_chorus",
+                            "bridge",
+                            "intro",
+                            "outro"
+                        ],
+                        "type": "string",
+                        "description": "Тип секции",
+                        "name": "section_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Язык перевода, иначе берётся из Accept-Language",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Вернуть перевод рядом с оригиналом",
+                        "name": "side_by_side",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Предпочитаемый язык перевода",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список куплетов песни",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PaginatedVersesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Песня не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Ошибка валидации",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Превышен лимит запросов",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.ApiKeyResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "key": {
+                    "$ref": "#/definitions/models.ApiKey"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ApiKeysResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ApiKey"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateGroupRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                }
+            }
+        },
+        "dto.CreateSongRequest": {
+            "type": "object",
+            "required": [
+                "group",
+                "title"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                }
+            }
+        },
+        "dto.DiffLine": {
+            "type": "object",
+            "properties": {
+                "from_line": {
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "to_line": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.DiscographyRecord": {
+            "type": "object",
+            "properties": {
+                "link": {
+                    "type": "string"
+                },
+                "release_date": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ExportRecord": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "$ref": "#/definitions/models.Group"
+                },
+                "song": {
+                    "$ref": "#/definitions/dto.ExportedSong"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ExportedSong": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "group_id": {
+                    "type": "string"
+                },
+                "group_name": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "release_date": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "string"
+                },
+                "split_strategy": {
+                    "description": "Стратегия разбиения на куплеты, пусто - глобальная по умолчанию",
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "verses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Verse"
+                    }
+                },
+                "version": {
+                    "description": "Версия растёт при каждом изменении песни и служит ETag",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.FieldChange": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.FieldError": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GroupDetailResponse": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "discography": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.DiscographyRecord"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "group": {
+                    "$ref": "#/definitions/models.Group"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "song_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.GroupResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "group": {
+                    "$ref": "#/definitions/models.Group"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GroupSummary": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "song_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.GroupsResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.GroupSummary"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ImportLrcRequest": {
+            "type": "object",
+            "required": [
+                "lrc"
+            ],
+            "properties": {
+                "lrc": {
+                    "type": "string"
+                },
+                "replace_text": {
+                    "description": "Заменить текст песни текстом из LRC. Если текста у песни нет, он заменяется всегда",
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.ImportRowResult": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.InsertVerseRequest": {
+            "type": "object",
+            "required": [
+                "text"
+            ],
+            "properties": {
+                "position": {
+                    "description": "0 - добавить в конец",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "section_type": {
+                    "description": "пусто - по заголовку в тексте",
+                    "type": "string",
+                    "enum": [
+                        "verse",
+                        "chorus",
+                        "pre_chorus",
+                        "bridge",
+                        "intro",
+                        "outro"
+                    ]
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.IssueApiKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.LyricsAtResponse": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "description": "nil, если строка ещё не началась",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.LyricLine"
+                        }
+                    ]
+                },
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "next": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LyricLine"
+                    }
+                },
+                "t": {
+                    "type": "number"
+                }
+            }
+        },
+        "dto.LyricsSearchResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.LyricsSearchResult"
+                    }
+                }
+            }
+        },
+        "dto.LyricsSearchResult": {
+            "type": "object",
+            "properties": {
+                "rank": {
+                    "type": "number"
+                },
+                "song": {
+                    "$ref": "#/definitions/models.Song"
+                },
+                "verses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.VerseMatch"
+                    }
+                }
+            }
+        },
+        "dto.MergeGroupsRequest": {
+            "type": "object",
+            "required": [
+                "source_ids"
+            ],
+            "properties": {
+                "source_ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.MergeGroupsResponse": {
+            "type": "object",
+            "properties": {
+                "aliases": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "group": {
+                    "$ref": "#/definitions/models.Group"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "moved_songs": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.MoveVerseRequest": {
+            "type": "object",
+            "required": [
+                "to"
+            ],
+            "properties": {
+                "to": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "dto.PaginatedVersesResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "has_next": {
+                    "type": "boolean"
+                },
+                "has_prev": {
+                    "type": "boolean"
+                },
+                "lang": {
+                    "description": "язык перевода, пусто - оригинал",
+                    "type": "string"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                },
+                "total_verses": {
+                    "type": "integer"
+                },
+                "verses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Verse"
+                    }
+                }
+            }
+        },
+        "dto.PreviewVersesRequest": {
+            "type": "object",
             "required": [
-                "group",
-                "title"
+                "text"
+            ],
+            "properties": {
+                "strategy": {
+                    "description": "пусто - глобальная стратегия",
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.PreviewVersesResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "strategy": {
+                    "type": "string"
+                },
+                "verses": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RebuildProgressLine": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "processed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.RebuildVersesResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "failed": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "processed": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.RenameGroupRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                }
+            }
+        },
+        "dto.RevisionDiffResponse": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FieldChange"
+                    }
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.DiffLine"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "removed": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.RevisionsResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "revisions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SongRevision"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.SaveTranslationRequest": {
+            "type": "object",
+            "required": [
+                "verses"
             ],
             "properties": {
-                "group": {
-                    "type": "string",
-                    "maxLength": 100,
-                    "minLength": 1
+                "text": {
+                    "description": "Текст перевода разбивается на куплеты той же стратегией, что и оригинал.\nВместо него можно передать куплеты явно",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "verses": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.SongsResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "has_more": {
+                    "type": "boolean"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "songs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Song"
+                    }
+                }
+            }
+        },
+        "dto.StandartResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "lang": {
+                    "description": "Язык перевода, которым заменены название и текст; пусто - оригинал",
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "song": {
+                    "$ref": "#/definitions/models.Song"
+                }
+            }
+        },
+        "dto.TimedLyricsResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.LyricLine"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "timed_lines": {
+                    "type": "integer"
+                },
+                "untimed_lines": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.TranslationResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "translation": {
+                    "$ref": "#/definitions/models.SongTranslation"
+                },
+                "verses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.VerseTranslation"
+                    }
+                }
+            }
+        },
+        "dto.TranslationSummary": {
+            "type": "object",
+            "properties": {
+                "lang": {
+                    "type": "string"
+                },
+                "stale": {
+                    "type": "boolean"
                 },
                 "title": {
-                    "type": "string",
-                    "maxLength": 100,
-                    "minLength": 1
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "verse_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.TranslationsResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "translations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.TranslationSummary"
+                    }
+                }
+            }
+        },
+        "dto.TrashResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "songs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.TrashedSong"
+                    }
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.FilteredRequest": {
+        "dto.TrashedSong": {
             "type": "object",
             "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "group_id": {
+                    "type": "string"
+                },
                 "group_name": {
                     "type": "string"
                 },
                 "link": {
                     "type": "string"
                 },
+                "purge_at": {
+                    "type": "string"
+                },
+                "release_date": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "string"
+                },
+                "split_strategy": {
+                    "description": "Стратегия разбиения на куплеты, пусто - глобальная по умолчанию",
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Версия растёт при каждом изменении песни и служит ETag",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.UpdateSongRequest": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "link": {
+                    "type": "string"
+                },
                 "release_date": {
                     "type": "string"
                 },
+                "split_strategy": {
+                    "description": "blank_line, section_headers, fixed_lines:N или default, чтобы вернуть глобальную",
+                    "type": "string"
+                },
                 "text": {
                     "type": "string"
                 },
@@ -298,79 +4021,132 @@ const docTemplate = `{
                 }
             }
         },
-        "dto.PaginatedVersesRequest": {
+        "dto.UpdateVerseRequest": {
             "type": "object",
             "required": [
-                "limit",
-                "page"
+                "text"
             ],
             "properties": {
-                "limit": {
-                    "description": "куплетов на страницу",
-                    "type": "integer",
-                    "minimum": 1
+                "section_type": {
+                    "description": "пусто - по заголовку в тексте",
+                    "type": "string",
+                    "enum": [
+                        "verse",
+                        "chorus",
+                        "pre_chorus",
+                        "bridge",
+                        "intro",
+                        "outro"
+                    ]
                 },
-                "page": {
-                    "type": "integer",
-                    "minimum": 1
+                "text": {
+                    "type": "string"
                 }
             }
         },
-        "dto.PaginatedVersesResponse": {
+        "dto.VerseMatch": {
             "type": "object",
             "properties": {
-                "limit": {
-                    "type": "integer"
-                },
-                "page": {
-                    "type": "integer"
+                "snippet": {
+                    "type": "string"
                 },
-                "total": {
+                "verse_number": {
                     "type": "integer"
-                },
-                "verses": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
                 }
             }
         },
-        "dto.SongsResponse": {
+        "dto.VerseResponse": {
             "type": "object",
             "properties": {
                 "error": {
                     "type": "string"
                 },
+                "lang": {
+                    "description": "язык перевода, пусто - оригинал",
+                    "type": "string"
+                },
                 "message": {
                     "type": "string"
                 },
-                "songs": {
+                "verse": {
+                    "$ref": "#/definitions/models.Verse"
+                }
+            }
+        },
+        "models.ApiKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "rotated_at": {
+                    "type": "string"
+                },
+                "scopes": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Song"
+                        "type": "string"
                     }
                 }
             }
         },
-        "dto.StandartResponse": {
+        "models.Group": {
             "type": "object",
             "properties": {
-                "error": {
+                "id": {
                     "type": "string"
                 },
-                "message": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.LyricLine": {
+            "type": "object",
+            "properties": {
+                "id": {
                     "type": "string"
                 },
-                "song": {
-                    "$ref": "#/definitions/models.Song"
+                "line_number": {
+                    "type": "integer"
+                },
+                "song_id": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "time_ms": {
+                    "description": "Время начала строки в миллисекундах, пусто - тайминг неизвестен",
+                    "type": "integer"
                 }
             }
         },
-        "dto.UpdateSongRequest": {
+        "models.Song": {
             "type": "object",
             "properties": {
-                "group": {
+                "created_at": {
+                    "type": "string"
+                },
+                "group_id": {
+                    "type": "string"
+                },
+                "group_name": {
                     "type": "string"
                 },
                 "link": {
@@ -379,20 +4155,57 @@ const docTemplate = `{
                 "release_date": {
                     "type": "string"
                 },
+                "song_id": {
+                    "type": "string"
+                },
+                "split_strategy": {
+                    "description": "Стратегия разбиения на куплеты, пусто - глобальная по умолчанию",
+                    "type": "string"
+                },
                 "text": {
                     "type": "string"
                 },
                 "title": {
                     "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Версия растёт при каждом изменении песни и служит ETag",
+                    "type": "integer"
                 }
             }
         },
-        "models.Song": {
+        "models.SongRevision": {
             "type": "object",
             "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "changed_fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "revision": {
+                    "type": "integer"
+                },
+                "snapshot": {
+                    "$ref": "#/definitions/models.SongSnapshot"
+                },
+                "song_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SongSnapshot": {
+            "type": "object",
+            "properties": {
                 "group_id": {
                     "type": "string"
                 },
@@ -405,9 +4218,35 @@ const docTemplate = `{
                 "release_date": {
                     "type": "string"
                 },
+                "split_strategy": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SongTranslation": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lang": {
+                    "type": "string"
+                },
                 "song_id": {
                     "type": "string"
                 },
+                "stale": {
+                    "type": "boolean"
+                },
                 "text": {
                     "type": "string"
                 },
@@ -418,6 +4257,60 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "models.Verse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "repeat_of": {
+                    "description": "Id куплета, который повторяет этот (например, повтор припева)",
+                    "type": "string"
+                },
+                "section_type": {
+                    "type": "string"
+                },
+                "song_id": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "translation": {
+                    "description": "Перевод куплета, если он запрошен рядом с оригиналом",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.VerseTranslation"
+                        }
+                    ]
+                },
+                "verse_number": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.VerseTranslation": {
+            "type": "object",
+            "properties": {
+                "lang": {
+                    "type": "string"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "verse_number": {
+                    "type": "integer"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "description": "API-ключ в виде \"Bearer \u003cключ\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
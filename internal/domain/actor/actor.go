@@ -0,0 +1,17 @@
+package actor
+
+import "context"
+
+type ctxKey struct{}
+
+// WithName возвращает контекст с автором изменений. Репозитории записывают
+// его в историю ревизий песни.
+func WithName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, name)
+}
+
+// FromContext возвращает автора изменений или пустую строку, если он неизвестен.
+func FromContext(ctx context.Context) string {
+	name, _ := ctx.Value(ctxKey{}).(string)
+	return name
+}
@@ -0,0 +1,120 @@
+// Package apperr описывает ошибки предметной области. У каждой ошибки есть
+// вид, по которому обработчик выбирает HTTP-статус, и стабильный код, на
+// который могут опираться клиенты: текст ошибки со временем может меняться.
+package apperr
+
+import "errors"
+
+type Kind int
+
+const (
+	Internal Kind = iota
+	Validation
+	NotFound
+	Conflict
+	Unavailable
+	PreconditionFailed
+	PreconditionRequired
+	Unauthorized
+	Forbidden
+	TooManyRequests
+	TooLarge
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Validation:
+		return "validation"
+	case NotFound:
+		return "not_found"
+	case Conflict:
+		return "conflict"
+	case Unavailable:
+		return "unavailable"
+	case PreconditionFailed:
+		return "precondition_failed"
+	case PreconditionRequired:
+		return "precondition_required"
+	case Unauthorized:
+		return "unauthorized"
+	case Forbidden:
+		return "forbidden"
+	case TooManyRequests:
+		return "too_many_requests"
+	case TooLarge:
+		return "too_large"
+	default:
+		return "internal"
+	}
+}
+
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Err     error
+}
+
+// New создаёт ошибку-сентинел. Уточнить её можно через
+// fmt.Errorf("%w: ...", err), вид и код при этом сохранятся.
+func New(kind Kind, code, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// Wrap присваивает вид и код ошибке из другого слоя, например ответу
+// внешнего сервиса, сохраняя её в цепочке.
+func Wrap(err error, kind Kind, code, message string) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: message,
+		Err:     err,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf возвращает вид ближайшей ошибки apperr в цепочке. Ошибки без
+// вида, например ошибки базы данных, считаются внутренними.
+func KindOf(err error) Kind {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Kind
+	}
+	return Internal
+}
+
+// CodeOf возвращает код ближайшей ошибки apperr в цепочке или "internal".
+func CodeOf(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return "internal"
+}
+
+// MessageOf возвращает текст ближайшей ошибки apperr в цепочке без
+// подробностей обёрнутых ошибок.
+func MessageOf(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Message
+	}
+	return err.Error()
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Права API-ключей. Каждое соответствует роли: songs:read - viewer,
+// songs:write - editor, admin - admin.
+const (
+	ScopeSongsRead  = "songs:read"
+	ScopeSongsWrite = "songs:write"
+	ScopeAdmin      = "admin"
+)
+
+// ApiKey - выпущенный API-ключ. Сам ключ не хранится, только его хеш.
+type ApiKey struct {
+	Id         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RotatedAt  *time.Time `json:"rotated_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
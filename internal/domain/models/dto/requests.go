@@ -2,7 +2,6 @@ package dto
 
 import (
 	"github.com/google/uuid"
-	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
 )
 
 type CreateSongRequest struct {
@@ -11,7 +10,8 @@ type CreateSongRequest struct {
 }
 
 type GetSongByIdRequest struct {
-	Id uuid.UUID `json:"id" validate:"required,uuid"`
+	Id    uuid.UUID `json:"id" validate:"required,uuid"`
+	Langs []string  `json:"-"` // предпочитаемые языки перевода по убыванию приоритета
 }
 
 type UpdateSongRequest struct {
@@ -20,27 +20,193 @@ type UpdateSongRequest struct {
 	ReleaseDate string `json:"release_date,omitempty"`
 	Text        string `json:"text,omitempty"`
 	Link        string `json:"link,omitempty"`
+	// blank_line, section_headers, fixed_lines:N или default, чтобы вернуть глобальную
+	SplitStrategy string `json:"split_strategy,omitempty"`
+	// Версии из If-Match, с которыми разрешено изменение
+	Precondition Precondition `json:"-"`
 }
 
 type DeleteSongByIdRequest struct {
-	Id uuid.UUID `json:"id" validate:"required,uuid"`
+	Id           uuid.UUID    `json:"id" validate:"required,uuid"`
+	Precondition Precondition `json:"-"`
 }
 
-type FilteredRequest struct {
-	Title       string `json:"title,omitempty"`
-	GroupName   string `json:"group_name,omitempty"`
-	ReleaseDate string `json:"release_date,omitempty"`
-	Text        string `json:"text,omitempty"`
-	Link        string `json:"link,omitempty"`
+// SongPatch - изменения песни из JSON Merge Patch (RFC 7386). nil - поле
+// не передано, пустая строка - поле очищено через null.
+type SongPatch struct {
+	GroupName     *string
+	Title         *string
+	ReleaseDate   *string
+	Text          *string
+	Link          *string
+	SplitStrategy *string
+	Precondition  Precondition
+}
+
+// Precondition - условие из заголовка If-Match: список версий песни
+// или Any для "If-Match: *".
+type Precondition struct {
+	Versions []int
+	Any      bool
 }
 
-type AddVersesRequest struct {
-	Verses []string    `json:"verses"`
-	Song   models.Song `json:"song"`
+func (p Precondition) Matches(version int) bool {
+	if p.Any {
+		return true
+	}
+	for _, v := range p.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	MatchContains = "contains"
+	MatchPrefix   = "prefix"
+	MatchExact    = "exact"
+)
+
+// FilteredRequest собирается из query-параметров GET /api/song.
+// Title и Groups сравниваются без учёта регистра в режиме Match.
+type FilteredRequest struct {
+	Title        string   `json:"title,omitempty"`
+	Groups       []string `json:"group,omitempty"`
+	ReleasedFrom string   `json:"released_from,omitempty"`
+	ReleasedTo   string   `json:"released_to,omitempty"`
+	Query        string   `json:"q,omitempty"`
+	Text         string   `json:"text,omitempty"`
+	Link         string   `json:"link,omitempty"`
+	Match        string   `json:"match,omitempty"`
+	Limit        int      `json:"limit,omitempty"`
+	Cursor       string   `json:"cursor,omitempty"`
+	Sort         string   `json:"sort,omitempty"`
 }
 
 type PaginatedVersesRequest struct {
-	SongId uuid.UUID `json:"-" validate:"required,uuid"`
+	SongId      uuid.UUID `json:"-" validate:"required,uuid"`
+	Page        int       `json:"page" validate:"required,min=1"`
+	Limit       int       `json:"limit" validate:"required,min=1"`                                                              //куплетов на страницу
+	SectionType string    `json:"section_type,omitempty" validate:"omitempty,oneof=verse chorus pre_chorus bridge intro outro"` // пусто - все секции
+	Langs       []string  `json:"-"`                                                                                            // предпочитаемые языки перевода
+	SideBySide  bool      `json:"side_by_side,omitempty"`                                                                       // оригинал и перевод рядом
+}
+
+type LyricsSearchRequest struct {
+	Query  string `json:"q"`
+	Lang   string `json:"lang,omitempty"` // ru, en или пусто - искать на обоих языках
+	Limit  int    `json:"limit,omitempty"`
+	Offset int    `json:"offset,omitempty"`
+}
+
+type CreateGroupRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+type RenameGroupRequest struct {
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+type GroupsListRequest struct {
+	Page  int `json:"page" validate:"required,min=1"`
+	Limit int `json:"limit" validate:"required,min=1"`
+}
+
+type TrashListRequest struct {
+	Page  int `json:"page" validate:"required,min=1"`
+	Limit int `json:"limit" validate:"required,min=1"`
+}
+
+type RevisionsListRequest struct {
+	SongId uuid.UUID `json:"-" validate:"required"`
 	Page   int       `json:"page" validate:"required,min=1"`
-	Limit  int       `json:"limit" validate:"required,min=1"` //куплетов на страницу
+	Limit  int       `json:"limit" validate:"required,min=1"`
+}
+
+// RevisionDiffRequest - сравнение двух ревизий песни. Нулевой To - последняя
+// ревизия, нулевой From - предыдущая перед To.
+type RevisionDiffRequest struct {
+	SongId uuid.UUID `json:"-" validate:"required"`
+	From   int       `json:"from" validate:"min=0"`
+	To     int       `json:"to" validate:"min=0"`
+}
+
+type RevertSongRequest struct {
+	SongId       uuid.UUID    `json:"-" validate:"required"`
+	Revision     int          `json:"revision" validate:"required,min=1"`
+	Precondition Precondition `json:"-"`
+}
+
+type MergeGroupsRequest struct {
+	SourceIds []uuid.UUID `json:"source_ids" validate:"required,min=1,dive,required"`
+}
+
+type UpdateVerseRequest struct {
+	Text        string `json:"text" validate:"required"`
+	SectionType string `json:"section_type,omitempty" validate:"omitempty,oneof=verse chorus pre_chorus bridge intro outro"` // пусто - по заголовку в тексте
+}
+
+type InsertVerseRequest struct {
+	Text        string `json:"text" validate:"required"`
+	Position    int    `json:"position" validate:"min=0"`                                                                    // 0 - добавить в конец
+	SectionType string `json:"section_type,omitempty" validate:"omitempty,oneof=verse chorus pre_chorus bridge intro outro"` // пусто - по заголовку в тексте
+}
+
+type MoveVerseRequest struct {
+	To int `json:"to" validate:"required,min=1"`
+}
+
+type PreviewVersesRequest struct {
+	Text     string `json:"text" validate:"required"`
+	Strategy string `json:"strategy,omitempty"` // пусто - глобальная стратегия
+}
+
+type ImportLrcRequest struct {
+	Lrc string `json:"lrc" validate:"required"`
+	// Заменить текст песни текстом из LRC. Если текста у песни нет, он заменяется всегда
+	ReplaceText bool `json:"replace_text,omitempty"`
+}
+
+type LyricsAtRequest struct {
+	SongId uuid.UUID `json:"-" validate:"required"`
+	Time   float64   `json:"t" validate:"min=0"`           // позиция воспроизведения в секундах
+	Next   int       `json:"next" validate:"min=0,max=20"` // сколько следующих строк вернуть
+}
+
+type SaveTranslationRequest struct {
+	Title string `json:"title,omitempty" validate:"max=255"`
+	// Текст перевода разбивается на куплеты той же стратегией, что и оригинал.
+	// Вместо него можно передать куплеты явно
+	Text   string   `json:"text,omitempty" validate:"required_without=Verses"`
+	Verses []string `json:"verses,omitempty" validate:"omitempty,dive,required"`
+}
+
+// ImportRow - строка файла импорта. Error заполняется, если строку не
+// удалось разобрать или она не прошла валидацию.
+type ImportRow struct {
+	Line    int
+	Request CreateSongRequest
+	Error   string
+}
+
+// Форматы экспорта библиотеки
+const (
+	ExportNDJSON = "ndjson"
+	ExportCSV    = "csv"
+	ExportJSON   = "json"
+)
+
+// ExportRequest собирается из query-параметров GET /api/export. Фильтры те
+// же, что у поиска песен, но без курсора и лимита.
+type ExportRequest struct {
+	Filter     FilteredRequest
+	Format     string
+	WithVerses bool
+	WithGroups bool
+}
+
+type IssueApiKeyRequest struct {
+	Name   string   `json:"name" validate:"required,min=1,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=songs:read songs:write admin"`
 }
@@ -1,19 +1,42 @@
 package dto
 
 import (
+	"github.com/google/uuid"
 	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"time"
 )
 
 type StandartResponse struct {
-	Song    models.Song `json:"song,omitempty"`
-	Message string      `json:"message,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Song models.Song `json:"song,omitempty"`
+	// Язык перевода, которым заменены название и текст; пусто - оригинал
+	Lang    string `json:"lang,omitempty"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
 }
 
 type SongsResponse struct {
-	Songs   []models.Song `json:"songs,omitempty"`
-	Message string        `json:"message,omitempty"`
-	Error   string        `json:"error,omitempty"`
+	Songs      []models.Song `json:"songs,omitempty"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	HasMore    bool          `json:"has_more"`
+	Message    string        `json:"message,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+type FieldError struct {
+	Field string `json:"field"`
+	Error string `json:"error"`
+}
+
+// Problem - тело ответа с ошибкой в формате RFC 7807 (application/problem+json).
+// Code стабилен, по нему клиенту стоит различать ошибки, detail - для людей.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Fields   []FieldError `json:"fields,omitempty"`
 }
 
 type SongTextResponse struct {
@@ -34,8 +57,282 @@ type SongTextPaginatedResponse struct {
 }
 
 type PaginatedVersesResponse struct {
-	Verses []string `json:"verses"`
-	Page   int      `json:"page"`
-	Limit  int      `json:"limit"`
-	Total  int      `json:"total"`
+	Verses      []models.Verse `json:"verses"`
+	Page        int            `json:"page"`
+	Limit       int            `json:"limit"`
+	TotalVerses int            `json:"total_verses"`
+	TotalPages  int            `json:"total_pages"`
+	HasNext     bool           `json:"has_next"`
+	HasPrev     bool           `json:"has_prev"`
+	Lang        string         `json:"lang,omitempty"` // язык перевода, пусто - оригинал
+	Message     string         `json:"message,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+type LyricsSearchResponse struct {
+	Results []LyricsSearchResult `json:"results"`
+	Message string               `json:"message,omitempty"`
+	Error   string               `json:"error,omitempty"`
+}
+
+type LyricsSearchResult struct {
+	Song   models.Song  `json:"song"`
+	Rank   float64      `json:"rank"`
+	Verses []VerseMatch `json:"verses"`
+}
+
+type VerseMatch struct {
+	VerseNumber int    `json:"verse_number"`
+	Snippet     string `json:"snippet"`
+}
+
+type GroupResponse struct {
+	Group   models.Group `json:"group,omitempty"`
+	Message string       `json:"message,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+type MergeGroupsResponse struct {
+	Group      models.Group `json:"group"`
+	Aliases    []string     `json:"aliases"`
+	MovedSongs int64        `json:"moved_songs"`
+	Message    string       `json:"message,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+type GroupSummary struct {
+	Id        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	SongCount int       `json:"song_count"`
+}
+
+type GroupsResponse struct {
+	Groups  []GroupSummary `json:"groups"`
+	Page    int            `json:"page"`
+	Limit   int            `json:"limit"`
+	Total   int            `json:"total"`
+	Message string         `json:"message,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+type GroupDetailResponse struct {
+	Group       models.Group        `json:"group"`
+	Aliases     []string            `json:"aliases"`
+	SongCount   int                 `json:"song_count"`
+	Discography []DiscographyRecord `json:"discography"`
+	Message     string              `json:"message,omitempty"`
+	Error       string              `json:"error,omitempty"`
+}
+
+type DiscographyRecord struct {
+	SongId      uuid.UUID `json:"song_id"`
+	Title       string    `json:"title"`
+	ReleaseDate string    `json:"release_date"`
+	Link        string    `json:"link"`
+}
+
+type VerseResponse struct {
+	Verse   models.Verse `json:"verse,omitempty"`
+	Lang    string       `json:"lang,omitempty"` // язык перевода, пусто - оригинал
+	Message string       `json:"message,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+type RebuildVersesResponse struct {
+	Processed int         `json:"processed"`
+	Failed    []uuid.UUID `json:"failed,omitempty"`
+	Message   string      `json:"message,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// RebuildProgressLine - строка отчёта о пересборке куплетов всей библиотеки,
+// пишется после каждой пачки песен. Счётчики накопительные.
+type RebuildProgressLine struct {
+	Processed int `json:"processed"`
+	Failed    int `json:"failed"`
+}
+
+// RebuildSummaryLine - последняя строка отчёта о пересборке куплетов.
+type RebuildSummaryLine struct {
+	Summary RebuildVersesResponse `json:"summary"`
+}
+
+type PreviewVersesResponse struct {
+	Strategy string   `json:"strategy"`
+	Verses   []string `json:"verses"`
+	Message  string   `json:"message,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+type TimedLyricsResponse struct {
+	Lines        []models.LyricLine `json:"lines"`
+	TimedLines   int                `json:"timed_lines"`
+	UntimedLines int                `json:"untimed_lines"`
+	Message      string             `json:"message,omitempty"`
+	Error        string             `json:"error,omitempty"`
+}
+
+type LyricsAtResponse struct {
+	Time    float64            `json:"t"`
+	Current *models.LyricLine  `json:"current"` // nil, если строка ещё не началась
+	Next    []models.LyricLine `json:"next"`
+	Message string             `json:"message,omitempty"`
+	Error   string             `json:"error,omitempty"`
+}
+
+type TranslationSummary struct {
+	Lang       string    `json:"lang"`
+	Title      string    `json:"title,omitempty"`
+	VerseCount int       `json:"verse_count"`
+	Stale      bool      `json:"stale"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type TranslationsResponse struct {
+	Translations []TranslationSummary `json:"translations"`
+	Message      string               `json:"message,omitempty"`
+	Error        string               `json:"error,omitempty"`
+}
+
+type TranslationResponse struct {
+	Translation models.SongTranslation    `json:"translation"`
+	Verses      []models.VerseTranslation `json:"verses"`
+	Message     string                    `json:"message,omitempty"`
+	Error       string                    `json:"error,omitempty"`
+}
+
+// Статусы строк импорта
+const (
+	ImportCreated         = "created"
+	ImportDuplicate       = "duplicate"
+	ImportMetadataMissing = "metadata_missing"
+	ImportInvalid         = "invalid"
+	ImportFailed          = "failed"
+	ImportRateLimited     = "rate_limited"
+)
+
+// ImportRowResult - результат импорта одной строки. Строки обрабатываются
+// параллельно, поэтому результаты приходят не по порядку - сопоставлять
+// их нужно по Line.
+type ImportRowResult struct {
+	Line   int        `json:"line"`
+	Group  string     `json:"group,omitempty"`
+	Title  string     `json:"title,omitempty"`
+	Status string     `json:"status"`
+	SongId *uuid.UUID `json:"song_id,omitempty"`
+	Code   string     `json:"code,omitempty"`
+	Error  string     `json:"error,omitempty"`
+}
+
+type ImportSummary struct {
+	Total           int `json:"total"`
+	Created         int `json:"created"`
+	Duplicate       int `json:"duplicate"`
+	MetadataMissing int `json:"metadata_missing"`
+	Invalid         int `json:"invalid"`
+	Failed          int `json:"failed"`
+	RateLimited     int `json:"rate_limited"`
+}
+
+func (s *ImportSummary) Add(status string) {
+	s.Total++
+	switch status {
+	case ImportCreated:
+		s.Created++
+	case ImportDuplicate:
+		s.Duplicate++
+	case ImportMetadataMissing:
+		s.MetadataMissing++
+	case ImportInvalid:
+		s.Invalid++
+	case ImportRateLimited:
+		s.RateLimited++
+	default:
+		s.Failed++
+	}
+}
+
+// ImportSummaryLine - последняя строка отчёта об импорте.
+type ImportSummaryLine struct {
+	Summary ImportSummary `json:"summary"`
+}
+
+// ExportedSong - песня в экспорте, с куплетами, если они запрошены.
+type ExportedSong struct {
+	models.Song
+	Verses []models.Verse `json:"verses,omitempty"`
+}
+
+// ExportRecord - строка экспорта в формате NDJSON: группа или песня.
+type ExportRecord struct {
+	Type  string        `json:"type"`
+	Group *models.Group `json:"group,omitempty"`
+	Song  *ExportedSong `json:"song,omitempty"`
+}
+
+// TrashedSong - песня в корзине и момент, после которого её удалит очистка.
+type TrashedSong struct {
+	models.Song
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+type TrashResponse struct {
+	Songs   []TrashedSong `json:"songs"`
+	Page    int           `json:"page"`
+	Limit   int           `json:"limit"`
+	Total   int           `json:"total"`
+	Message string        `json:"message,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+type RevisionsResponse struct {
+	Revisions []models.SongRevision `json:"revisions"`
+	Page      int                   `json:"page"`
+	Limit     int                   `json:"limit"`
+	Total     int                   `json:"total"`
+	Message   string                `json:"message,omitempty"`
+	Error     string                `json:"error,omitempty"`
+}
+
+// FieldChange - поле песни, отличающееся в двух ревизиях.
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// DiffLine - строка построчного диффа текста. op: equal, insert или delete;
+// from_line и to_line - номер строки в старом и новом тексте.
+type DiffLine struct {
+	Op       string `json:"op"`
+	Text     string `json:"text"`
+	FromLine int    `json:"from_line,omitempty"`
+	ToLine   int    `json:"to_line,omitempty"`
+}
+
+type RevisionDiffResponse struct {
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Fields  []FieldChange `json:"fields"`
+	Lines   []DiffLine    `json:"lines"`
+	Added   int           `json:"added"`
+	Removed int           `json:"removed"`
+	Message string        `json:"message,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// ApiKeyResponse - ключ и, при выпуске и ротации, его секрет. Секрет
+// показывается только в этом ответе, сервер хранит лишь хеш.
+type ApiKeyResponse struct {
+	Key     models.ApiKey `json:"key"`
+	Token   string        `json:"token,omitempty"`
+	Message string        `json:"message,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+type ApiKeysResponse struct {
+	Keys    []models.ApiKey `json:"keys"`
+	Message string          `json:"message,omitempty"`
+	Error   string          `json:"error,omitempty"`
 }
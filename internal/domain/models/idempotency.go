@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// IdempotencyRecord - запрос с заголовком Idempotency-Key и ответ на него.
+// StatusCode равен нулю, пока первый запрос ещё выполняется. Owner - клиент,
+// приславший ключ: у каждого клиента свои ключи.
+type IdempotencyRecord struct {
+	Owner       string
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Headers     map[string]string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+func (r IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
@@ -0,0 +1,17 @@
+package models
+
+import "github.com/google/uuid"
+
+// LyricLine - строка текста песни с таймингом для синхронизированного показа.
+type LyricLine struct {
+	Id         uuid.UUID `json:"id"`
+	SongId     uuid.UUID `json:"song_id"`
+	LineNumber int       `json:"line_number"`
+	// Время начала строки в миллисекундах, пусто - тайминг неизвестен
+	TimeMs *int64 `json:"time_ms,omitempty"`
+	Text   string `json:"text"`
+}
+
+// LyricsRealigner переносит тайминги existing на строки нового текста песни
+// text, например пересобранного из куплетов после правки.
+type LyricsRealigner func(songId uuid.UUID, existing []LyricLine, text string) []LyricLine
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// SongSnapshot - состояние полей песни в момент ревизии.
+type SongSnapshot struct {
+	GroupId       uuid.UUID `json:"group_id"`
+	GroupName     string    `json:"group_name"`
+	Title         string    `json:"title"`
+	ReleaseDate   string    `json:"release_date"`
+	Text          string    `json:"text"`
+	Link          string    `json:"link"`
+	SplitStrategy string    `json:"split_strategy,omitempty"`
+}
+
+// SongRevision - запись истории песни. Номера ревизий идут подряд с 1 и не
+// совпадают с версией песни: перевод или корзина меняют версию, но не поля.
+type SongRevision struct {
+	SongId        uuid.UUID    `json:"song_id"`
+	Revision      int          `json:"revision"`
+	Snapshot      SongSnapshot `json:"snapshot"`
+	ChangedFields []string     `json:"changed_fields"`
+	Actor         string       `json:"actor,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
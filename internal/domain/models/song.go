@@ -13,6 +13,10 @@ type Song struct {
 	ReleaseDate string    `json:"release_date"`
 	Text        string    `json:"text"`
 	Link        string    `json:"link"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	// Стратегия разбиения на куплеты, пусто - глобальная по умолчанию
+	SplitStrategy string `json:"split_strategy,omitempty"`
+	// Версия растёт при каждом изменении песни и служит ETag
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// SongTranslation - перевод названия и текста песни на язык Lang.
+// Stale - текст оригинала изменился после сохранения перевода.
+type SongTranslation struct {
+	Id        uuid.UUID `json:"id"`
+	SongId    uuid.UUID `json:"song_id"`
+	Lang      string    `json:"lang"`
+	Title     string    `json:"title,omitempty"`
+	Text      string    `json:"text"`
+	Stale     bool      `json:"stale"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// VerseTranslation - перевод куплета с номером VerseNumber оригинала.
+type VerseTranslation struct {
+	VerseNumber int    `json:"verse_number"`
+	Lang        string `json:"lang"`
+	Text        string `json:"text"`
+}
@@ -2,9 +2,32 @@ package models
 
 import "github.com/google/uuid"
 
+// VerseSeparator разделяет куплеты, когда текст песни собирается из них.
+const VerseSeparator = "\n\n"
+
+// Типы секций песни. Совпадают с CHECK в таблице verses.
+const (
+	SectionVerse     = "verse"
+	SectionChorus    = "chorus"
+	SectionPreChorus = "pre_chorus"
+	SectionBridge    = "bridge"
+	SectionIntro     = "intro"
+	SectionOutro     = "outro"
+)
+
+// VersesBuilder разбивает текст песни на куплеты. Репозиторий вызывает его
+// в транзакции с песней, прочитанной под блокировкой, чтобы куплеты
+// собирались из текущего текста.
+type VersesBuilder func(song Song) []Verse
+
 type Verse struct {
 	Id          uuid.UUID `json:"id"`
 	SongId      uuid.UUID `json:"song_id"`
 	VerseNumber int       `json:"verse_number"`
 	Text        string    `json:"text"`
+	SectionType string    `json:"section_type"`
+	// Id куплета, который повторяет этот (например, повтор припева)
+	RepeatOf *uuid.UUID `json:"repeat_of,omitempty"`
+	// Перевод куплета, если он запрошен рядом с оригиналом
+	Translation *VerseTranslation `json:"translation,omitempty"`
 }
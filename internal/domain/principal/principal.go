@@ -0,0 +1,74 @@
+package principal
+
+import "context"
+
+// Role - уровень доступа. Каждая следующая роль включает права предыдущих.
+type Role int
+
+const (
+	RoleNone Role = iota
+	RoleViewer
+	RoleEditor
+	RoleAdmin
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return "viewer"
+	case RoleEditor:
+		return "editor"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+// ParseRole разбирает название роли; неизвестные названия дают RoleNone.
+func ParseRole(name string) Role {
+	switch name {
+	case "viewer":
+		return RoleViewer
+	case "editor":
+		return RoleEditor
+	case "admin":
+		return RoleAdmin
+	default:
+		return RoleNone
+	}
+}
+
+// Виды субъектов запроса
+const (
+	KindApiKey = "key"
+	KindUser   = "user"
+)
+
+// Principal - кто выполняет запрос: API-ключ или пользователь из JWT.
+type Principal struct {
+	Kind string
+	Name string
+	Role Role
+}
+
+// Actor - как субъект записывается автором изменений, например "user:alice".
+func (p Principal) Actor() string {
+	return p.Kind + ":" + p.Name
+}
+
+func (p Principal) Allows(role Role) bool {
+	return p.Role >= role
+}
+
+type ctxKey struct{}
+
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext возвращает субъект запроса; ok = false для анонимного запроса.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(Principal)
+	return p, ok
+}
@@ -4,10 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
 	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/metrics"
 	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
 	"net/http"
 	"net/url"
+	"time"
+)
+
+var (
+	ErrMetadataNotFound    = apperr.New(apperr.NotFound, "metadata_not_found", "song is unknown to the metadata service")
+	ErrMetadataUnavailable = apperr.New(apperr.Unavailable, "metadata_unavailable", "metadata service is unavailable")
 )
 
 type MusicMetadataRepo struct {
@@ -36,13 +44,21 @@ func (r *MusicMetadataRepo) GetSongDetails(ctx context.Context, request dto.Crea
 
 	apiUrl := fmt.Sprintf("%s/info?group=%s&song=%s", r.ApiUrl, url.QueryEscape(request.Group), url.QueryEscape(request.Title))
 
-	r.Logger.Info.Info("Requesting song metadata from external API",
+	r.Logger.Info.InfoContext(ctx, "Requesting song metadata from external API",
 		"group", request.Group,
 		"title", request.Title)
 
+	// result - итог запроса для метрик, меняется на причину ошибки
+	result := metrics.MetadataOk
+	start := time.Now()
+	defer func() {
+		metrics.ObserveMetadataRequest(result, time.Since(start))
+	}()
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiUrl, nil)
 	if err != nil {
-		r.Logger.Info.Error("Failed to create external API request",
+		result = metrics.MetadataRequestError
+		r.Logger.Info.ErrorContext(ctx, "Failed to create external API request",
 			"error", err,
 			"url", apiUrl)
 		return dto.SongDetailResponse{}, fmt.Errorf("failed to create request: %w", err)
@@ -50,29 +66,39 @@ func (r *MusicMetadataRepo) GetSongDetails(ctx context.Context, request dto.Crea
 
 	resp, err := r.client.Do(req)
 	if err != nil {
-		r.Logger.Info.Error("Failed to send request to external API",
+		result = metrics.MetadataTransportError
+		if ctx.Err() != nil {
+			result = metrics.MetadataCanceled
+		}
+		r.Logger.Info.ErrorContext(ctx, "Failed to send request to external API",
 			"error", err,
 			"url", apiUrl)
-		return dto.SongDetailResponse{}, fmt.Errorf("failed to send request: %w", err)
+		return dto.SongDetailResponse{}, fmt.Errorf("%w: failed to send request: %w", ErrMetadataUnavailable, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		r.Logger.Info.Error("External API returned non-OK status",
+		r.Logger.Info.ErrorContext(ctx, "External API returned non-OK status",
 			"status_code", resp.StatusCode,
 			"url", apiUrl)
-		return dto.SongDetailResponse{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		if resp.StatusCode == http.StatusNotFound {
+			result = metrics.MetadataNotFound
+			return dto.SongDetailResponse{}, ErrMetadataNotFound
+		}
+		result = metrics.MetadataBadStatus
+		return dto.SongDetailResponse{}, fmt.Errorf("%w: unexpected status code: %d", ErrMetadataUnavailable, resp.StatusCode)
 	}
 
 	var songDetails dto.SongDetailResponse
 	if err := json.NewDecoder(resp.Body).Decode(&songDetails); err != nil {
-		r.Logger.Info.Error("Failed to decode response from external API",
+		result = metrics.MetadataDecodeError
+		r.Logger.Info.ErrorContext(ctx, "Failed to decode response from external API",
 			"error", err,
 			"url", apiUrl)
-		return dto.SongDetailResponse{}, fmt.Errorf("failed to decode response: %w", err)
+		return dto.SongDetailResponse{}, fmt.Errorf("%w: failed to decode response: %w", ErrMetadataUnavailable, err)
 	}
 
-	r.Logger.Info.Info("Successfully retrieved song metadata from external API",
+	r.Logger.Info.InfoContext(ctx, "Successfully retrieved song metadata from external API",
 		"group", request.Group,
 		"title", request.Title)
 
@@ -0,0 +1,129 @@
+// Package metrics описывает метрики сервиса в формате Prometheus. Метрики
+// регистрируются в реестре по умолчанию вместе с метриками Go-рантайма и
+// процесса и отдаются на /metrics.
+package metrics
+
+import (
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const namespace = "music_library"
+
+// UnmatchedRoute - значение метки route для запросов, не попавших ни в один
+// маршрут: путь в метке дал бы неограниченное число рядов.
+const UnmatchedRoute = "unmatched"
+
+var knownMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// Результаты запросов к сервису метаданных
+const (
+	MetadataOk             = "ok"
+	MetadataNotFound       = "not_found"
+	MetadataBadStatus      = "bad_status"
+	MetadataTransportError = "transport_error"
+	MetadataCanceled       = "canceled"
+	MetadataDecodeError    = "decode_error"
+	MetadataRequestError   = "request_error"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route pattern and status.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route pattern and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	metadataRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "metadata_requests_total",
+		Help:      "Requests to the external metadata API by result.",
+	}, []string{"result"})
+
+	metadataRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "metadata_request_duration_seconds",
+		Help:      "External metadata API latency by result.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"result"})
+
+	songsCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "songs_created_total",
+		Help:      "Songs created through the API or import.",
+	})
+
+	duplicatesRejected = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "song_duplicates_rejected_total",
+		Help:      "Song creations rejected because the song already exists.",
+	})
+
+	versesProcessed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "verses_processed_total",
+		Help:      "Verses written by splitting song texts or editing single verses.",
+	})
+)
+
+// RegisterDB добавляет метрики пула соединений из sql.DB.Stats():
+// открытые, занятые и свободные соединения, ожидания и закрытия.
+func RegisterDB(db *sql.DB, name string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler отдаёт метрики в текстовом формате Prometheus.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveHTTPRequest учитывает обработанный запрос. Метод и маршрут
+// приходят от клиента, поэтому незнакомые значения сводятся к одному, чтобы
+// число рядов оставалось ограниченным.
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	if !slices.Contains(knownMethods, method) {
+		method = "OTHER"
+	}
+	if route == "" {
+		route = UnmatchedRoute
+	}
+	labels := []string{method, route, strconv.Itoa(status)}
+	httpRequests.WithLabelValues(labels...).Inc()
+	httpRequestDuration.WithLabelValues(labels...).Observe(duration.Seconds())
+}
+
+func ObserveMetadataRequest(result string, duration time.Duration) {
+	metadataRequests.WithLabelValues(result).Inc()
+	metadataRequestDuration.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+func SongCreated() {
+	songsCreated.Inc()
+}
+
+func DuplicateRejected() {
+	duplicatesRejected.Inc()
+}
+
+// VersesProcessed учитывает куплеты, записанные в базу: вызывается после
+// успешного сохранения, а не при разбиении текста.
+func VersesProcessed(count int) {
+	versesProcessed.Add(float64(count))
+}
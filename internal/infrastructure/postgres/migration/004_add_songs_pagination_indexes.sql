@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE INDEX IF NOT EXISTS idx_songs_created_at_id ON songs ((COALESCE(created_at, 'epoch'::timestamp)), id);
+CREATE INDEX IF NOT EXISTS idx_songs_title_id ON songs (title, id);
+CREATE INDEX IF NOT EXISTS idx_songs_release_date_id ON songs (release_date, id);
+CREATE INDEX IF NOT EXISTS idx_songs_group_name_id ON songs ((COALESCE(group_name, '')), id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_songs_group_name_id;
+DROP INDEX IF EXISTS idx_songs_release_date_id;
+DROP INDEX IF EXISTS idx_songs_title_id;
+DROP INDEX IF EXISTS idx_songs_created_at_id;
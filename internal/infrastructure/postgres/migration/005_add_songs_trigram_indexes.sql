@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_songs_title_trgm ON songs USING GIN (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_songs_group_name_trgm ON songs USING GIN (group_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_songs_title_lower ON songs (lower(title));
+CREATE INDEX IF NOT EXISTS idx_songs_group_name_lower ON songs (lower(group_name));
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_songs_group_name_lower;
+DROP INDEX IF EXISTS idx_songs_title_lower;
+DROP INDEX IF EXISTS idx_songs_group_name_trgm;
+DROP INDEX IF EXISTS idx_songs_title_trgm;
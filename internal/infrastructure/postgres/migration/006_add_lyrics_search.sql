@@ -0,0 +1,24 @@
+-- +goose Up
+-- Тексты приходят из внешнего API с экранированными переводами строк ("\n"),
+-- поэтому перед разбором заменяем их на настоящие, иначе слова склеиваются.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english'::regconfig, coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('russian'::regconfig, coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english'::regconfig, replace(text, '\n', E'\n')), 'B') ||
+        setweight(to_tsvector('russian'::regconfig, replace(text, '\n', E'\n')), 'B')
+    ) STORED;
+CREATE INDEX IF NOT EXISTS idx_songs_search_vector ON songs USING GIN (search_vector);
+
+ALTER TABLE verses ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        to_tsvector('english'::regconfig, replace(text, '\n', E'\n')) ||
+        to_tsvector('russian'::regconfig, replace(text, '\n', E'\n'))
+    ) STORED;
+CREATE INDEX IF NOT EXISTS idx_verses_search_vector ON verses USING GIN (search_vector);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_verses_search_vector;
+ALTER TABLE verses DROP COLUMN IF EXISTS search_vector;
+DROP INDEX IF EXISTS idx_songs_search_vector;
+ALTER TABLE songs DROP COLUMN IF EXISTS search_vector;
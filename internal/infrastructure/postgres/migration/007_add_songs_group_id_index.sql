@@ -0,0 +1,5 @@
+-- +goose Up
+CREATE INDEX IF NOT EXISTS idx_songs_group_id ON songs (group_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_songs_group_id;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS group_aliases (
+                                             id UUID PRIMARY KEY,
+                                             group_id UUID NOT NULL,
+                                             alias VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+    );
+CREATE UNIQUE INDEX IF NOT EXISTS idx_group_aliases_alias_lower ON group_aliases (lower(alias));
+CREATE INDEX IF NOT EXISTS idx_group_aliases_group_id ON group_aliases (group_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS group_aliases;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Ограничение отложенное: при перенумерации куплетов номера временно совпадают
+-- внутри одного UPDATE, проверка выполняется при коммите транзакции.
+ALTER TABLE verses ADD CONSTRAINT verses_song_id_verse_number_key
+    UNIQUE (song_id, verse_number) DEFERRABLE INITIALLY DEFERRED;
+
+-- +goose Down
+ALTER TABLE verses DROP CONSTRAINT IF EXISTS verses_song_id_verse_number_key;
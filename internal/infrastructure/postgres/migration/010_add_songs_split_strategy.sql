@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS split_strategy VARCHAR(32);
+
+-- +goose Down
+ALTER TABLE songs DROP COLUMN IF EXISTS split_strategy;
@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE verses
+    ADD COLUMN IF NOT EXISTS section_type VARCHAR(16) NOT NULL DEFAULT 'verse',
+    ADD COLUMN IF NOT EXISTS repeat_of UUID REFERENCES verses(id) ON DELETE SET NULL;
+
+ALTER TABLE verses ADD CONSTRAINT verses_section_type_check
+    CHECK (section_type IN ('verse', 'chorus', 'pre_chorus', 'bridge', 'intro', 'outro'));
+
+CREATE INDEX IF NOT EXISTS idx_verses_song_id_section_type ON verses (song_id, section_type);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_verses_song_id_section_type;
+ALTER TABLE verses DROP CONSTRAINT IF EXISTS verses_section_type_check;
+ALTER TABLE verses
+    DROP COLUMN IF EXISTS repeat_of,
+    DROP COLUMN IF EXISTS section_type;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- Строки текста песни с таймингами из LRC. time_ms пустой у строк, для
+-- которых тайминг потерялся после правки текста.
+CREATE TABLE IF NOT EXISTS lyric_lines (
+                                           id UUID PRIMARY KEY,
+                                           song_id UUID NOT NULL,
+                                           line_number INTEGER NOT NULL,
+                                           time_ms BIGINT,
+                                           text TEXT NOT NULL,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE,
+    UNIQUE (song_id, line_number)
+    );
+CREATE INDEX IF NOT EXISTS idx_lyric_lines_song_id_time_ms ON lyric_lines (song_id, time_ms);
+
+-- +goose Down
+DROP TABLE IF EXISTS lyric_lines;
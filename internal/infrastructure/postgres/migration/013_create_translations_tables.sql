@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS song_translations (
+                                                 id UUID PRIMARY KEY,
+                                                 song_id UUID NOT NULL,
+                                                 lang VARCHAR(35) NOT NULL,
+    title VARCHAR(255) NOT NULL DEFAULT '',
+    text TEXT NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITHOUT TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (song_id) REFERENCES songs(id) ON DELETE CASCADE,
+    UNIQUE (song_id, lang)
+    );
+
+-- Переводы куплетов привязаны к номеру куплета оригинала. Ограничение
+-- отложенное, как и у verses: номера сдвигаются вместе с куплетами.
+CREATE TABLE IF NOT EXISTS verse_translations (
+                                                  id UUID PRIMARY KEY,
+                                                  song_id UUID NOT NULL,
+                                                  lang VARCHAR(35) NOT NULL,
+    verse_number INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    FOREIGN KEY (song_id, lang) REFERENCES song_translations(song_id, lang) ON DELETE CASCADE,
+    CONSTRAINT verse_translations_song_lang_number_key
+        UNIQUE (song_id, lang, verse_number) DEFERRABLE INITIALLY DEFERRED
+    );
+CREATE INDEX IF NOT EXISTS idx_verse_translations_song_id_verse_number ON verse_translations (song_id, verse_number);
+
+-- +goose Down
+DROP TABLE IF EXISTS verse_translations;
+DROP TABLE IF EXISTS song_translations;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Версия песни для оптимистичной блокировки, увеличивается при каждом изменении
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE songs DROP COLUMN IF EXISTS version;
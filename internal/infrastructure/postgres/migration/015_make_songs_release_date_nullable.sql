@@ -0,0 +1,14 @@
+-- +goose Up
+-- Дата релиза может быть неизвестна: PATCH с null очищает её
+ALTER TABLE songs ALTER COLUMN release_date DROP NOT NULL;
+
+-- Курсорная пагинация сортирует по COALESCE(release_date, '-infinity')
+DROP INDEX IF EXISTS idx_songs_release_date_id;
+CREATE INDEX IF NOT EXISTS idx_songs_release_date_id ON songs ((COALESCE(release_date, '-infinity'::date)), id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_songs_release_date_id;
+CREATE INDEX IF NOT EXISTS idx_songs_release_date_id ON songs (release_date, id);
+
+UPDATE songs SET release_date = '1970-01-01' WHERE release_date IS NULL;
+ALTER TABLE songs ALTER COLUMN release_date SET NOT NULL;
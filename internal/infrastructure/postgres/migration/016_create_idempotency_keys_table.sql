@@ -0,0 +1,16 @@
+-- +goose Up
+-- Ответы на запросы с заголовком Idempotency-Key. Пока запрос выполняется,
+-- status_code равен NULL; повтор с тем же ключом получает сохранённый ответ.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+                                                key VARCHAR(255) PRIMARY KEY,
+                                                request_hash CHAR(64) NOT NULL,
+    status_code INTEGER,
+    headers JSONB,
+    body BYTEA,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITHOUT TIME ZONE NOT NULL
+    );
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS idempotency_keys;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- Удалённые песни попадают в корзину и окончательно удаляются по истечении
+-- срока хранения
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITHOUT TIME ZONE;
+CREATE INDEX IF NOT EXISTS idx_songs_deleted_at_id ON songs (deleted_at, id) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+-- Без колонки песни из корзины снова стали бы видны, поэтому они удаляются
+DELETE FROM songs WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS idx_songs_deleted_at_id;
+ALTER TABLE songs DROP COLUMN IF EXISTS deleted_at;
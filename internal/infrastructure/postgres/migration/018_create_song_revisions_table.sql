@@ -0,0 +1,80 @@
+-- +goose Up
+-- История изменений песни: снимок полей после каждого изменения. Ревизии
+-- пишет триггер, поэтому в историю попадают все пути изменения песни:
+-- PUT/PATCH, правка куплетов, импорт LRC, переименование и слияние групп
+CREATE TABLE IF NOT EXISTS song_revisions (
+    song_id UUID NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    snapshot JSONB NOT NULL,
+    changed_fields TEXT[] NOT NULL,
+    -- Автор изменения из настройки транзакции app.actor, если он известен
+    actor VARCHAR(255),
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (song_id, revision)
+);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION song_revision_snapshot(s songs) RETURNS JSONB AS $$
+    SELECT jsonb_build_object(
+        'group_id', s.group_id,
+        'group_name', s.group_name,
+        'title', s.title,
+        'release_date', s.release_date,
+        'text', s.text,
+        'link', s.link,
+        'split_strategy', s.split_strategy
+    );
+$$ LANGUAGE sql IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION record_song_revision() RETURNS TRIGGER AS $$
+DECLARE
+    changed TEXT[] := ARRAY[]::TEXT[];
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        changed := ARRAY['group_id', 'group_name', 'title', 'release_date', 'text', 'link', 'split_strategy'];
+    ELSE
+        IF NEW.group_id IS DISTINCT FROM OLD.group_id THEN changed := array_append(changed, 'group_id'); END IF;
+        IF NEW.group_name IS DISTINCT FROM OLD.group_name THEN changed := array_append(changed, 'group_name'); END IF;
+        IF NEW.title IS DISTINCT FROM OLD.title THEN changed := array_append(changed, 'title'); END IF;
+        IF NEW.release_date IS DISTINCT FROM OLD.release_date THEN changed := array_append(changed, 'release_date'); END IF;
+        IF NEW.text IS DISTINCT FROM OLD.text THEN changed := array_append(changed, 'text'); END IF;
+        IF NEW.link IS DISTINCT FROM OLD.link THEN changed := array_append(changed, 'link'); END IF;
+        IF NEW.split_strategy IS DISTINCT FROM OLD.split_strategy THEN changed := array_append(changed, 'split_strategy'); END IF;
+        -- Удаление в корзину, восстановление и переводы меняют только
+        -- версию и ревизию не создают
+        IF cardinality(changed) = 0 THEN
+            RETURN NEW;
+        END IF;
+    END IF;
+
+    -- Песня заблокирована изменением, поэтому номера ревизий не пересекаются
+    INSERT INTO song_revisions (song_id, revision, snapshot, changed_fields, actor)
+    SELECT NEW.id, COALESCE(max(revision), 0) + 1, song_revision_snapshot(NEW), changed,
+           NULLIF(current_setting('app.actor', true), '')
+    FROM song_revisions
+    WHERE song_id = NEW.id;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER songs_record_revision
+    AFTER INSERT OR UPDATE ON songs
+    FOR EACH ROW EXECUTE FUNCTION record_song_revision();
+
+-- У существующих песен история начинается с текущего состояния
+INSERT INTO song_revisions (song_id, revision, snapshot, changed_fields, created_at)
+SELECT s.id, 1, song_revision_snapshot(s),
+       ARRAY['group_id', 'group_name', 'title', 'release_date', 'text', 'link', 'split_strategy'],
+       COALESCE(s.updated_at, s.created_at, CURRENT_TIMESTAMP)
+FROM songs s
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+DROP TRIGGER IF EXISTS songs_record_revision ON songs;
+DROP FUNCTION IF EXISTS record_song_revision();
+DROP FUNCTION IF EXISTS song_revision_snapshot(songs);
+DROP TABLE IF EXISTS song_revisions;
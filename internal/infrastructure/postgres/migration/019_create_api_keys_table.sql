@@ -0,0 +1,17 @@
+-- +goose Up
+-- API-ключи хранятся только в виде SHA-256: сам ключ показывается один раз
+-- при выпуске. prefix - начало ключа, по нему ключ узнают в списке
+CREATE TABLE IF NOT EXISTS api_keys (
+                                        id UUID PRIMARY KEY,
+                                        name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    rotated_at TIMESTAMP WITHOUT TIME ZONE,
+    last_used_at TIMESTAMP WITHOUT TIME ZONE,
+    revoked_at TIMESTAMP WITHOUT TIME ZONE
+    );
+
+-- +goose Down
+DROP TABLE IF EXISTS api_keys;
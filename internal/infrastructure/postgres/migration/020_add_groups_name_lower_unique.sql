@@ -0,0 +1,30 @@
+-- +goose Up
+-- Группы, названия которых отличаются только регистром, объединяются так же,
+-- как при MergeGroups: песни и алиасы переходят к группе с наибольшим числом
+-- песен, остальные удаляются. Их названия совпадают с оставшимся без учёта
+-- регистра, поэтому алиасы для них не нужны.
+CREATE TEMPORARY TABLE group_duplicates ON COMMIT DROP AS
+SELECT g.id,
+       first_value(g.id) OVER (PARTITION BY lower(g.name) ORDER BY count(s.id) DESC, g.id) AS keeper_id
+FROM groups g
+         LEFT JOIN songs s ON s.group_id = g.id
+GROUP BY g.id, g.name;
+DELETE FROM group_duplicates WHERE id = keeper_id;
+
+UPDATE songs s
+SET group_id = d.keeper_id, group_name = k.name, version = s.version + 1
+FROM group_duplicates d
+         JOIN groups k ON k.id = d.keeper_id
+WHERE s.group_id = d.id;
+
+UPDATE group_aliases a
+SET group_id = d.keeper_id
+FROM group_duplicates d
+WHERE a.group_id = d.id;
+
+DELETE FROM groups g USING group_duplicates d WHERE g.id = d.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_groups_name_lower ON groups (lower(name));
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_groups_name_lower;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Перевод устаревает, когда меняется текст куплетов оригинала: номера и
+-- содержание куплетов перевода больше не соответствуют оригиналу. Такой
+-- перевод не подставляется в ответы, пока его не сохранят заново.
+ALTER TABLE song_translations ADD COLUMN IF NOT EXISTS stale BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE song_translations DROP COLUMN IF EXISTS stale;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Ключ идемпотентности принадлежит клиенту, который его прислал: одинаковые
+-- ключи разных клиентов не должны пересекаться. Ключи, сохранённые до этого,
+-- остаются с пустым владельцем и удаляются по истечении срока.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS owner VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (owner, key);
+
+-- +goose Down
+DELETE FROM idempotency_keys a USING idempotency_keys b
+WHERE a.key = b.key AND a.created_at < b.created_at;
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS owner;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (key);
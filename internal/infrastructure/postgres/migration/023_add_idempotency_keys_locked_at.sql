@@ -0,0 +1,8 @@
+-- +goose Up
+-- Время, когда ключ занял выполняющийся запрос. Если процесс упал, не
+-- освободив ключ, незавершённую запись можно занять заново по истечении
+-- аренды, не дожидаясь expires_at.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now();
+
+-- +goose Down
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS locked_at;
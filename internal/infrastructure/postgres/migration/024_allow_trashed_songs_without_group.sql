@@ -0,0 +1,10 @@
+-- +goose Up
+-- Песни удалённой группы уходят в корзину и теряют ссылку на группу. При
+-- восстановлении группа находится или создаётся заново по group_name.
+ALTER TABLE songs ALTER COLUMN group_id DROP NOT NULL;
+ALTER TABLE songs ADD CONSTRAINT songs_group_id_live CHECK (group_id IS NOT NULL OR deleted_at IS NOT NULL);
+
+-- +goose Down
+DELETE FROM songs WHERE group_id IS NULL;
+ALTER TABLE songs DROP CONSTRAINT IF EXISTS songs_group_id_live;
+ALTER TABLE songs ALTER COLUMN group_id SET NOT NULL;
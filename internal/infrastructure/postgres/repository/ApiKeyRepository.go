@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
+	"time"
+)
+
+var ErrApiKeyNotFound = apperr.New(apperr.NotFound, "api_key_not_found", "api key doesn't exist or is revoked")
+
+const apiKeyColumns = "id, name, prefix, to_jsonb(scopes), created_at, rotated_at, last_used_at, revoked_at"
+
+type ApiKeyRepository struct {
+	db     *sql.DB
+	Logger *logger.Logger
+}
+
+func NewApiKeyRepository(db *sql.DB, logger *logger.Logger) *ApiKeyRepository {
+	return &ApiKeyRepository{
+		db:     db,
+		Logger: logger,
+	}
+}
+
+func (r *ApiKeyRepository) CreateKey(ctx context.Context, key models.ApiKey, keyHash string) (models.ApiKey, error) {
+	query, args, err := squirrel.Insert("api_keys").
+		Columns("id", "name", "prefix", "key_hash", "scopes").
+		Values(key.Id, key.Name, key.Prefix, keyHash, key.Scopes).
+		Suffix("RETURNING " + apiKeyColumns).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for api key creation",
+			"error", err,
+			"key_id", key.Id)
+		return models.ApiKey{}, err
+	}
+
+	created, err := scanApiKey(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to create api key",
+			"error", err,
+			"key_id", key.Id)
+		return models.ApiKey{}, err
+	}
+
+	return created, nil
+}
+
+// GetKeyByHash находит действующий ключ по хешу. Отозванные ключи не находятся.
+func (r *ApiKeyRepository) GetKeyByHash(ctx context.Context, keyHash string) (models.ApiKey, error) {
+	query, args, err := squirrel.Select(apiKeyColumns).
+		From("api_keys").
+		Where(squirrel.Eq{"key_hash": keyHash, "revoked_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for api key lookup",
+			"error", err)
+		return models.ApiKey{}, err
+	}
+
+	key, err := scanApiKey(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ApiKey{}, ErrApiKeyNotFound
+		}
+		r.Logger.Info.ErrorContext(ctx, "Failed to look up api key",
+			"error", err)
+		return models.ApiKey{}, err
+	}
+
+	return key, nil
+}
+
+// ListKeys возвращает все ключи, включая отозванные, начиная с новых.
+func (r *ApiKeyRepository) ListKeys(ctx context.Context) ([]models.ApiKey, error) {
+	query, args, err := squirrel.Select(apiKeyColumns).
+		From("api_keys").
+		OrderBy("created_at DESC", "id").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for api keys list",
+			"error", err)
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to query api keys",
+			"error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.ApiKey{}
+	for rows.Next() {
+		key, err := scanApiKey(rows)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan api key row",
+				"error", err)
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	err = rows.Err()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Error iterating over api key rows",
+			"error", err)
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// RotateKey заменяет секрет действующего ключа. Старый секрет перестаёт
+// работать сразу, имя и права ключа сохраняются.
+func (r *ApiKeyRepository) RotateKey(ctx context.Context, keyId uuid.UUID, prefix, keyHash string) (models.ApiKey, error) {
+	query, args, err := squirrel.Update("api_keys").
+		Set("prefix", prefix).
+		Set("key_hash", keyHash).
+		Set("rotated_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": keyId, "revoked_at": nil}).
+		Suffix("RETURNING " + apiKeyColumns).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for api key rotation",
+			"error", err,
+			"key_id", keyId)
+		return models.ApiKey{}, err
+	}
+
+	key, err := scanApiKey(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ApiKey{}, ErrApiKeyNotFound
+		}
+		r.Logger.Info.ErrorContext(ctx, "Failed to rotate api key",
+			"error", err,
+			"key_id", keyId)
+		return models.ApiKey{}, err
+	}
+
+	return key, nil
+}
+
+func (r *ApiKeyRepository) RevokeKey(ctx context.Context, keyId uuid.UUID) (models.ApiKey, error) {
+	query, args, err := squirrel.Update("api_keys").
+		Set("revoked_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": keyId, "revoked_at": nil}).
+		Suffix("RETURNING " + apiKeyColumns).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for api key revocation",
+			"error", err,
+			"key_id", keyId)
+		return models.ApiKey{}, err
+	}
+
+	key, err := scanApiKey(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.ApiKey{}, ErrApiKeyNotFound
+		}
+		r.Logger.Info.ErrorContext(ctx, "Failed to revoke api key",
+			"error", err,
+			"key_id", keyId)
+		return models.ApiKey{}, err
+	}
+
+	return key, nil
+}
+
+// TouchKey обновляет время последнего использования ключа, если оно старше
+// precision: писать в базу на каждый запрос незачем.
+func (r *ApiKeyRepository) TouchKey(ctx context.Context, keyId uuid.UUID, precision time.Duration) error {
+	query, args, err := squirrel.Update("api_keys").
+		Set("last_used_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": keyId}).
+		Where(squirrel.Or{
+			squirrel.Eq{"last_used_at": nil},
+			squirrel.Expr("last_used_at < now() - make_interval(secs => ?)", precision.Seconds()),
+		}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for api key usage",
+			"error", err,
+			"key_id", keyId)
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to update api key usage",
+			"error", err,
+			"key_id", keyId)
+		return err
+	}
+
+	return nil
+}
+
+func scanApiKey(row rowScanner) (models.ApiKey, error) {
+	var key models.ApiKey
+	var scopes []byte
+	var rotatedAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(
+		&key.Id,
+		&key.Name,
+		&key.Prefix,
+		&scopes,
+		&key.CreatedAt,
+		&rotatedAt,
+		&lastUsedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return models.ApiKey{}, err
+	}
+	if rotatedAt.Valid {
+		key.RotatedAt = &rotatedAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	if err := json.Unmarshal(scopes, &key.Scopes); err != nil {
+		return models.ApiKey{}, err
+	}
+	return key, nil
+}
@@ -3,11 +3,26 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
 	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
 	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
 )
 
+var (
+	ErrGroupNotFound        = apperr.New(apperr.NotFound, "group_not_found", "group doesn't exist")
+	ErrGroupHasSongs        = apperr.New(apperr.Conflict, "group_has_songs", "group still has songs")
+	ErrGroupExists          = apperr.New(apperr.Conflict, "group_exists", "group already exists")
+	ErrGroupHasTrashedSongs = apperr.New(apperr.Conflict, "group_has_trashed_songs", "group has songs in the trash")
+)
+
+// uniqueViolation - код ошибки PostgreSQL при нарушении уникального индекса
+const uniqueViolation = "23505"
+
 type GroupRepository struct {
 	db     *sql.DB
 	Logger *logger.Logger
@@ -20,48 +35,66 @@ func NewGroupRepository(db *sql.DB, logger *logger.Logger) *GroupRepository {
 	}
 }
 
-func (r *GroupRepository) CreateGroup(group models.Group) error {
+// CreateGroup добавляет группу. Названия групп уникальны без учёта регистра:
+// если группу с таким названием успели создать параллельно, возвращается
+// уже существующая группа вместе с ErrGroupExists.
+func (r *GroupRepository) CreateGroup(ctx context.Context, group models.Group) (models.Group, error) {
 	query, args, err := squirrel.Insert("groups").Columns("name, id").
-		Values(group.Name, group.Id).PlaceholderFormat(squirrel.Dollar).ToSql()
+		Values(group.Name, group.Id).
+		Suffix("ON CONFLICT ((lower(name))) DO NOTHING").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
 	if err != nil {
-		r.Logger.Info.Error("Failed to build SQL query for group creation",
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for group creation",
 			"error", err,
 			"group_name", group.Name,
 			"group_id", group.Id)
-		return err
+		return models.Group{}, err
 	}
 
-	_, err = r.db.Exec(query, args...)
+	result, err := r.db.ExecContext(ctx, query, args...)
 	if err != nil {
-		r.Logger.Info.Error("Failed to execute group creation query",
+		r.Logger.Info.ErrorContext(ctx, "Failed to execute group creation query",
 			"error", err,
 			"group_name", group.Name,
 			"group_id", group.Id)
-		return err
+		return models.Group{}, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return models.Group{}, err
 	}
 
-	return nil
+	if affected == 0 {
+		existing, err := r.GetGroupByName(ctx, group.Name)
+		if err != nil {
+			return models.Group{}, err
+		}
+		return existing, ErrGroupExists
+	}
+
+	return group, nil
 }
 
-func (r *GroupRepository) GetGroupByName(name string) (models.Group, error) {
+// GetGroupByName ищет группу по названию без учёта регистра, как и
+// уникальный индекс idx_groups_name_lower.
+func (r *GroupRepository) GetGroupByName(ctx context.Context, name string) (models.Group, error) {
 	query, args, err := squirrel.Select("id, name").
 		From("groups").
-		Where(squirrel.Eq{
-			"name": name,
-		}).PlaceholderFormat(squirrel.Dollar).ToSql()
+		Where(squirrel.Expr("lower(name) = lower(?)", name)).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
 	if err != nil {
-		r.Logger.Info.Error("Failed to build SQL query for group lookup",
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for group lookup",
 			"error", err,
 			"group_name", name)
 		return models.Group{}, err
 	}
 
 	var group models.Group
-	err = r.db.QueryRow(query, args...).Scan(&group.Id, &group.Name)
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&group.Id, &group.Name)
 	if err != nil {
 		if err == sql.ErrNoRows {
 		} else {
-			r.Logger.Info.Error("Error executing group lookup query",
+			r.Logger.Info.ErrorContext(ctx, "Error executing group lookup query",
 				"error", err,
 				"group_name", name)
 		}
@@ -74,12 +107,12 @@ func (r *GroupRepository) GetGroupByName(name string) (models.Group, error) {
 func (r *GroupRepository) GroupExsist(ctx context.Context, name string) (bool, error) {
 	query, args, err := squirrel.Select("1").
 		From("groups").
-		Where(squirrel.Eq{"name": name}).
+		Where(squirrel.Expr("lower(name) = lower(?)", name)).
 		PlaceholderFormat(squirrel.Dollar).
 		ToSql()
 
 	if err != nil {
-		r.Logger.Info.Error("Failed to build query for group existence check",
+		r.Logger.Info.ErrorContext(ctx, "Failed to build query for group existence check",
 			"error", err,
 			"group_name", name)
 		return false, err
@@ -93,7 +126,7 @@ func (r *GroupRepository) GroupExsist(ctx context.Context, name string) (bool, e
 			return false, nil
 		}
 
-		r.Logger.Info.Error("Error checking if group exists",
+		r.Logger.Info.ErrorContext(ctx, "Error checking if group exists",
 			"error", err,
 			"group_name", name)
 		return false, err
@@ -101,3 +134,513 @@ func (r *GroupRepository) GroupExsist(ctx context.Context, name string) (bool, e
 
 	return true, nil
 }
+
+func (r *GroupRepository) GetGroupById(ctx context.Context, groupId uuid.UUID) (models.Group, error) {
+	query, args, err := squirrel.Select("id, name").
+		From("groups").
+		Where(squirrel.Eq{
+			"id": groupId,
+		}).PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for group lookup by ID",
+			"error", err,
+			"group_id", groupId)
+		return models.Group{}, err
+	}
+
+	var group models.Group
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&group.Id, &group.Name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Group{}, ErrGroupNotFound
+		}
+		r.Logger.Info.ErrorContext(ctx, "Error executing group lookup query",
+			"error", err,
+			"group_id", groupId)
+		return models.Group{}, err
+	}
+
+	return group, nil
+}
+
+func (r *GroupRepository) ListGroups(ctx context.Context, page, limit int) ([]dto.GroupSummary, int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT count(*) FROM groups").Scan(&total)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to count groups",
+			"error", err)
+		return nil, 0, err
+	}
+
+	query, args, err := squirrel.Select("g.id", "g.name", "count(s.id)").
+		From("groups g").
+		LeftJoin("songs s ON s.group_id = g.id AND s.deleted_at IS NULL").
+		GroupBy("g.id", "g.name").
+		OrderBy("g.name ASC", "g.id ASC").
+		Limit(uint64(limit)).
+		Offset(uint64((page - 1) * limit)).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for groups list",
+			"error", err)
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to execute groups list query",
+			"error", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	groups := []dto.GroupSummary{}
+	for rows.Next() {
+		var group dto.GroupSummary
+		err := rows.Scan(&group.Id, &group.Name, &group.SongCount)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan group row",
+				"error", err)
+			return nil, 0, err
+		}
+		groups = append(groups, group)
+	}
+	err = rows.Err()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Error iterating over group rows",
+			"error", err)
+		return nil, 0, err
+	}
+
+	return groups, total, nil
+}
+
+// RenameGroup меняет имя группы и денормализованное songs.group_name
+// в одной транзакции, чтобы фильтр по исполнителю не видел старое имя.
+func (r *GroupRepository) RenameGroup(ctx context.Context, groupId uuid.UUID, name string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to begin transaction for group rename",
+			"error", err,
+			"group_id", groupId)
+		return err
+	}
+	defer tx.Rollback()
+
+	// Переименование меняет group_name песен, и это попадает в их историю
+	err = setActor(ctx, tx)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to set revision actor",
+			"error", err,
+			"group_id", groupId)
+		return err
+	}
+
+	query, args, err := squirrel.Update("groups").
+		Set("name", name).
+		Where(squirrel.Eq{"id": groupId}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for group rename",
+			"error", err,
+			"group_id", groupId)
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if isUniqueViolation(err) {
+		return ErrGroupExists
+	}
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to execute group rename query",
+			"error", err,
+			"group_id", groupId)
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrGroupNotFound
+	}
+
+	query, args, err = squirrel.Update("songs").
+		Set("group_name", name).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(squirrel.Eq{"group_id": groupId}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for songs group name update",
+			"error", err,
+			"group_id", groupId)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to update group name in songs",
+			"error", err,
+			"group_id", groupId)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to commit transaction for group rename",
+			"error", err,
+			"group_id", groupId)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteGroup отказывается удалять группу с песнями, если не запрошено
+// каскадное удаление. При каскадном удалении песни группы уходят в корзину и
+// теряют ссылку на неё; восстановленная песня заново найдёт или создаст
+// группу по названию.
+func (r *GroupRepository) DeleteGroup(ctx context.Context, groupId uuid.UUID, cascade bool) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to begin transaction for group deletion",
+			"error", err,
+			"group_id", groupId)
+		return err
+	}
+	defer tx.Rollback()
+
+	// Песни в корзине тоже держат группу: без каскада удаление отклоняется,
+	// чтобы не потерять их молча
+	var songCount, trashedCount int
+	err = tx.QueryRowContext(ctx, `
+		SELECT count(*) FILTER (WHERE deleted_at IS NULL), count(*) FILTER (WHERE deleted_at IS NOT NULL)
+		FROM songs WHERE group_id = $1`, groupId).Scan(&songCount, &trashedCount)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to count group songs",
+			"error", err,
+			"group_id", groupId)
+		return err
+	}
+
+	if !cascade {
+		if songCount > 0 {
+			return ErrGroupHasSongs
+		}
+		if trashedCount > 0 {
+			return ErrGroupHasTrashedSongs
+		}
+	}
+	if songCount+trashedCount > 0 {
+		err = setActor(ctx, tx)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to set revision actor",
+				"error", err,
+				"group_id", groupId)
+			return err
+		}
+
+		query, args, err := squirrel.Update("songs").
+			Set("deleted_at", squirrel.Expr("COALESCE(deleted_at, now())")).
+			Set("version", squirrel.Expr("version + CASE WHEN deleted_at IS NULL THEN 1 ELSE 0 END")).
+			Set("group_id", nil).
+			Where(squirrel.Eq{"group_id": groupId}).
+			PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for moving group songs to trash",
+				"error", err,
+				"group_id", groupId)
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to move group songs to trash",
+				"error", err,
+				"group_id", groupId)
+			return err
+		}
+	}
+
+	query, args, err := squirrel.Delete("groups").
+		Where(squirrel.Eq{"id": groupId}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for group deletion",
+			"error", err,
+			"group_id", groupId)
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to execute group deletion query",
+			"error", err,
+			"group_id", groupId)
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrGroupNotFound
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to commit transaction for group deletion",
+			"error", err,
+			"group_id", groupId)
+		return err
+	}
+
+	return nil
+}
+
+// ResolveGroup ищет группу сначала по названию, затем по алиасам,
+// которые остались от объединённых дубликатов. Регистр не учитывается.
+func (r *GroupRepository) ResolveGroup(ctx context.Context, name string) (models.Group, error) {
+	group, err := r.GetGroupByName(ctx, name)
+	if err == nil {
+		return group, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return models.Group{}, err
+	}
+
+	query, args, err := squirrel.Select("g.id", "g.name").
+		From("group_aliases a").
+		Join("groups g ON g.id = a.group_id").
+		Where(squirrel.Expr("lower(a.alias) = lower(?)", name)).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for group alias lookup",
+			"error", err,
+			"group_name", name)
+		return models.Group{}, err
+	}
+
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&group.Id, &group.Name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Group{}, ErrGroupNotFound
+		}
+		r.Logger.Info.ErrorContext(ctx, "Error executing group alias lookup query",
+			"error", err,
+			"group_name", name)
+		return models.Group{}, err
+	}
+
+	return group, nil
+}
+
+func (r *GroupRepository) GetGroupAliases(ctx context.Context, groupId uuid.UUID) ([]string, error) {
+	query, args, err := squirrel.Select("alias").
+		From("group_aliases").
+		Where(squirrel.Eq{"group_id": groupId}).
+		OrderBy("alias ASC").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for group aliases",
+			"error", err,
+			"group_id", groupId)
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to execute group aliases query",
+			"error", err,
+			"group_id", groupId)
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := []string{}
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan group alias row",
+				"error", err)
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+	err = rows.Err()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Error iterating over group alias rows",
+			"error", err)
+		return nil, err
+	}
+
+	return aliases, nil
+}
+
+// MergeGroups переносит песни и алиасы исходных групп в целевую, сохраняет
+// названия исходных групп как алиасы и удаляет их. Всё в одной транзакции.
+// Возвращает целевую группу, названия ставших алиасами групп и количество перенесённых песен.
+func (r *GroupRepository) MergeGroups(ctx context.Context, targetId uuid.UUID, sourceIds []uuid.UUID) (models.Group, []string, int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to begin transaction for group merge",
+			"error", err,
+			"group_id", targetId)
+		return models.Group{}, nil, 0, err
+	}
+	defer tx.Rollback()
+
+	// Песни переходят в целевую группу, и это попадает в их историю
+	err = setActor(ctx, tx)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to set revision actor",
+			"error", err,
+			"group_id", targetId)
+		return models.Group{}, nil, 0, err
+	}
+
+	// Блокируем все группы, чтобы параллельное переименование или
+	// удаление не разошлось с переносом песен
+	query, args, err := squirrel.Select("id", "name").
+		From("groups").
+		Where(squirrel.Eq{"id": append([]uuid.UUID{targetId}, sourceIds...)}).
+		OrderBy("id").
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for group merge lock",
+			"error", err,
+			"group_id", targetId)
+		return models.Group{}, nil, 0, err
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to lock groups for merge",
+			"error", err,
+			"group_id", targetId)
+		return models.Group{}, nil, 0, err
+	}
+	names := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var group models.Group
+		if err := rows.Scan(&group.Id, &group.Name); err != nil {
+			rows.Close()
+			return models.Group{}, nil, 0, err
+		}
+		names[group.Id] = group.Name
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return models.Group{}, nil, 0, err
+	}
+	if len(names) != len(sourceIds)+1 {
+		return models.Group{}, nil, 0, ErrGroupNotFound
+	}
+
+	target := models.Group{Id: targetId, Name: names[targetId]}
+	aliases := make([]string, 0, len(sourceIds))
+	for _, sourceId := range sourceIds {
+		aliases = append(aliases, names[sourceId])
+	}
+
+	query, args, err = squirrel.Update("songs").
+		Set("group_id", target.Id).
+		Set("group_name", target.Name).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(squirrel.Eq{"group_id": sourceIds}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for moving songs",
+			"error", err,
+			"group_id", target.Id)
+		return models.Group{}, nil, 0, err
+	}
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to move songs to target group",
+			"error", err,
+			"group_id", target.Id)
+		return models.Group{}, nil, 0, err
+	}
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return models.Group{}, nil, 0, err
+	}
+
+	query, args, err = squirrel.Update("group_aliases").
+		Set("group_id", target.Id).
+		Where(squirrel.Eq{"group_id": sourceIds}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for moving aliases",
+			"error", err,
+			"group_id", target.Id)
+		return models.Group{}, nil, 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to move aliases to target group",
+			"error", err,
+			"group_id", target.Id)
+		return models.Group{}, nil, 0, err
+	}
+
+	for _, alias := range aliases {
+		query, args, err := squirrel.Insert("group_aliases").
+			Columns("id", "group_id", "alias").
+			Values(uuid.New(), target.Id, alias).
+			Suffix("ON CONFLICT ((lower(alias))) DO UPDATE SET group_id = EXCLUDED.group_id").
+			PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for alias creation",
+				"error", err,
+				"alias", alias)
+			return models.Group{}, nil, 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to create group alias",
+				"error", err,
+				"alias", alias,
+				"group_id", target.Id)
+			return models.Group{}, nil, 0, err
+		}
+	}
+
+	query, args, err = squirrel.Delete("groups").
+		Where(squirrel.Eq{"id": sourceIds}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for source groups deletion",
+			"error", err,
+			"group_id", target.Id)
+		return models.Group{}, nil, 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to delete merged groups",
+			"error", err,
+			"group_id", target.Id)
+		return models.Group{}, nil, 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to commit transaction for group merge",
+			"error", err,
+			"group_id", target.Id)
+		return models.Group{}, nil, 0, err
+	}
+
+	return target, aliases, moved, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
+	"time"
+)
+
+// maxReserveAttempts - сколько раз Reserve пробует занять ключ, если запись,
+// с которой он столкнулся, удалили до того, как её удалось прочитать.
+const maxReserveAttempts = 3
+
+type IdempotencyRepository struct {
+	db     *sql.DB
+	Logger *logger.Logger
+}
+
+func NewIdempotencyRepository(db *sql.DB, logger *logger.Logger) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db:     db,
+		Logger: logger,
+	}
+}
+
+// Reserve занимает ключ owner за текущим запросом. Если ключ уже занят и ещё
+// не истёк, возвращается сохранённая запись и false. Истёкший ключ
+// перезаписывается, как будто его не было; так же перезаписывается
+// незавершённый ключ, который занят дольше lease: запрос, занявший его,
+// не завершился и не освободил ключ, например из-за падения процесса.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, owner, key, requestHash string, ttl, lease time.Duration) (models.IdempotencyRecord, bool, error) {
+	record := models.IdempotencyRecord{Owner: owner, Key: key, RequestHash: requestHash}
+
+	query, args, err := squirrel.Insert("idempotency_keys").
+		Columns("owner", "key", "request_hash", "expires_at").
+		Values(owner, key, requestHash, squirrel.Expr("now() + make_interval(secs => ?)", ttl.Seconds())).
+		Suffix(`ON CONFLICT (owner, key) DO UPDATE SET
+		    request_hash = EXCLUDED.request_hash,
+		    status_code = NULL,
+		    headers = NULL,
+		    body = NULL,
+		    created_at = now(),
+		    locked_at = now(),
+		    expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= now()
+		   OR (idempotency_keys.status_code IS NULL AND idempotency_keys.locked_at <= now() - make_interval(secs => ?))
+		RETURNING created_at, expires_at`, lease.Seconds()).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for idempotency key reservation",
+			"error", err,
+			"owner", owner,
+			"key", key)
+		return record, false, err
+	}
+
+	// Занятую запись могут удалить (Release) между вставкой и чтением,
+	// тогда ключ снова свободен и вставка повторяется
+	for attempt := 1; ; attempt++ {
+		err = r.db.QueryRowContext(ctx, query, args...).Scan(&record.CreatedAt, &record.ExpiresAt)
+		if err == nil {
+			return record, true, nil
+		}
+		if err != sql.ErrNoRows {
+			r.Logger.Info.ErrorContext(ctx, "Failed to reserve idempotency key",
+				"error", err,
+				"owner", owner,
+				"key", key)
+			return record, false, err
+		}
+
+		stored, err := r.getRecord(ctx, owner, key)
+		if errors.Is(err, sql.ErrNoRows) && attempt < maxReserveAttempts {
+			continue
+		}
+		return stored, false, err
+	}
+}
+
+func (r *IdempotencyRepository) getRecord(ctx context.Context, owner, key string) (models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+
+	query, args, err := squirrel.Select("owner", "key", "request_hash", "status_code", "headers", "body", "created_at", "expires_at").
+		From("idempotency_keys").
+		Where(squirrel.Eq{"owner": owner, "key": key}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for idempotency key",
+			"error", err,
+			"owner", owner,
+			"key", key)
+		return record, err
+	}
+
+	var statusCode sql.NullInt64
+	var headers []byte
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(
+		&record.Owner,
+		&record.Key,
+		&record.RequestHash,
+		&statusCode,
+		&headers,
+		&record.Body,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return record, err
+	}
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to get idempotency key",
+			"error", err,
+			"owner", owner,
+			"key", key)
+		return record, err
+	}
+	record.StatusCode = int(statusCode.Int64)
+
+	if len(headers) > 0 {
+		err = json.Unmarshal(headers, &record.Headers)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to decode stored response headers",
+				"error", err,
+				"owner", owner,
+				"key", key)
+			return record, err
+		}
+	}
+
+	return record, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, owner, key string, statusCode int, headers map[string]string, body []byte) error {
+	encodedHeaders, err := json.Marshal(headers)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to encode response headers",
+			"error", err,
+			"owner", owner,
+			"key", key)
+		return err
+	}
+
+	query, args, err := squirrel.Update("idempotency_keys").
+		Set("status_code", statusCode).
+		Set("headers", encodedHeaders).
+		Set("body", body).
+		Where(squirrel.Eq{"owner": owner, "key": key}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for idempotency key completion",
+			"error", err,
+			"owner", owner,
+			"key", key)
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to save idempotent response",
+			"error", err,
+			"owner", owner,
+			"key", key)
+		return err
+	}
+
+	return nil
+}
+
+// Release освобождает ключ незавершённого запроса, чтобы клиент мог
+// повторить его с тем же ключом.
+func (r *IdempotencyRepository) Release(ctx context.Context, owner, key string) error {
+	query, args, err := squirrel.Delete("idempotency_keys").
+		Where(squirrel.Eq{"owner": owner, "key": key, "status_code": nil}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for idempotency key release",
+			"error", err,
+			"owner", owner,
+			"key", key)
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to release idempotency key",
+			"error", err,
+			"owner", owner,
+			"key", key)
+		return err
+	}
+
+	return nil
+}
+
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	query, args, err := squirrel.Delete("idempotency_keys").
+		Where("expires_at <= now()").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for expired idempotency keys",
+			"error", err)
+		return 0, err
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to delete expired idempotency keys",
+			"error", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
+)
+
+var ErrLyricsNotFound = apperr.New(apperr.NotFound, "lyrics_not_found", "song has no timed lyrics")
+
+const lyricLineColumns = "id, song_id, line_number, time_ms, text"
+
+type LyricsRepository struct {
+	db     *sql.DB
+	Logger *logger.Logger
+}
+
+func NewLyricsRepository(db *sql.DB, logger *logger.Logger) *LyricsRepository {
+	return &LyricsRepository{
+		db:     db,
+		Logger: logger,
+	}
+}
+
+// GetLines возвращает строки с таймингами в порядке текста.
+func (r *LyricsRepository) GetLines(ctx context.Context, songId uuid.UUID) ([]models.LyricLine, error) {
+	query, args, err := squirrel.Select(lyricLineColumns).
+		From("lyric_lines").
+		Where(squirrel.Eq{"song_id": songId}).
+		Where(songNotDeleted("lyric_lines.song_id")).
+		OrderBy("line_number").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for lyric lines",
+			"error", err,
+			"song_id", songId)
+		return nil, err
+	}
+
+	return r.queryLines(ctx, query, args...)
+}
+
+// GetLinesAt возвращает строку, которая звучит в момент timeMs, и next
+// следующих за ней строк. Если момент раньше первой строки, current = nil.
+func (r *LyricsRepository) GetLinesAt(ctx context.Context, songId uuid.UUID, timeMs int64, next int) (*models.LyricLine, []models.LyricLine, error) {
+	query, args, err := squirrel.Select(lyricLineColumns).
+		From("lyric_lines").
+		Where(squirrel.Eq{"song_id": songId}).
+		Where(songNotDeleted("lyric_lines.song_id")).
+		Where(squirrel.LtOrEq{"time_ms": timeMs}).
+		OrderBy("time_ms DESC", "line_number DESC").
+		Limit(1).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for current lyric line",
+			"error", err,
+			"song_id", songId)
+		return nil, nil, err
+	}
+
+	current, err := r.queryLines(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query, args, err = squirrel.Select(lyricLineColumns).
+		From("lyric_lines").
+		Where(squirrel.Eq{"song_id": songId}).
+		Where(songNotDeleted("lyric_lines.song_id")).
+		Where(squirrel.Gt{"time_ms": timeMs}).
+		OrderBy("time_ms", "line_number").
+		Limit(uint64(next)).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for next lyric lines",
+			"error", err,
+			"song_id", songId)
+		return nil, nil, err
+	}
+
+	upcoming, err := r.queryLines(ctx, query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(current) == 0 {
+		if len(upcoming) == 0 {
+			return nil, nil, ErrLyricsNotFound
+		}
+		return nil, upcoming, nil
+	}
+	return &current[0], upcoming, nil
+}
+
+// ReplaceLines заменяет строки с таймингами песни. Строки выровнены по
+// тексту версии version, поэтому песня блокируется на время записи, и если
+// её версия уже другая (текст мог измениться), возвращается ErrSongModified.
+func (r *LyricsRepository) ReplaceLines(ctx context.Context, songId uuid.UUID, version int, lines []models.LyricLine) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to begin transaction for lyric lines",
+			"error", err,
+			"song_id", songId)
+		return err
+	}
+	defer tx.Rollback()
+
+	var current int
+	err = tx.QueryRowContext(ctx, "SELECT version FROM songs WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", songId).Scan(&current)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSongNotFound
+		}
+		r.Logger.Info.ErrorContext(ctx, "Failed to lock song for lyric lines",
+			"error", err,
+			"song_id", songId)
+		return err
+	}
+	if current != version {
+		return ErrSongModified
+	}
+
+	err = replaceLyricLines(ctx, tx, songId, lines)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to replace lyric lines",
+			"error", err,
+			"song_id", songId)
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to commit transaction for lyric lines",
+			"error", err,
+			"song_id", songId)
+		return err
+	}
+
+	return nil
+}
+
+// replaceLyricLines удаляет строки песни и записывает новые в переданной
+// транзакции, чтобы тайминги менялись вместе с текстом песни.
+func replaceLyricLines(ctx context.Context, tx *sql.Tx, songId uuid.UUID, lines []models.LyricLine) error {
+	query, args, err := squirrel.Delete("lyric_lines").
+		Where(squirrel.Eq{"song_id": songId}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		query, args, err := squirrel.Insert("lyric_lines").
+			Columns("id", "song_id", "line_number", "time_ms", "text").
+			Values(line.Id, songId, line.LineNumber, line.TimeMs, line.Text).
+			PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("insert lyric line %d: %w", line.LineNumber, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *LyricsRepository) queryLines(ctx context.Context, query string, args ...any) ([]models.LyricLine, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to query lyric lines",
+			"error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines, err := scanLyricLines(rows)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to scan lyric line rows",
+			"error", err)
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// lyricLinesTx читает строки песни в транзакции правки текста.
+func lyricLinesTx(ctx context.Context, tx *sql.Tx, songId uuid.UUID) ([]models.LyricLine, error) {
+	query, args, err := squirrel.Select(lyricLineColumns).
+		From("lyric_lines").
+		Where(squirrel.Eq{"song_id": songId}).
+		OrderBy("line_number").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanLyricLines(rows)
+}
+
+func scanLyricLines(rows *sql.Rows) ([]models.LyricLine, error) {
+	lines := []models.LyricLine{}
+	for rows.Next() {
+		var line models.LyricLine
+		var timeMs sql.NullInt64
+		err := rows.Scan(&line.Id, &line.SongId, &line.LineNumber, &timeMs, &line.Text)
+		if err != nil {
+			return nil, err
+		}
+		if timeMs.Valid {
+			line.TimeMs = &timeMs.Int64
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
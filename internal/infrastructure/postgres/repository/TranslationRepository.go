@@ -0,0 +1,363 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
+)
+
+var (
+	ErrTranslationNotFound = apperr.New(apperr.NotFound, "translation_not_found", "translation doesn't exist")
+	ErrTranslationMismatch = apperr.New(apperr.Validation, "translation_verse_mismatch", "translation verse count doesn't match the original")
+)
+
+type TranslationRepository struct {
+	db     *sql.DB
+	Logger *logger.Logger
+}
+
+func NewTranslationRepository(db *sql.DB, logger *logger.Logger) *TranslationRepository {
+	return &TranslationRepository{
+		db:     db,
+		Logger: logger,
+	}
+}
+
+func (r *TranslationRepository) ListTranslations(ctx context.Context, songId uuid.UUID) ([]dto.TranslationSummary, error) {
+	query, args, err := squirrel.Select("t.lang", "t.title", "t.stale", "t.updated_at", "count(v.id)").
+		From("song_translations t").
+		LeftJoin("verse_translations v ON v.song_id = t.song_id AND v.lang = t.lang").
+		Where(squirrel.Eq{"t.song_id": songId}).
+		Where(songNotDeleted("t.song_id")).
+		GroupBy("t.lang", "t.title", "t.stale", "t.updated_at").
+		OrderBy("t.lang").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for translations list",
+			"error", err,
+			"song_id", songId)
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to query translations",
+			"error", err,
+			"song_id", songId)
+		return nil, err
+	}
+	defer rows.Close()
+
+	translations := []dto.TranslationSummary{}
+	for rows.Next() {
+		var translation dto.TranslationSummary
+		err := rows.Scan(&translation.Lang, &translation.Title, &translation.Stale, &translation.UpdatedAt, &translation.VerseCount)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan translation row",
+				"error", err)
+			return nil, err
+		}
+		translations = append(translations, translation)
+	}
+	err = rows.Err()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Error iterating over translation rows",
+			"error", err)
+		return nil, err
+	}
+
+	return translations, nil
+}
+
+// GetLangs возвращает языки, на которые переведена песня. Устаревшие
+// переводы не учитываются: их куплеты не соответствуют оригиналу.
+func (r *TranslationRepository) GetLangs(ctx context.Context, songId uuid.UUID) ([]string, error) {
+	query, args, err := squirrel.Select("lang").
+		From("song_translations").
+		Where(squirrel.Eq{"song_id": songId, "stale": false}).
+		Where(songNotDeleted("song_translations.song_id")).
+		OrderBy("lang").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for translation languages",
+			"error", err,
+			"song_id", songId)
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to query translation languages",
+			"error", err,
+			"song_id", songId)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var langs []string
+	for rows.Next() {
+		var lang string
+		if err := rows.Scan(&lang); err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan translation language",
+				"error", err)
+			return nil, err
+		}
+		langs = append(langs, lang)
+	}
+	return langs, rows.Err()
+}
+
+func (r *TranslationRepository) GetTranslation(ctx context.Context, songId uuid.UUID, lang string) (models.SongTranslation, error) {
+	query, args, err := squirrel.Select("id", "song_id", "lang", "title", "text", "stale", "created_at", "updated_at").
+		From("song_translations").
+		Where(squirrel.Eq{"song_id": songId, "lang": lang}).
+		Where(songNotDeleted("song_translations.song_id")).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for translation lookup",
+			"error", err,
+			"song_id", songId)
+		return models.SongTranslation{}, err
+	}
+
+	var translation models.SongTranslation
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(
+		&translation.Id,
+		&translation.SongId,
+		&translation.Lang,
+		&translation.Title,
+		&translation.Text,
+		&translation.Stale,
+		&translation.CreatedAt,
+		&translation.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SongTranslation{}, ErrTranslationNotFound
+		}
+		r.Logger.Info.ErrorContext(ctx, "Error executing translation lookup query",
+			"error", err,
+			"song_id", songId,
+			"lang", lang)
+		return models.SongTranslation{}, err
+	}
+
+	return translation, nil
+}
+
+// GetVerseTranslations возвращает переводы куплетов песни на язык lang.
+// Если numbers не пуст, только для куплетов с этими номерами.
+func (r *TranslationRepository) GetVerseTranslations(ctx context.Context, songId uuid.UUID, lang string, numbers []int) ([]models.VerseTranslation, error) {
+	queryBuilder := squirrel.Select("verse_number", "lang", "text").
+		From("verse_translations").
+		Where(squirrel.Eq{"song_id": songId, "lang": lang}).
+		Where(songNotDeleted("verse_translations.song_id"))
+	if len(numbers) > 0 {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"verse_number": numbers})
+	}
+
+	query, args, err := queryBuilder.
+		OrderBy("verse_number").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for verse translations",
+			"error", err,
+			"song_id", songId)
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to query verse translations",
+			"error", err,
+			"song_id", songId,
+			"lang", lang)
+		return nil, err
+	}
+	defer rows.Close()
+
+	verses := []models.VerseTranslation{}
+	for rows.Next() {
+		var verse models.VerseTranslation
+		err := rows.Scan(&verse.VerseNumber, &verse.Lang, &verse.Text)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan verse translation row",
+				"error", err)
+			return nil, err
+		}
+		verses = append(verses, verse)
+	}
+	err = rows.Err()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Error iterating over verse translation rows",
+			"error", err)
+		return nil, err
+	}
+
+	return verses, nil
+}
+
+// SaveTranslation создаёт или заменяет перевод песни. Количество куплетов
+// перевода должно совпадать с оригиналом, иначе их номера не сопоставить.
+func (r *TranslationRepository) SaveTranslation(ctx context.Context, translation models.SongTranslation, verses []string) (models.SongTranslation, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to begin transaction for translation",
+			"error", err,
+			"song_id", translation.SongId)
+		return models.SongTranslation{}, err
+	}
+	defer tx.Rollback()
+
+	// Блокировка песни не даёт поменять куплеты оригинала, пока сохраняется перевод
+	var count int
+	err = tx.QueryRowContext(ctx, `
+		SELECT (SELECT count(*) FROM verses WHERE song_id = s.id)
+		FROM songs s WHERE s.id = $1 AND s.deleted_at IS NULL FOR UPDATE`, translation.SongId).Scan(&count)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SongTranslation{}, ErrSongNotFound
+		}
+		r.Logger.Info.ErrorContext(ctx, "Failed to count song verses",
+			"error", err,
+			"song_id", translation.SongId)
+		return models.SongTranslation{}, err
+	}
+	if count != len(verses) {
+		return models.SongTranslation{}, fmt.Errorf("%w: original has %d verses, translation has %d", ErrTranslationMismatch, count, len(verses))
+	}
+
+	// Перевод входит в представление песни, поэтому меняет её версию (ETag)
+	_, err = tx.ExecContext(ctx, `UPDATE songs SET version = version + 1 WHERE id = $1`, translation.SongId)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to bump song version",
+			"error", err,
+			"song_id", translation.SongId)
+		return models.SongTranslation{}, err
+	}
+
+	query, args, err := squirrel.Insert("song_translations").
+		Columns("id", "song_id", "lang", "title", "text").
+		Values(uuid.New(), translation.SongId, translation.Lang, translation.Title, translation.Text).
+		Suffix("ON CONFLICT (song_id, lang) DO UPDATE SET title = EXCLUDED.title, text = EXCLUDED.text, stale = false, updated_at = now()").
+		Suffix("RETURNING id, created_at, updated_at").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for translation upsert",
+			"error", err,
+			"song_id", translation.SongId)
+		return models.SongTranslation{}, err
+	}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&translation.Id, &translation.CreatedAt, &translation.UpdatedAt)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to upsert translation",
+			"error", err,
+			"song_id", translation.SongId,
+			"lang", translation.Lang)
+		return models.SongTranslation{}, err
+	}
+
+	query, args, err = squirrel.Delete("verse_translations").
+		Where(squirrel.Eq{"song_id": translation.SongId, "lang": translation.Lang}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return models.SongTranslation{}, err
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to delete verse translations",
+			"error", err,
+			"song_id", translation.SongId,
+			"lang", translation.Lang)
+		return models.SongTranslation{}, err
+	}
+
+	for i, verse := range verses {
+		query, args, err := squirrel.Insert("verse_translations").
+			Columns("id", "song_id", "lang", "verse_number", "text").
+			Values(uuid.New(), translation.SongId, translation.Lang, i+1, verse).
+			PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			return models.SongTranslation{}, err
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to insert verse translation",
+				"error", err,
+				"song_id", translation.SongId,
+				"verse_number", i+1)
+			return models.SongTranslation{}, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to commit transaction for translation",
+			"error", err,
+			"song_id", translation.SongId)
+		return models.SongTranslation{}, err
+	}
+
+	return translation, nil
+}
+
+// DeleteTranslation удаляет перевод и, как SaveTranslation, меняет версию песни.
+func (r *TranslationRepository) DeleteTranslation(ctx context.Context, songId uuid.UUID, lang string) error {
+	result, err := r.db.ExecContext(ctx, `
+		WITH deleted AS (
+			DELETE FROM song_translations
+			WHERE song_id = $1 AND lang = $2
+			  AND song_id IN (SELECT id FROM songs WHERE deleted_at IS NULL)
+			RETURNING song_id
+		)
+		UPDATE songs SET version = version + 1 WHERE id IN (SELECT song_id FROM deleted)`,
+		songId, lang)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to delete translation",
+			"error", err,
+			"song_id", songId,
+			"lang", lang)
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrTranslationNotFound
+	}
+
+	return nil
+}
+
+// markTranslationsStale помечает переводы песни устаревшими после изменения
+// текста куплетов оригинала в транзакции tx.
+func markTranslationsStale(ctx context.Context, tx *sql.Tx, songId uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE song_translations
+		SET stale = true, updated_at = now()
+		WHERE song_id = $1 AND NOT stale`,
+		songId)
+	return err
+}
+
+// syncTranslationTexts пересобирает текст переводов из куплетов перевода
+// после удаления или перемещения куплетов, как syncSongText для оригинала.
+func syncTranslationTexts(ctx context.Context, tx *sql.Tx, songId uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE song_translations t
+		SET text = COALESCE((
+		        SELECT string_agg(v.text, $2 ORDER BY v.verse_number)
+		        FROM verse_translations v
+		        WHERE v.song_id = t.song_id AND v.lang = t.lang), '')
+		WHERE t.song_id = $1 AND NOT t.stale`,
+		songId, models.VerseSeparator)
+	return err
+}
@@ -4,12 +4,27 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
 	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
 	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
 )
 
+var (
+	ErrVerseNotFound        = apperr.New(apperr.NotFound, "verse_not_found", "verse doesn't exist")
+	ErrInvalidVersePosition = apperr.New(apperr.Validation, "invalid_verse_position", "verse position is out of range")
+	ErrNilSongId            = apperr.New(apperr.Validation, "nil_song_id", "song ID cannot be nil")
+)
+
+const verseColumns = "id, song_id, verse_number, text, section_type, repeat_of"
+
+// numberedVerseTables - таблицы, где строки привязаны к номеру куплета.
+// При вставке, удалении и перемещении куплета номера сдвигаются во всех.
+var numberedVerseTables = []string{"verses", "verse_translations"}
+
 type VerseRepository struct {
 	Db     *sql.DB
 	Logger *logger.Logger
@@ -22,110 +37,206 @@ func NewVerseRepository(db *sql.DB, logger *logger.Logger) *VerseRepository {
 	}
 }
 
-func (r *VerseRepository) AddVerses(ctx context.Context, req dto.AddVersesRequest) error {
+// AddVerses заменяет куплеты песни собранными build. Песня читается под
+// блокировкой строки в той же транзакции, поэтому правка текста, которая
+// закоммитилась после чтения песни сервисом, не перезаписывается куплетами
+// из старого текста. Возвращает количество записанных куплетов.
+func (r *VerseRepository) AddVerses(ctx context.Context, songId uuid.UUID, build models.VersesBuilder) (int, error) {
 
-	if req.Song.Id == uuid.Nil {
-		r.Logger.Info.Error("Cannot add verses: song ID is nil")
-		return errors.New("song ID cannot be nil")
-	}
-
-	if len(req.Verses) == 0 {
-		r.Logger.Info.Error("Cannot add verses: no verses provided",
-			"song_id", req.Song.Id)
-		return errors.New("no verses provided")
+	if songId == uuid.Nil {
+		r.Logger.Info.ErrorContext(ctx, "Cannot add verses: song ID is nil")
+		return 0, ErrNilSongId
 	}
 
 	tx, err := r.Db.BeginTx(ctx, nil)
 	if err != nil {
-		r.Logger.Info.Error("Failed to begin transaction for adding verses",
+		r.Logger.Info.ErrorContext(ctx, "Failed to begin transaction for adding verses",
 			"error", err,
-			"song_id", req.Song.Id)
-		return err
+			"song_id", songId)
+		return 0, err
 	}
 	defer tx.Rollback()
 
+	song := models.Song{Id: songId}
+	err = tx.QueryRowContext(ctx,
+		"SELECT text, COALESCE(split_strategy, '') FROM songs WHERE id = $1 AND deleted_at IS NULL FOR UPDATE",
+		songId).Scan(&song.Text, &song.SplitStrategy)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrSongNotFound
+		}
+		r.Logger.Info.ErrorContext(ctx, "Failed to lock song for adding verses",
+			"error", err,
+			"song_id", songId)
+		return 0, err
+	}
+
+	verses := build(song)
+	err = replaceVerses(ctx, tx, songId, verses)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to replace verses",
+			"error", err,
+			"song_id", songId)
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to commit transaction for adding verses",
+			"error", err,
+			"song_id", songId)
+		return 0, err
+	}
+
+	return len(verses), nil
+}
+
+// replaceVerses удаляет куплеты песни и записывает новые в переданной
+// транзакции, чтобы их можно было заменить вместе с обновлением песни.
+// Если текст куплетов изменился, переводы песни помечаются устаревшими.
+func replaceVerses(ctx context.Context, tx *sql.Tx, songId uuid.UUID, verses []models.Verse) error {
 	deleteQuery, deleteArgs, err := squirrel.Delete("verses").
-		Where(squirrel.Eq{"song_id": req.Song.Id}).
+		Where(squirrel.Eq{"song_id": songId}).
+		Suffix("RETURNING verse_number, text").
 		PlaceholderFormat(squirrel.Dollar).
 		ToSql()
 	if err != nil {
-		r.Logger.Info.Error("Failed to build delete query",
-			"error", err,
-			"song_id", req.Song.Id)
 		return err
 	}
 
-	_, err = tx.ExecContext(ctx, deleteQuery, deleteArgs...)
+	rows, err := tx.QueryContext(ctx, deleteQuery, deleteArgs...)
 	if err != nil {
-		r.Logger.Info.Error("Failed to delete existing verses",
-			"error", err,
-			"song_id", req.Song.Id)
+		return err
+	}
+	previous := make(map[int]string)
+	for rows.Next() {
+		var number int
+		var text string
+		if err := rows.Scan(&number, &text); err != nil {
+			rows.Close()
+			return err
+		}
+		previous[number] = text
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
 		return err
 	}
 
-	for i, verse := range req.Verses {
-		verseId := uuid.New()
+	changed := len(previous) != len(verses)
+	for i, verse := range verses {
+		if previous[i+1] != verse.Text {
+			changed = true
+		}
+	}
+	if changed {
+		if err := markTranslationsStale(ctx, tx, songId); err != nil {
+			return err
+		}
+	}
+
+	for i, verse := range verses {
+		if verse.Id == uuid.Nil {
+			verse.Id = uuid.New()
+		}
+		if verse.SectionType == "" {
+			verse.SectionType = models.SectionVerse
+		}
 
 		insertQuery, insertArgs, err := squirrel.Insert("verses").
-			Columns("id", "song_id", "verse_number", "text").
-			Values(verseId, req.Song.Id, i+1, verse).
+			Columns("id", "song_id", "verse_number", "text", "section_type", "repeat_of").
+			Values(verse.Id, songId, i+1, verse.Text, verse.SectionType, verse.RepeatOf).
 			PlaceholderFormat(squirrel.Dollar).
 			ToSql()
 		if err != nil {
-			r.Logger.Info.Error("Failed to build insert query",
-				"error", err,
-				"song_id", req.Song.Id,
-				"verse_number", i+1)
 			return err
 		}
 
 		_, err = tx.ExecContext(ctx, insertQuery, insertArgs...)
 		if err != nil {
-			r.Logger.Info.Error("Failed to insert verse",
-				"error", err,
-				"song_id", req.Song.Id,
-				"verse_number", i+1)
-			return err
+			return fmt.Errorf("insert verse %d: %w", i+1, err)
 		}
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		r.Logger.Info.Error("Failed to commit transaction for adding verses",
-			"error", err,
-			"song_id", req.Song.Id)
-		return err
-	}
-
 	return nil
 }
 
+// GetPaginatedVerses отдаёт страницу куплетов и общее количество куплетов
+// песни с учётом фильтра. Страница за пределами списка возвращается пустой,
+// несуществующая песня - ErrSongNotFound.
 func (r *VerseRepository) GetPaginatedVerses(ctx context.Context, request dto.PaginatedVersesRequest) (dto.PaginatedVersesResponse, error) {
+	resp := dto.PaginatedVersesResponse{
+		Verses: []models.Verse{},
+		Page:   request.Page,
+		Limit:  request.Limit,
+	}
 
-	var resp dto.PaginatedVersesResponse
-	firstVerse := (request.Page-1)*request.Limit + 1
-	lastVerse := (request.Page) * request.Limit
+	// LEFT JOIN от songs отличает песню без куплетов от несуществующей песни
+	join := "verses v ON v.song_id = s.id"
+	var joinArgs []any
+	if request.SectionType != "" {
+		join += " AND v.section_type = ?"
+		joinArgs = append(joinArgs, request.SectionType)
+	}
 
-	query, args, err := squirrel.Select("text").
+	query, args, err := squirrel.Select("count(v.id)").
+		From("songs s").
+		LeftJoin(join, joinArgs...).
+		Where(squirrel.Eq{"s.id": request.SongId, "s.deleted_at": nil}).
+		GroupBy("s.id").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for verses count",
+			"error", err,
+			"song_id", request.SongId)
+		return dto.PaginatedVersesResponse{}, err
+	}
+
+	err = r.Db.QueryRowContext(ctx, query, args...).Scan(&resp.TotalVerses)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.PaginatedVersesResponse{}, ErrSongNotFound
+		}
+		r.Logger.Info.ErrorContext(ctx, "Failed to count verses",
+			"error", err,
+			"song_id", request.SongId)
+		return dto.PaginatedVersesResponse{}, err
+	}
+
+	offset := (request.Page - 1) * request.Limit
+	resp.TotalPages = (resp.TotalVerses + request.Limit - 1) / request.Limit
+	resp.HasPrev = request.Page > 1
+	resp.HasNext = request.Page < resp.TotalPages
+	if offset >= resp.TotalVerses {
+		return resp, nil
+	}
+
+	// С фильтром по типу секции номера куплетов идут с пропусками, поэтому
+	// страница считается через OFFSET, а не по диапазону verse_number.
+	queryBuilder := squirrel.Select(verseColumns).
 		From("verses").
 		Where(squirrel.Eq{
 			"song_id": request.SongId,
-		}).
-		Where(squirrel.GtOrEq{
-			"verse_number": firstVerse,
-		}).Where(squirrel.LtOrEq{
-		"verse_number": lastVerse,
-	}).
+		})
+	if request.SectionType != "" {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"section_type": request.SectionType})
+	}
+
+	query, args, err = queryBuilder.
 		OrderBy("verse_number ASC").
+		Limit(uint64(request.Limit)).
+		Offset(uint64(offset)).
 		PlaceholderFormat(squirrel.Dollar).ToSql()
 	if err != nil {
-		r.Logger.Info.Error("Failed to build query query", err)
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for paginated verses",
+			"error", err,
+			"song_id", request.SongId)
 		return dto.PaginatedVersesResponse{}, err
 	}
 
 	rows, err := r.Db.QueryContext(ctx, query, args...)
 	if err != nil {
-		r.Logger.Info.Error("Failed to query verses",
+		r.Logger.Info.ErrorContext(ctx, "Failed to query verses",
 			"error", err,
 			"song_id", request.SongId)
 		return dto.PaginatedVersesResponse{}, err
@@ -133,10 +244,9 @@ func (r *VerseRepository) GetPaginatedVerses(ctx context.Context, request dto.Pa
 	defer rows.Close()
 
 	for rows.Next() {
-		var verse string
-		err := rows.Scan(&verse)
+		verse, err := scanVerse(rows)
 		if err != nil {
-			r.Logger.Info.Error("Failed to scan verse row",
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan verse row",
 				"error", err)
 			return dto.PaginatedVersesResponse{}, err
 		}
@@ -145,21 +255,377 @@ func (r *VerseRepository) GetPaginatedVerses(ctx context.Context, request dto.Pa
 	}
 	err = rows.Err()
 	if err != nil {
-		r.Logger.Info.Error("Error iterating over rows",
+		r.Logger.Info.ErrorContext(ctx, "Error iterating over rows",
 			"error", err)
 		return dto.PaginatedVersesResponse{}, err
 	}
 
-	if len(resp.Verses) == 0 && request.Page == 1 {
-		return dto.PaginatedVersesResponse{
-			Page:  request.Page,
-			Limit: request.Limit,
-			Total: 0,
-		}, errors.New("no verses found")
+	return resp, nil
+}
+
+func (r *VerseRepository) GetVerse(ctx context.Context, songId uuid.UUID, number int) (models.Verse, error) {
+	return r.getVerse(ctx, r.Db, songId, number)
+}
+
+// UpdateVerse заменяет текст и тип одного куплета и пересобирает songs.text.
+// После правки куплет больше не считается повтором, а его повторы - повторами.
+func (r *VerseRepository) UpdateVerse(ctx context.Context, songId uuid.UUID, number int, text, sectionType string, realign models.LyricsRealigner) (models.Verse, error) {
+	var verse models.Verse
+	err := r.editVerses(ctx, songId, realign, func(tx *sql.Tx, count int) error {
+		if number < 1 || number > count {
+			return ErrVerseNotFound
+		}
+
+		current, err := r.getVerse(ctx, tx, songId, number)
+		if err != nil {
+			return err
+		}
+
+		query, args, err := squirrel.Update("verses").
+			Set("repeat_of", nil).
+			Where(squirrel.Eq{"repeat_of": current.Id}).
+			PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+
+		query, args, err = squirrel.Update("verses").
+			Set("text", text).
+			Set("section_type", sectionType).
+			Set("repeat_of", nil).
+			Where(squirrel.Eq{"id": current.Id}).
+			PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+		if current.Text != text {
+			if err := markTranslationsStale(ctx, tx, songId); err != nil {
+				return err
+			}
+		}
+
+		verse, err = r.getVerse(ctx, tx, songId, number)
+		return err
+	})
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to update verse",
+			"error", err,
+			"song_id", songId,
+			"verse_number", number)
 	}
+	return verse, err
+}
+
+// InsertVerse вставляет куплет на позицию position, сдвигая последующие.
+// Позиция 0 означает добавление в конец.
+func (r *VerseRepository) InsertVerse(ctx context.Context, songId uuid.UUID, position int, text, sectionType string, realign models.LyricsRealigner) (models.Verse, error) {
+	var verse models.Verse
+	err := r.editVerses(ctx, songId, realign, func(tx *sql.Tx, count int) error {
+		if position == 0 {
+			position = count + 1
+		}
+		if position < 1 || position > count+1 {
+			return ErrInvalidVersePosition
+		}
+
+		for _, table := range numberedVerseTables {
+			query, args, err := squirrel.Update(table).
+				Set("verse_number", squirrel.Expr("verse_number + 1")).
+				Where(squirrel.Eq{"song_id": songId}).
+				Where(squirrel.GtOrEq{"verse_number": position}).
+				PlaceholderFormat(squirrel.Dollar).ToSql()
+			if err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return err
+			}
+		}
+
+		verse = models.Verse{
+			Id:          uuid.New(),
+			SongId:      songId,
+			VerseNumber: position,
+			Text:        text,
+			SectionType: sectionType,
+		}
+		query, args, err := squirrel.Insert("verses").
+			Columns("id", "song_id", "verse_number", "text", "section_type").
+			Values(verse.Id, verse.SongId, verse.VerseNumber, verse.Text, verse.SectionType).
+			PlaceholderFormat(squirrel.Dollar).ToSql()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+
+		// У нового куплета нет перевода, и переводы больше не покрывают оригинал
+		return markTranslationsStale(ctx, tx, songId)
+	})
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to insert verse",
+			"error", err,
+			"song_id", songId,
+			"position", position)
+	}
+	return verse, err
+}
+
+// DeleteVerse удаляет куплет и сдвигает последующие на одну позицию вверх.
+func (r *VerseRepository) DeleteVerse(ctx context.Context, songId uuid.UUID, number int, realign models.LyricsRealigner) error {
+	err := r.editVerses(ctx, songId, realign, func(tx *sql.Tx, count int) error {
+		if number < 1 || number > count {
+			return ErrVerseNotFound
+		}
+
+		// Повторы удаляемого куплета не теряют связь друг с другом: они
+		// временно ссылаются на первый из них, а relinkRepeats делает его исходным
+		current, err := r.getVerse(ctx, tx, songId, number)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE verses
+			SET repeat_of = (SELECT id FROM verses WHERE repeat_of = $1 ORDER BY verse_number LIMIT 1)
+			WHERE repeat_of = $1`,
+			current.Id)
+		if err != nil {
+			return err
+		}
+
+		for _, table := range numberedVerseTables {
+			query, args, err := squirrel.Delete(table).
+				Where(squirrel.Eq{"song_id": songId, "verse_number": number}).
+				PlaceholderFormat(squirrel.Dollar).ToSql()
+			if err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return err
+			}
+
+			query, args, err = squirrel.Update(table).
+				Set("verse_number", squirrel.Expr("verse_number - 1")).
+				Where(squirrel.Eq{"song_id": songId}).
+				Where(squirrel.Gt{"verse_number": number}).
+				PlaceholderFormat(squirrel.Dollar).ToSql()
+			if err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to delete verse",
+			"error", err,
+			"song_id", songId,
+			"verse_number", number)
+	}
+	return err
+}
+
+// MoveVerse переносит куплет с позиции from на позицию to, куплеты между
+// ними сдвигаются на одну позицию. Всё делается одним UPDATE.
+func (r *VerseRepository) MoveVerse(ctx context.Context, songId uuid.UUID, from, to int, realign models.LyricsRealigner) (models.Verse, error) {
+	var verse models.Verse
+	err := r.editVerses(ctx, songId, realign, func(tx *sql.Tx, count int) error {
+		if from < 1 || from > count {
+			return ErrVerseNotFound
+		}
+		if to < 1 || to > count {
+			return ErrInvalidVersePosition
+		}
+
+		if from != to {
+			shift := "verse_number + 1"
+			if from < to {
+				shift = "verse_number - 1"
+			}
+
+			for _, table := range numberedVerseTables {
+				query, args, err := squirrel.Update(table).
+					Set("verse_number", squirrel.Expr("CASE WHEN verse_number = ? THEN ? ELSE "+shift+" END", from, to)).
+					Where(squirrel.Eq{"song_id": songId}).
+					Where(squirrel.Expr("verse_number BETWEEN ? AND ?", min(from, to), max(from, to))).
+					PlaceholderFormat(squirrel.Dollar).ToSql()
+				if err != nil {
+					return err
+				}
+				if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+					return err
+				}
+			}
+		}
+
+		var err error
+		verse, err = r.getVerse(ctx, tx, songId, to)
+		return err
+	})
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to move verse",
+			"error", err,
+			"song_id", songId,
+			"from", from,
+			"to", to)
+	}
+	return verse, err
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (r *VerseRepository) getVerse(ctx context.Context, db queryRower, songId uuid.UUID, number int) (models.Verse, error) {
+	query, args, err := squirrel.Select(verseColumns).
+		From("verses").
+		Where(squirrel.Eq{"song_id": songId, "verse_number": number}).
+		Where(songNotDeleted("verses.song_id")).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for verse lookup",
+			"error", err,
+			"song_id", songId)
+		return models.Verse{}, err
+	}
+
+	verse, err := scanVerse(db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Verse{}, ErrVerseNotFound
+		}
+		r.Logger.Info.ErrorContext(ctx, "Error executing verse lookup query",
+			"error", err,
+			"song_id", songId,
+			"verse_number", number)
+		return models.Verse{}, err
+	}
+
+	return verse, nil
+}
+
+func scanVerse(row rowScanner) (models.Verse, error) {
+	var verse models.Verse
+	var repeatOf uuid.NullUUID
+	err := row.Scan(
+		&verse.Id,
+		&verse.SongId,
+		&verse.VerseNumber,
+		&verse.Text,
+		&verse.SectionType,
+		&repeatOf,
+	)
+	if repeatOf.Valid {
+		verse.RepeatOf = &repeatOf.UUID
+	}
+	return verse, err
+}
+
+// editVerses выполняет правку куплетов в транзакции: блокирует песню, чтобы
+// параллельные правки не перепутали нумерацию, передаёт в edit текущее
+// количество куплетов, после правки восстанавливает ссылки на повторы,
+// пересобирает из куплетов songs.text и текст переводов и переносит на
+// новый текст тайминги через realign, чтобы они не расходились.
+func (r *VerseRepository) editVerses(ctx context.Context, songId uuid.UUID, realign models.LyricsRealigner, edit func(tx *sql.Tx, count int) error) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var locked uuid.UUID
+	err = tx.QueryRowContext(ctx, "SELECT id FROM songs WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", songId).Scan(&locked)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrSongNotFound
+		}
+		return err
+	}
+
+	var count int
+	err = tx.QueryRowContext(ctx, "SELECT count(*) FROM verses WHERE song_id = $1", songId).Scan(&count)
+	if err != nil {
+		return err
+	}
+
+	if err := edit(tx, count); err != nil {
+		return err
+	}
+	if err := relinkRepeats(ctx, tx, songId); err != nil {
+		return err
+	}
+	if err := syncTranslationTexts(ctx, tx, songId); err != nil {
+		return err
+	}
+
+	if err := setActor(ctx, tx); err != nil {
+		return err
+	}
+	text, err := syncSongText(ctx, tx, songId)
+	if err != nil {
+		return err
+	}
+	if err := realignLyricLines(ctx, tx, songId, text, realign); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func syncSongText(ctx context.Context, tx *sql.Tx, songId uuid.UUID) (string, error) {
+	var text string
+	err := tx.QueryRowContext(ctx, `
+		UPDATE songs
+		SET text = COALESCE((SELECT string_agg(text, $2 ORDER BY verse_number) FROM verses WHERE song_id = $1), ''),
+		    updated_at = now(),
+		    version = version + 1
+		WHERE id = $1
+		RETURNING text`,
+		songId, models.VerseSeparator).Scan(&text)
+	return text, err
+}
+
+// realignLyricLines переносит тайминги песни на новый текст. Песни без
+// таймингов не затрагиваются.
+func realignLyricLines(ctx context.Context, tx *sql.Tx, songId uuid.UUID, text string, realign models.LyricsRealigner) error {
+	if realign == nil {
+		return nil
+	}
+
+	existing, err := lyricLinesTx(ctx, tx, songId)
+	if err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		return nil
+	}
+
+	return replaceLyricLines(ctx, tx, songId, realign(songId, existing, text))
+}
 
-	resp.Limit = request.Limit
-	resp.Page = request.Page
-	resp.Total = len(resp.Verses)
-	return resp, err
+// relinkRepeats восстанавливает инвариант repeat_of после перестановки
+// куплетов: куплет ссылается только на более ранний. В каждой группе
+// повторов исходным становится первый по порядку куплет, остальные
+// ссылаются на него.
+func relinkRepeats(ctx context.Context, tx *sql.Tx, songId uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE verses v
+		SET repeat_of = NULLIF(f.first_id, v.id)
+		FROM (
+			SELECT id, first_value(id) OVER (PARTITION BY COALESCE(repeat_of, id) ORDER BY verse_number) AS first_id
+			FROM verses
+			WHERE song_id = $1
+		) f
+		WHERE v.id = f.id AND v.repeat_of IS DISTINCT FROM NULLIF(f.first_id, v.id)`,
+		songId)
+	return err
 }
@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+)
+
+// exportBatchSize - сколько песен экспорта держится в памяти, пока для
+// них загружаются куплеты.
+const exportBatchSize = 500
+
+// ExportLibrary выгружает группы и песни из одного снимка базы, чтобы
+// изменения, сделанные во время выгрузки, не дали песен без групп. С
+// onlyMatching выгружаются только группы песен под фильтр.
+func (r *SongRepository) ExportLibrary(ctx context.Context, request dto.ExportRequest, onlyMatching bool, emitGroup func(models.Group) error, emitSong func(models.Song, []models.Verse) error) error {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to begin transaction for export",
+			"error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if request.WithGroups {
+		err = r.exportGroups(ctx, tx, request.Filter, onlyMatching, emitGroup)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Куплеты догружаются, пока песни ещё читаются, а соединение транзакции
+	// занято их выборкой. Поэтому куплеты читаются во второй транзакции с
+	// тем же снимком.
+	var versesTx *sql.Tx
+	if request.WithVerses {
+		versesTx, err = r.beginSnapshotTx(ctx, tx)
+		if err != nil {
+			return err
+		}
+		defer versesTx.Rollback()
+	}
+
+	err = r.exportSongs(ctx, tx, versesTx, request.Filter, emitSong)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// beginSnapshotTx открывает транзакцию только для чтения, которая видит тот
+// же снимок базы, что и tx.
+func (r *SongRepository) beginSnapshotTx(ctx context.Context, tx *sql.Tx) (*sql.Tx, error) {
+	var snapshot string
+	err := tx.QueryRowContext(ctx, "SELECT pg_export_snapshot()").Scan(&snapshot)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to export snapshot",
+			"error", err)
+		return nil, err
+	}
+
+	snapshotTx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to begin snapshot transaction",
+			"error", err)
+		return nil, err
+	}
+	// SET TRANSACTION SNAPSHOT не принимает параметры, а идентификатор
+	// снимка выдала сама база
+	_, err = snapshotTx.ExecContext(ctx, "SET TRANSACTION SNAPSHOT '"+snapshot+"'")
+	if err != nil {
+		snapshotTx.Rollback()
+		r.Logger.Info.ErrorContext(ctx, "Failed to import snapshot",
+			"error", err,
+			"snapshot", snapshot)
+		return nil, err
+	}
+	return snapshotTx, nil
+}
+
+// exportSongs читает песни по фильтру одним запросом и передаёт их в emit
+// по мере чтения, не загружая всю выборку в память. Если передан versesTx,
+// куплеты догружаются из него пачками по exportBatchSize песен.
+func (r *SongRepository) exportSongs(ctx context.Context, tx *sql.Tx, versesTx *sql.Tx, request dto.FilteredRequest, emit func(models.Song, []models.Verse) error) error {
+	sort, err := parseSongSort(request.Sort)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := filterSongs(squirrel.Select(songColumns).From("songs"), request).
+		OrderBy(sort.orderBy()...).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for songs export",
+			"error", err)
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to execute songs export query",
+			"error", err)
+		return err
+	}
+	defer rows.Close()
+
+	withVerses := versesTx != nil
+	batch := make([]models.Song, 0, exportBatchSize)
+	flush := func() error {
+		var verses map[uuid.UUID][]models.Verse
+		if withVerses && len(batch) > 0 {
+			songIds := make([]uuid.UUID, len(batch))
+			for i, song := range batch {
+				songIds[i] = song.Id
+			}
+			verses, err = r.versesBySong(ctx, versesTx, songIds)
+			if err != nil {
+				return err
+			}
+		}
+		for _, song := range batch {
+			err := emit(song, verses[song.Id])
+			if err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for rows.Next() {
+		song, err := scanSong(rows)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan exported song row",
+				"error", err)
+			return err
+		}
+		batch = append(batch, song)
+		if len(batch) == exportBatchSize || !withVerses {
+			err = flush()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Error iterating over exported song rows",
+			"error", err)
+		return err
+	}
+
+	return flush()
+}
+
+func (r *SongRepository) versesBySong(ctx context.Context, tx *sql.Tx, songIds []uuid.UUID) (map[uuid.UUID][]models.Verse, error) {
+	query, args, err := squirrel.Select(verseColumns).
+		From("verses").
+		Where(squirrel.Eq{"song_id": songIds}).
+		OrderBy("song_id", "verse_number").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for exported verses",
+			"error", err)
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to query exported verses",
+			"error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	verses := make(map[uuid.UUID][]models.Verse, len(songIds))
+	for rows.Next() {
+		verse, err := scanVerse(rows)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan exported verse row",
+				"error", err)
+			return nil, err
+		}
+		verses[verse.SongId] = append(verses[verse.SongId], verse)
+	}
+	err = rows.Err()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Error iterating over exported verse rows",
+			"error", err)
+		return nil, err
+	}
+
+	return verses, nil
+}
+
+// exportGroups передаёт в emit группы по мере чтения. С onlyMatching
+// выгружаются только группы, у которых есть песни под фильтр request.
+func (r *SongRepository) exportGroups(ctx context.Context, tx *sql.Tx, request dto.FilteredRequest, onlyMatching bool, emit func(models.Group) error) error {
+	builder := squirrel.Select("id", "name").From("groups")
+	if onlyMatching {
+		songs := filterSongs(squirrel.Select("group_id").From("songs"), request)
+		sub, subArgs, err := songs.ToSql()
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to build SQL subquery for groups export",
+				"error", err)
+			return err
+		}
+		builder = builder.Where("id IN ("+sub+")", subArgs...)
+	}
+
+	query, args, err := builder.
+		OrderBy("name", "id").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for groups export",
+			"error", err)
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to execute groups export query",
+			"error", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var group models.Group
+		err := rows.Scan(&group.Id, &group.Name)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan exported group row",
+				"error", err)
+			return err
+		}
+		err = emit(group)
+		if err != nil {
+			return err
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Error iterating over exported group rows",
+			"error", err)
+		return err
+	}
+
+	return nil
+}
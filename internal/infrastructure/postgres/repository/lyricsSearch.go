@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"strings"
+	"unicode"
+)
+
+const headlineOptions = "MaxFragments=2, MaxWords=20, MinWords=5"
+
+var searchConfigs = map[string]string{
+	"en": "english",
+	"ru": "russian",
+}
+
+// lyricsTsQuery строит tsquery сразу для русской и английской морфологии,
+// если язык не указан явно: каталог смешанный, и заранее неизвестно,
+// на каком языке написан запрос.
+func lyricsTsQuery(lang, q string) (string, []any) {
+	configs := []string{"english", "russian"}
+	if config, ok := searchConfigs[lang]; ok {
+		configs = []string{config}
+	}
+
+	parts := make([]string, 0, len(configs))
+	args := make([]any, 0, len(configs)*2)
+	for _, config := range configs {
+		parts = append(parts, "websearch_to_tsquery(?::regconfig, ?)")
+		args = append(args, config, q)
+	}
+
+	return "(" + strings.Join(parts, " || ") + ")", args
+}
+
+// headlineConfig выбирает конфигурацию для ts_headline: явно заданный язык
+// или русский, если в запросе есть кириллица.
+func headlineConfig(lang, q string) string {
+	if config, ok := searchConfigs[lang]; ok {
+		return config
+	}
+	for _, r := range q {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return "russian"
+		}
+	}
+	return "english"
+}
+
+func (r *SongRepository) SearchLyrics(ctx context.Context, request dto.LyricsSearchRequest) ([]dto.LyricsSearchResult, error) {
+	tsQuery, tsArgs := lyricsTsQuery(request.Lang, request.Query)
+
+	query, args, err := squirrel.Select(songColumns).
+		Column(squirrel.Expr("ts_rank_cd(search_vector, "+tsQuery+") AS rank", tsArgs...)).
+		From("songs").
+		Where(notDeleted).
+		Where(squirrel.Expr("search_vector @@ "+tsQuery, tsArgs...)).
+		OrderBy("rank DESC", "id ASC").
+		Limit(uint64(request.Limit)).
+		Offset(uint64(request.Offset)).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for lyrics search",
+			"error", err,
+			"query", request.Query)
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to execute lyrics search query",
+			"error", err,
+			"query", request.Query)
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []dto.LyricsSearchResult{}
+	positions := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var result dto.LyricsSearchResult
+		var releaseDate sql.NullString
+		song := &result.Song
+		err := rows.Scan(
+			&song.Id,
+			&song.GroupId,
+			&song.GroupName,
+			&song.Title,
+			&releaseDate,
+			&song.Text,
+			&song.Link,
+			&song.SplitStrategy,
+			&song.Version,
+			&song.CreatedAt,
+			&song.UpdatedAt,
+			&result.Rank,
+		)
+		song.ReleaseDate = releaseDate.String
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan lyrics search row",
+				"error", err)
+			return nil, err
+		}
+		result.Verses = []dto.VerseMatch{}
+		positions[song.Id] = len(results)
+		results = append(results, result)
+	}
+	err = rows.Err()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Error iterating over lyrics search rows",
+			"error", err)
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		return results, nil
+	}
+
+	songIds := make([]uuid.UUID, 0, len(results))
+	for _, result := range results {
+		songIds = append(songIds, result.Song.Id)
+	}
+
+	headlineArgs := append([]any{headlineConfig(request.Lang, request.Query)}, tsArgs...)
+	headlineArgs = append(headlineArgs, headlineOptions)
+
+	query, args, err = squirrel.Select("song_id", "verse_number").
+		Column(squirrel.Expr("ts_headline(?::regconfig, replace(text, '\\n', E'\\n'), "+tsQuery+", ?)", headlineArgs...)).
+		From("verses").
+		Where(squirrel.Eq{"song_id": songIds}).
+		Where(squirrel.Expr("search_vector @@ "+tsQuery, tsArgs...)).
+		OrderBy("song_id", "verse_number").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for verse highlights",
+			"error", err,
+			"query", request.Query)
+		return nil, err
+	}
+
+	verseRows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to execute verse highlights query",
+			"error", err,
+			"query", request.Query)
+		return nil, err
+	}
+	defer verseRows.Close()
+
+	for verseRows.Next() {
+		var songId uuid.UUID
+		var match dto.VerseMatch
+		err := verseRows.Scan(&songId, &match.VerseNumber, &match.Snippet)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan verse highlight row",
+				"error", err)
+			return nil, err
+		}
+		i := positions[songId]
+		results[i].Verses = append(results[i].Verses, match)
+	}
+	err = verseRows.Err()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Error iterating over verse highlight rows",
+			"error", err)
+		return nil, err
+	}
+
+	return results, nil
+}
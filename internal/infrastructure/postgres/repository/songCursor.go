@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"strings"
+	"time"
+)
+
+const defaultSongSort = "created_at"
+
+var (
+	ErrInvalidSort   = apperr.New(apperr.Validation, "invalid_sort", "invalid sort")
+	ErrInvalidCursor = apperr.New(apperr.Validation, "invalid_cursor", "invalid cursor")
+)
+
+// songSortKey описывает поле, по которому можно сортировать песни.
+// expr используется и в ORDER BY, и в условии курсора, поэтому NULL
+// заменяются на значение по умолчанию - иначе сравнение строк ломается.
+// valid проверяет значение из курсора до того, как оно попадёт в запрос:
+// курсор приходит от клиента, и значение, которое не приводится к cast,
+// должно давать ошибку валидации (422), а не ошибку базы.
+type songSortKey struct {
+	expr  string
+	cast  string
+	value func(song models.Song) string
+	valid func(value string) bool
+}
+
+var songSortKeys = map[string]songSortKey{
+	"title": {
+		expr:  "title",
+		cast:  "text",
+		value: func(song models.Song) string { return song.Title },
+	},
+	"release_date": {
+		expr: "COALESCE(release_date, '-infinity'::date)",
+		cast: "date",
+		value: func(song models.Song) string {
+			if song.ReleaseDate == "" {
+				return "-infinity"
+			}
+			return song.ReleaseDate
+		},
+		valid: func(value string) bool {
+			return value == "-infinity" || isTime(value, time.DateOnly, time.RFC3339Nano)
+		},
+	},
+	"created_at": {
+		expr: "COALESCE(created_at, 'epoch'::timestamp)",
+		cast: "timestamp",
+		value: func(song models.Song) string {
+			return song.CreatedAt.UTC().Format(time.RFC3339Nano)
+		},
+		valid: func(value string) bool { return isTime(value, time.RFC3339Nano) },
+	},
+	"group_name": {
+		expr:  "COALESCE(group_name, '')",
+		cast:  "text",
+		value: func(song models.Song) string { return song.GroupName },
+	},
+}
+
+func isTime(value string, layouts ...string) bool {
+	for _, layout := range layouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+type songSortField struct {
+	name string
+	desc bool
+}
+
+type songSort []songSortField
+
+// parseSongSort разбирает строку вида "group_name,-release_date".
+// Минус перед полем означает сортировку по убыванию.
+func parseSongSort(raw string) (songSort, error) {
+	if strings.TrimSpace(raw) == "" {
+		raw = defaultSongSort
+	}
+
+	var sort songSort
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		field := songSortField{name: part}
+		if strings.HasPrefix(part, "-") {
+			field.name = part[1:]
+			field.desc = true
+		}
+
+		if _, ok := songSortKeys[field.name]; !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidSort, field.name)
+		}
+		if seen[field.name] {
+			return nil, fmt.Errorf("%w: duplicate field %q", ErrInvalidSort, field.name)
+		}
+		seen[field.name] = true
+
+		sort = append(sort, field)
+	}
+
+	return sort, nil
+}
+
+func (s songSort) String() string {
+	parts := make([]string, 0, len(s))
+	for _, field := range s {
+		if field.desc {
+			parts = append(parts, "-"+field.name)
+		} else {
+			parts = append(parts, field.name)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// orderBy всегда добавляет id в конец, чтобы порядок был строгим
+// даже при совпадающих значениях полей сортировки.
+func (s songSort) orderBy() []string {
+	clauses := make([]string, 0, len(s)+1)
+	for _, field := range s {
+		direction := "ASC"
+		if field.desc {
+			direction = "DESC"
+		}
+		clauses = append(clauses, songSortKeys[field.name].expr+" "+direction)
+	}
+	return append(clauses, "id ASC")
+}
+
+// after строит условие keyset-пагинации: строка идёт после курсора, если
+// первое отличающееся поле сортировки больше (или меньше для DESC).
+func (s songSort) after(cursor songCursor) squirrel.Sqlizer {
+	var or squirrel.Or
+	var equal squirrel.And
+
+	for i, field := range s {
+		key := songSortKeys[field.name]
+		op := ">"
+		if field.desc {
+			op = "<"
+		}
+
+		term := append(squirrel.And{}, equal...)
+		term = append(term, squirrel.Expr(fmt.Sprintf("%s %s ?::%s", key.expr, op, key.cast), cursor.Values[i]))
+		or = append(or, term)
+
+		equal = append(equal, squirrel.Expr(fmt.Sprintf("%s = ?::%s", key.expr, key.cast), cursor.Values[i]))
+	}
+
+	last := append(squirrel.And{}, equal...)
+	last = append(last, squirrel.Expr("id > ?", cursor.Id))
+	return append(or, last)
+}
+
+type songCursor struct {
+	Sort   string    `json:"s"`
+	Values []string  `json:"v"`
+	Id     uuid.UUID `json:"id"`
+}
+
+func encodeSongCursor(sort songSort, last models.Song) (string, error) {
+	cursor := songCursor{
+		Sort: sort.String(),
+		Id:   last.Id,
+	}
+	for _, field := range sort {
+		cursor.Values = append(cursor.Values, songSortKeys[field.name].value(last))
+	}
+
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeSongCursor проверяет, что курсор выдан для той же сортировки,
+// иначе значения в нём не соответствуют полям ORDER BY.
+func decodeSongCursor(raw string, sort songSort) (songCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return songCursor{}, ErrInvalidCursor
+	}
+
+	var cursor songCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return songCursor{}, ErrInvalidCursor
+	}
+
+	if cursor.Sort != sort.String() || len(cursor.Values) != len(sort) {
+		return songCursor{}, fmt.Errorf("%w: cursor was issued for a different sort", ErrInvalidCursor)
+	}
+	for i, field := range sort {
+		if valid := songSortKeys[field.name].valid; valid != nil && !valid(cursor.Values[i]) {
+			return songCursor{}, fmt.Errorf("%w: bad %s value", ErrInvalidCursor, field.name)
+		}
+	}
+
+	return cursor, nil
+}
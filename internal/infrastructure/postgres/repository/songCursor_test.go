@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSongSort(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{raw: "", want: "created_at"},
+		{raw: "title", want: "title"},
+		{raw: " group_name , -release_date ", want: "group_name,-release_date"},
+		{raw: "-created_at,title", want: "-created_at,title"},
+		{raw: "text", wantErr: true},
+		{raw: "title,-title", wantErr: true},
+		{raw: "title,", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := parseSongSort(tt.raw)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSort) {
+					t.Fatalf("parseSongSort() error = %v, want %v", err, ErrInvalidSort)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSongSort() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("parseSongSort() = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSongCursorRoundTrip(t *testing.T) {
+	song := models.Song{
+		Id:        uuid.New(),
+		GroupName: "Muse",
+		Title:     "Supermassive Black Hole",
+		CreatedAt: time.Date(2024, 3, 1, 12, 30, 0, 123456000, time.FixedZone("MSK", 3*60*60)),
+	}
+
+	tests := []struct {
+		sort        string
+		releaseDate string
+		want        []string
+	}{
+		{sort: "created_at", want: []string{"2024-03-01T09:30:00.123456Z"}},
+		{sort: "title,-group_name", want: []string{"Supermassive Black Hole", "Muse"}},
+		{sort: "-release_date", releaseDate: "2006-07-16T00:00:00Z", want: []string{"2006-07-16T00:00:00Z"}},
+		{sort: "release_date", releaseDate: "2006-07-16", want: []string{"2006-07-16"}},
+		{sort: "release_date", want: []string{"-infinity"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			sort, err := parseSongSort(tt.sort)
+			if err != nil {
+				t.Fatal(err)
+			}
+			song := song
+			song.ReleaseDate = tt.releaseDate
+
+			raw, err := encodeSongCursor(sort, song)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cursor, err := decodeSongCursor(raw, sort)
+			if err != nil {
+				t.Fatalf("decodeSongCursor() error = %v", err)
+			}
+
+			want := songCursor{Sort: sort.String(), Values: tt.want, Id: song.Id}
+			if !reflect.DeepEqual(cursor, want) {
+				t.Errorf("decodeSongCursor() = %+v, want %+v", cursor, want)
+			}
+		})
+	}
+}
+
+func TestDecodeSongCursorRejectsTampering(t *testing.T) {
+	encode := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+	id := uuid.New().String()
+
+	tests := []struct {
+		name string
+		sort string
+		raw  string
+	}{
+		{name: "не base64", sort: "created_at", raw: "not a cursor!"},
+		{name: "стандартный base64 с паддингом", sort: "created_at", raw: base64.StdEncoding.EncodeToString([]byte(`{"s":"created_at"}`))},
+		{name: "не JSON", sort: "created_at", raw: encode("created_at")},
+		{name: "некорректный id", sort: "title", raw: encode(`{"s":"title","v":["a"],"id":"42"}`)},
+		{name: "другая сортировка", sort: "title", raw: encode(`{"s":"-title","v":["a"],"id":"` + id + `"}`)},
+		{name: "лишнее значение", sort: "title", raw: encode(`{"s":"title","v":["a","b"],"id":"` + id + `"}`)},
+		{name: "нет значений", sort: "title", raw: encode(`{"s":"title","id":"` + id + `"}`)},
+		{name: "created_at не время", sort: "created_at", raw: encode(`{"s":"created_at","v":["yesterday"],"id":"` + id + `"}`)},
+		{name: "release_date не дата", sort: "release_date", raw: encode(`{"s":"release_date","v":["2024-13-45"],"id":"` + id + `"}`)},
+		{name: "SQL в значении", sort: "release_date", raw: encode(`{"s":"release_date","v":["2024-01-01'; DROP TABLE songs; --"],"id":"` + id + `"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sort, err := parseSongSort(tt.sort)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = decodeSongCursor(tt.raw, sort)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeSongCursor() error = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
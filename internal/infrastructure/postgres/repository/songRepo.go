@@ -6,12 +6,32 @@ import (
 	"errors"
 	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
 	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
 	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
 	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
+	"strings"
 	"time"
 )
 
+var (
+	ErrSongNotFound = apperr.New(apperr.NotFound, "song_not_found", "song doesn't exist")
+	ErrSongExists   = apperr.New(apperr.Conflict, "song_exists", "song already exists")
+	ErrSongModified = apperr.New(apperr.PreconditionFailed, "song_modified", "song was modified by another request")
+)
+
+const songColumns = "id, group_id, group_name, title, release_date, text, link, COALESCE(split_strategy, ''), version, created_at, updated_at"
+
+// notDeleted отсекает песни в корзине. Его добавляют все чтения и
+// изменения песен, кроме работы с самой корзиной.
+var notDeleted = squirrel.Eq{"deleted_at": nil}
+
+// songNotDeleted - то же условие для таблиц, привязанных к песне: куплеты,
+// тайминги и переводы песни из корзины не видны.
+func songNotDeleted(songIdColumn string) squirrel.Sqlizer {
+	return squirrel.Expr("EXISTS (SELECT 1 FROM songs WHERE songs.id = " + songIdColumn + " AND songs.deleted_at IS NULL)")
+}
+
 type SongRepository struct {
 	db     *sql.DB
 	Logger *logger.Logger
@@ -27,7 +47,7 @@ func NewSongRepo(db *sql.DB, logger *logger.Logger) *SongRepository {
 func (r *SongRepository) CreateSong(ctx context.Context, song models.Song) error {
 	exists, err := r.SongExistsByDetails(ctx, song)
 	if err != nil {
-		r.Logger.Info.Error("Failed to check if song exists",
+		r.Logger.Info.ErrorContext(ctx, "Failed to check if song exists",
 			"error", err,
 			"song_title", song.Title,
 			"group_name", song.GroupName)
@@ -35,63 +55,72 @@ func (r *SongRepository) CreateSong(ctx context.Context, song models.Song) error
 	}
 
 	if exists {
-		return errors.New("song already exists")
+		return ErrSongExists
 	}
 
 	query, args, err := squirrel.Insert("Songs").Columns("id, group_id, group_name, title, release_date, text, link").
 		Values(song.Id, song.GroupId, song.GroupName, song.Title, song.ReleaseDate, song.Text, song.Link).
 		PlaceholderFormat(squirrel.Dollar).ToSql()
 	if err != nil {
-		r.Logger.Info.Error("Failed to build SQL query for song creation",
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for song creation",
+			"error", err,
+			"song_id", song.Id)
+		return err
+	}
+
+	// Первую ревизию пишет триггер, автор должен попасть в ту же транзакцию
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to begin transaction for song creation",
 			"error", err,
 			"song_id", song.Id)
 		return err
 	}
+	defer tx.Rollback()
 
-	_, err = r.db.ExecContext(ctx, query, args...)
+	err = setActor(ctx, tx)
 	if err != nil {
-		r.Logger.Info.Error("Failed to execute song creation query",
+		r.Logger.Info.ErrorContext(ctx, "Failed to set revision actor",
+			"error", err,
+			"song_id", song.Id)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to execute song creation query",
 			"error", err,
 			"song_id", song.Id,
 			"song_title", song.Title)
 		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
 func (r *SongRepository) GetSongById(ctx context.Context, songId uuid.UUID) (models.Song, error) {
-	query, args, err := squirrel.Select("id, group_id, group_name, title, release_date, text, link, created_at, updated_at").
+	query, args, err := squirrel.Select(songColumns).
 		From("songs").
 		Where(squirrel.Eq{
 			"id": songId,
-		}).PlaceholderFormat(squirrel.Dollar).ToSql()
+		}).
+		Where(notDeleted).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
 	if err != nil {
-		r.Logger.Info.Error("Failed to build SQL query for song lookup by ID",
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for song lookup by ID",
 			"error", err,
 			"song_id", songId)
 		return models.Song{}, err
 	}
 
-	var song models.Song
-	err = r.db.QueryRowContext(ctx, query, args...).Scan(
-		&song.Id,
-		&song.GroupId,
-		&song.GroupName,
-		&song.Title,
-		&song.ReleaseDate,
-		&song.Text,
-		&song.Link,
-		&song.CreatedAt,
-		&song.UpdatedAt,
-	)
-
+	song, err := scanSong(r.db.QueryRowContext(ctx, query, args...))
 	if err != nil {
-		if err != sql.ErrNoRows {
-			r.Logger.Info.Error("Error executing song lookup query",
-				"error", err,
-				"song_id", songId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Song{}, ErrSongNotFound
 		}
+		r.Logger.Info.ErrorContext(ctx, "Error executing song lookup query",
+			"error", err,
+			"song_id", songId)
 		return models.Song{}, err
 	}
 
@@ -104,10 +133,11 @@ func (r *SongRepository) SongExsistsById(ctx context.Context, songId uuid.UUID)
 		Where(squirrel.Eq{
 			"id": songId,
 		}).
+		Where(notDeleted).
 		PlaceholderFormat(squirrel.Dollar).
 		ToSql()
 	if err != nil {
-		r.Logger.Info.Error("Failed to build SQL query to check song existence by ID",
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query to check song existence by ID",
 			"error", err,
 			"song_id", songId)
 		return false, err
@@ -120,7 +150,7 @@ func (r *SongRepository) SongExsistsById(ctx context.Context, songId uuid.UUID)
 		if errors.Is(err, sql.ErrNoRows) {
 			return false, nil
 		}
-		r.Logger.Info.Error("Error checking if song exists by ID",
+		r.Logger.Info.ErrorContext(ctx, "Error checking if song exists by ID",
 			"error", err,
 			"song_id", songId)
 		return false, err
@@ -139,10 +169,11 @@ func (r *SongRepository) SongExistsByDetails(ctx context.Context, song models.So
 			"text":         song.Text,
 			"link":         song.Link,
 		}).
+		Where(notDeleted).
 		PlaceholderFormat(squirrel.Dollar).
 		ToSql()
 	if err != nil {
-		r.Logger.Info.Error("Failed to build SQL query to check song existence by details",
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query to check song existence by details",
 			"error", err,
 			"title", song.Title,
 			"group_name", song.GroupName)
@@ -156,7 +187,7 @@ func (r *SongRepository) SongExistsByDetails(ctx context.Context, song models.So
 		if errors.Is(err, sql.ErrNoRows) {
 			return false, nil
 		}
-		r.Logger.Info.Error("Error checking if song exists by details",
+		r.Logger.Info.ErrorContext(ctx, "Error checking if song exists by details",
 			"error", err,
 			"title", song.Title,
 			"group_name", song.GroupName)
@@ -166,119 +197,226 @@ func (r *SongRepository) SongExistsByDetails(ctx context.Context, song models.So
 	return true, nil
 }
 
-func (r *SongRepository) UpdateSong(ctx context.Context, song models.Song) error {
-	exists, err := r.SongExsistsById(ctx, song.Id)
+// UpdateSong обновляет непустые поля песни, только если её версия в базе всё
+// ещё равна song.Version; иначе кто-то успел изменить песню и возвращается
+// ErrSongModified. Нулевая версия отключает проверку. Если verses или lines
+// не nil, куплеты и строки с таймингами заменяются в той же транзакции, чтобы
+// текст, куплеты и тайминги не расходились.
+func (r *SongRepository) UpdateSong(ctx context.Context, song models.Song, verses []models.Verse, lines []models.LyricLine) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		r.Logger.Info.Error("Failed to check if song exists before update",
+		r.Logger.Info.ErrorContext(ctx, "Failed to begin transaction for song update",
 			"error", err,
 			"song_id", song.Id)
 		return err
 	}
+	defer tx.Rollback()
 
-	if !exists {
-		r.Logger.Info.Error("Song does not exist for update",
+	err = setActor(ctx, tx)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to set revision actor",
+			"error", err,
 			"song_id", song.Id)
-		return errors.New("song doesn't exists")
+		return err
 	}
 
+	// Песня сохраняется целиком: пустые строки - очищенные поля, а не
+	// "оставить как было", это решает сервис
 	queryBuilder := squirrel.Update("songs").
 		Where(squirrel.Eq{"id": song.Id}).
+		Where(notDeleted).
+		Set("group_id", song.GroupId).
+		Set("group_name", song.GroupName).
+		Set("title", song.Title).
+		Set("release_date", squirrel.Expr("NULLIF(?, '')::date", song.ReleaseDate)).
+		Set("text", song.Text).
+		Set("link", song.Link).
+		Set("split_strategy", squirrel.Expr("NULLIF(?, '')", song.SplitStrategy)).
 		Set("updated_at", time.Now()).
+		Set("version", squirrel.Expr("version + 1")).
 		PlaceholderFormat(squirrel.Dollar)
-
-	if song.GroupId != uuid.Nil {
-		queryBuilder = queryBuilder.Set("group_id", song.GroupId)
-	}
-	if song.Title != "" {
-		queryBuilder = queryBuilder.Set("title", song.Title)
-	}
-	if song.ReleaseDate != "" {
-		queryBuilder = queryBuilder.Set("release_date", song.ReleaseDate)
-	}
-	if song.Text != "" {
-		queryBuilder = queryBuilder.Set("text", song.Text)
-	}
-	if song.Link != "" {
-		queryBuilder = queryBuilder.Set("link", song.Link)
+	if song.Version > 0 {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"version": song.Version})
 	}
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
-		r.Logger.Info.Error("Failed to build SQL query for song update",
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for song update",
 			"error", err,
 			"song_id", song.Id)
 		return err
 	}
 
-	_, err = r.db.ExecContext(ctx, query, args...)
+	result, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
-		r.Logger.Info.Error("Failed to execute song update query",
+		r.Logger.Info.ErrorContext(ctx, "Failed to execute song update query",
 			"error", err,
 			"song_id", song.Id)
 		return err
 	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return r.notUpdatedError(ctx, song.Id)
+	}
 
-	return err
-}
+	if verses != nil {
+		err = replaceVerses(ctx, tx, song.Id, verses)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to replace verses on song update",
+				"error", err,
+				"song_id", song.Id)
+			return err
+		}
+	}
 
-func (r *SongRepository) DeleteSong(ctx context.Context, songId uuid.UUID) error {
-	exists, err := r.SongExsistsById(ctx, songId)
+	if lines != nil {
+		err = replaceLyricLines(ctx, tx, song.Id, lines)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to replace lyric lines on song update",
+				"error", err,
+				"song_id", song.Id)
+			return err
+		}
+	}
+
+	err = tx.Commit()
 	if err != nil {
-		r.Logger.Info.Error("Failed to check if song exists before deletion",
+		r.Logger.Info.ErrorContext(ctx, "Failed to commit transaction for song update",
 			"error", err,
-			"song_id", songId)
+			"song_id", song.Id)
 		return err
 	}
 
-	if !exists {
-		r.Logger.Info.Error("Song does not exist for deletion",
-			"song_id", songId)
-		return errors.New("song doesn't exist")
+	return nil
+}
+
+// GetSongIdsAfter возвращает id песен по возрастанию, начиная после after.
+// Используется для обхода всей библиотеки пачками.
+func (r *SongRepository) GetSongIdsAfter(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	query, args, err := squirrel.Select("id").
+		From("songs").
+		Where(squirrel.Gt{"id": after}).
+		Where(notDeleted).
+		OrderBy("id ASC").
+		Limit(uint64(limit)).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for song ids",
+			"error", err)
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to execute song ids query",
+			"error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan song id",
+				"error", err)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	err = rows.Err()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Error iterating over song ids",
+			"error", err)
+		return nil, err
 	}
 
-	query, args, err := squirrel.Delete("songs").
+	return ids, nil
+}
+
+// DeleteSong переносит песню в корзину: куплеты и переводы остаются, пока
+// песню не восстановят или не очистят. Если version не нулевая, песня
+// удаляется только в этой версии, как в UpdateSong.
+func (r *SongRepository) DeleteSong(ctx context.Context, songId uuid.UUID, version int) error {
+	queryBuilder := squirrel.Update("songs").
+		Set("deleted_at", squirrel.Expr("now()")).
+		Set("version", squirrel.Expr("version + 1")).
 		Where(squirrel.Eq{
 			"id": songId,
-		}).PlaceholderFormat(squirrel.Dollar).ToSql()
+		}).
+		Where(notDeleted).
+		PlaceholderFormat(squirrel.Dollar)
+	if version > 0 {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"version": version})
+	}
+
+	query, args, err := queryBuilder.ToSql()
 	if err != nil {
-		r.Logger.Info.Error("Failed to build SQL query for song deletion",
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for song deletion",
 			"error", err,
 			"song_id", songId)
 		return err
 	}
 
-	_, err = r.db.ExecContext(ctx, query, args...)
+	result, err := r.db.ExecContext(ctx, query, args...)
 	if err != nil {
-		r.Logger.Info.Error("Failed to execute song deletion query",
+		r.Logger.Info.ErrorContext(ctx, "Failed to execute song deletion query",
 			"error", err,
 			"song_id", songId)
 		return err
 	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return r.notUpdatedError(ctx, songId)
+	}
 
 	return nil
 }
 
+// notUpdatedError объясняет, почему запрос с условием на версию не затронул
+// ни одной строки: песни нет или её версия уже другая.
+func (r *SongRepository) notUpdatedError(ctx context.Context, songId uuid.UUID) error {
+	exists, err := r.SongExsistsById(ctx, songId)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		r.Logger.Info.ErrorContext(ctx, "Song does not exist",
+			"song_id", songId)
+		return ErrSongNotFound
+	}
+	return ErrSongModified
+}
+
 func (r *SongRepository) GetSongTextById(ctx context.Context, songId uuid.UUID) (string, error) {
 	exists, err := r.SongExsistsById(ctx, songId)
 	if err != nil {
-		r.Logger.Info.Error("Failed to check if song exists before getting text",
+		r.Logger.Info.ErrorContext(ctx, "Failed to check if song exists before getting text",
 			"error", err,
 			"song_id", songId)
 		return "", err
 	}
 	if !exists {
-		r.Logger.Info.Error("Song does not exist for text retrieval",
+		r.Logger.Info.ErrorContext(ctx, "Song does not exist for text retrieval",
 			"song_id", songId)
-		return "", errors.New("song doesn't exist")
+		return "", ErrSongNotFound
 	}
 
 	query, args, err := squirrel.Select("text").
 		From("songs").
 		Where(squirrel.Eq{
 			"id": songId,
-		}).PlaceholderFormat(squirrel.Dollar).ToSql()
+		}).
+		Where(notDeleted).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
 	if err != nil {
-		r.Logger.Info.Error("Failed to build SQL query for song text retrieval",
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for song text retrieval",
 			"error", err,
 			"song_id", songId)
 		return "", err
@@ -287,7 +425,7 @@ func (r *SongRepository) GetSongTextById(ctx context.Context, songId uuid.UUID)
 	var text string
 	err = r.db.QueryRowContext(ctx, query, args...).Scan(&text)
 	if err != nil {
-		r.Logger.Info.Error("Failed to retrieve song text",
+		r.Logger.Info.ErrorContext(ctx, "Failed to retrieve song text",
 			"error", err,
 			"song_id", songId)
 		return "", err
@@ -296,58 +434,190 @@ func (r *SongRepository) GetSongTextById(ctx context.Context, songId uuid.UUID)
 	return text, nil
 }
 
-func (r *SongRepository) GetSongsWithFilter(ctx context.Context, request dto.FilteredRequest) ([]models.Song, error) {
-	builder := squirrel.Select("id, group_id, group_name, title, release_date, text, link, created_at, updated_at").
-		From("songs")
+func (r *SongRepository) GetSongsWithFilter(ctx context.Context, request dto.FilteredRequest) ([]models.Song, string, error) {
+	sort, err := parseSongSort(request.Sort)
+	if err != nil {
+		return nil, "", err
+	}
+
+	builder := filterSongs(squirrel.Select(songColumns).From("songs"), request)
+
+	if request.Cursor != "" {
+		cursor, err := decodeSongCursor(request.Cursor, sort)
+		if err != nil {
+			return nil, "", err
+		}
+		builder = builder.Where(sort.after(cursor))
+	}
+
+	// Берём на одну запись больше, чтобы понять, есть ли следующая страница
+	builder = builder.OrderBy(sort.orderBy()...).Limit(uint64(request.Limit + 1))
+
+	query, args, err := builder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for filtered songs",
+			"error", err)
+		return nil, "", err
+	}
+
+	var songs []models.Song
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to execute filtered songs query",
+			"error", err)
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		song, err := scanSong(rows)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan song row",
+				"error", err)
+			return nil, "", err
+		}
+		songs = append(songs, song)
+	}
+	err = rows.Err()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Error iterating over song rows",
+			"error", err)
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(songs) > request.Limit {
+		songs = songs[:request.Limit]
+		nextCursor, err = encodeSongCursor(sort, songs[len(songs)-1])
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to encode songs cursor",
+				"error", err)
+			return nil, "", err
+		}
+	}
+
+	return songs, nextCursor, nil
+}
+
+// filterSongs добавляет к запросу условия фильтров поиска песен. Курсор,
+// сортировка и лимит сюда не входят.
+func filterSongs(builder squirrel.SelectBuilder, request dto.FilteredRequest) squirrel.SelectBuilder {
+	builder = builder.Where(notDeleted)
 
-	// Apply filters
 	if request.Title != "" {
-		builder = builder.Where(squirrel.Eq{"title": request.Title})
+		builder = builder.Where(matchExpr("title", request.Title, request.Match))
+	}
+	if len(request.Groups) > 0 {
+		groups := squirrel.Or{}
+		for _, group := range request.Groups {
+			groups = append(groups, matchExpr("group_name", group, request.Match))
+		}
+		builder = builder.Where(groups)
+	}
+	if request.ReleasedFrom != "" {
+		builder = builder.Where(squirrel.Expr("release_date >= ?::date", request.ReleasedFrom))
+	}
+	if request.ReleasedTo != "" {
+		builder = builder.Where(squirrel.Expr("release_date <= ?::date", request.ReleasedTo))
 	}
-	if request.ReleaseDate != "" {
-		builder = builder.Where(squirrel.Eq{"release_date": request.ReleaseDate})
+	if request.Query != "" {
+		pattern := "%" + escapeLike(request.Query) + "%"
+		builder = builder.Where(squirrel.Or{
+			squirrel.ILike{"title": pattern},
+			squirrel.ILike{"group_name": pattern},
+		})
 	}
 	if request.Text != "" {
-		builder = builder.Where(squirrel.Eq{"text": request.Text})
+		builder = builder.Where(squirrel.ILike{"text": "%" + escapeLike(request.Text) + "%"})
 	}
 	if request.Link != "" {
 		builder = builder.Where(squirrel.Eq{"link": request.Link})
 	}
-	if request.GroupName != "" {
-		builder = builder.Where(squirrel.Eq{"group_name": request.GroupName})
-	}
 
-	query, args, err := builder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	return builder
+}
+
+func (r *SongRepository) GetSongsByGroupId(ctx context.Context, groupId uuid.UUID) ([]models.Song, error) {
+	query, args, err := squirrel.Select(songColumns).
+		From("songs").
+		Where(squirrel.Eq{"group_id": groupId}).
+		Where(notDeleted).
+		OrderBy("release_date ASC", "title ASC", "id ASC").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
 	if err != nil {
-		r.Logger.Info.Error("Failed to build SQL query for filtered songs",
-			"error", err)
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for group songs",
+			"error", err,
+			"group_id", groupId)
 		return nil, err
 	}
 
-	var songs []models.Song
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		r.Logger.Info.Error("Failed to execute filtered songs query",
-			"error", err)
+		r.Logger.Info.ErrorContext(ctx, "Failed to execute group songs query",
+			"error", err,
+			"group_id", groupId)
 		return nil, err
 	}
 	defer rows.Close()
 
+	songs := []models.Song{}
 	for rows.Next() {
-		var song models.Song
-		rows.Scan(
-			&song.Id,
-			&song.GroupId,
-			&song.GroupName,
-			&song.Title,
-			&song.ReleaseDate,
-			&song.Text,
-			&song.Link,
-			&song.CreatedAt,
-			&song.UpdatedAt,
-		)
+		song, err := scanSong(rows)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan song row",
+				"error", err)
+			return nil, err
+		}
 		songs = append(songs, song)
 	}
+	err = rows.Err()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Error iterating over song rows",
+			"error", err)
+		return nil, err
+	}
 
 	return songs, nil
 }
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+// matchExpr сравнивает колонку со значением без учёта регистра.
+func matchExpr(column, value, match string) squirrel.Sqlizer {
+	switch match {
+	case dto.MatchExact:
+		return squirrel.Expr("lower("+column+") = lower(?)", value)
+	case dto.MatchPrefix:
+		return squirrel.ILike{column: escapeLike(value) + "%"}
+	default:
+		return squirrel.ILike{column: "%" + escapeLike(value) + "%"}
+	}
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSong(row rowScanner) (models.Song, error) {
+	var song models.Song
+	var releaseDate sql.NullString
+	err := row.Scan(
+		&song.Id,
+		&song.GroupId,
+		&song.GroupName,
+		&song.Title,
+		&releaseDate,
+		&song.Text,
+		&song.Link,
+		&song.SplitStrategy,
+		&song.Version,
+		&song.CreatedAt,
+		&song.UpdatedAt,
+	)
+	song.ReleaseDate = releaseDate.String
+	return song, err
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/actor"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+)
+
+var ErrRevisionNotFound = apperr.New(apperr.NotFound, "revision_not_found", "song revision doesn't exist")
+
+const revisionColumns = "song_id, revision, snapshot, to_jsonb(changed_fields), COALESCE(actor, ''), created_at"
+
+// setActor передаёт автора изменения триггеру, который пишет ревизии песни.
+// Настройка действует до конца транзакции.
+func setActor(ctx context.Context, tx *sql.Tx) error {
+	name := actor.FromContext(ctx)
+	if name == "" {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, "SELECT set_config('app.actor', $1, true)", name)
+	return err
+}
+
+// ListRevisions возвращает ревизии песни, начиная с последней.
+func (r *SongRepository) ListRevisions(ctx context.Context, songId uuid.UUID, page, limit int) ([]models.SongRevision, int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT count(*) FROM song_revisions WHERE song_id = $1", songId).Scan(&total)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to count song revisions",
+			"error", err,
+			"song_id", songId)
+		return nil, 0, err
+	}
+
+	query, args, err := squirrel.Select(revisionColumns).
+		From("song_revisions").
+		Where(squirrel.Eq{"song_id": songId}).
+		Where(songNotDeleted("song_revisions.song_id")).
+		OrderBy("revision DESC").
+		Limit(uint64(limit)).
+		Offset(uint64((page - 1) * limit)).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for song revisions",
+			"error", err,
+			"song_id", songId)
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to query song revisions",
+			"error", err,
+			"song_id", songId)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	revisions := []models.SongRevision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan song revision row",
+				"error", err,
+				"song_id", songId)
+			return nil, 0, err
+		}
+		revisions = append(revisions, revision)
+	}
+	err = rows.Err()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Error iterating over song revision rows",
+			"error", err)
+		return nil, 0, err
+	}
+
+	return revisions, total, nil
+}
+
+// GetRevision возвращает ревизию песни с номером revision.
+func (r *SongRepository) GetRevision(ctx context.Context, songId uuid.UUID, revision int) (models.SongRevision, error) {
+	query, args, err := squirrel.Select(revisionColumns).
+		From("song_revisions").
+		Where(squirrel.Eq{"song_id": songId, "revision": revision}).
+		Where(songNotDeleted("song_revisions.song_id")).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for song revision",
+			"error", err,
+			"song_id", songId)
+		return models.SongRevision{}, err
+	}
+
+	result, err := scanRevision(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SongRevision{}, ErrRevisionNotFound
+		}
+		r.Logger.Info.ErrorContext(ctx, "Failed to get song revision",
+			"error", err,
+			"song_id", songId,
+			"revision", revision)
+		return models.SongRevision{}, err
+	}
+
+	return result, nil
+}
+
+func scanRevision(row rowScanner) (models.SongRevision, error) {
+	var revision models.SongRevision
+	var snapshot, changedFields []byte
+	err := row.Scan(
+		&revision.SongId,
+		&revision.Revision,
+		&snapshot,
+		&changedFields,
+		&revision.Actor,
+		&revision.CreatedAt,
+	)
+	if err != nil {
+		return models.SongRevision{}, err
+	}
+	if err := json.Unmarshal(snapshot, &revision.Snapshot); err != nil {
+		return models.SongRevision{}, err
+	}
+	if err := json.Unmarshal(changedFields, &revision.ChangedFields); err != nil {
+		return models.SongRevision{}, err
+	}
+	return revision, nil
+}
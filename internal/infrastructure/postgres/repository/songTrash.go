@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"time"
+)
+
+var ErrSongNotInTrash = apperr.New(apperr.NotFound, "song_not_in_trash", "song is not in trash")
+
+// ListTrash возвращает песни из корзины, начиная с удалённых последними.
+func (r *SongRepository) ListTrash(ctx context.Context, page, limit int) ([]dto.TrashedSong, int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT count(*) FROM songs WHERE deleted_at IS NOT NULL").Scan(&total)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to count trashed songs",
+			"error", err)
+		return nil, 0, err
+	}
+
+	query, args, err := squirrel.Select(songColumns, "deleted_at").
+		From("songs").
+		Where(squirrel.NotEq{"deleted_at": nil}).
+		OrderBy("deleted_at DESC", "id ASC").
+		Limit(uint64(limit)).
+		Offset(uint64((page - 1) * limit)).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for trash list",
+			"error", err)
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to query trashed songs",
+			"error", err)
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	songs := []dto.TrashedSong{}
+	for rows.Next() {
+		var trashed dto.TrashedSong
+		var releaseDate sql.NullString
+		song := &trashed.Song
+		err := rows.Scan(
+			&song.Id,
+			&song.GroupId,
+			&song.GroupName,
+			&song.Title,
+			&releaseDate,
+			&song.Text,
+			&song.Link,
+			&song.SplitStrategy,
+			&song.Version,
+			&song.CreatedAt,
+			&song.UpdatedAt,
+			&trashed.DeletedAt,
+		)
+		song.ReleaseDate = releaseDate.String
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to scan trashed song row",
+				"error", err)
+			return nil, 0, err
+		}
+		songs = append(songs, trashed)
+	}
+	err = rows.Err()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Error iterating over trashed song rows",
+			"error", err)
+		return nil, 0, err
+	}
+
+	return songs, total, nil
+}
+
+// RestoreSong возвращает песню из корзины. Если за это время создали такую
+// же песню, восстановление отклоняется, как и повторное создание. Песня
+// удалённой группы привязывается к группе с тем же названием, а если такой
+// нет, группа создаётся заново.
+func (r *SongRepository) RestoreSong(ctx context.Context, songId uuid.UUID) (models.Song, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to begin transaction for song restore",
+			"error", err,
+			"song_id", songId)
+		return models.Song{}, err
+	}
+	defer tx.Rollback()
+
+	// Привязка к группе заново записывается ревизией
+	err = setActor(ctx, tx)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to set revision actor",
+			"error", err,
+			"song_id", songId)
+		return models.Song{}, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO groups (id, name)
+		SELECT $2, group_name FROM songs
+		WHERE id = $1 AND deleted_at IS NOT NULL AND group_id IS NULL
+		ON CONFLICT ((lower(name))) DO NOTHING`, songId, uuid.New())
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to recreate song group",
+			"error", err,
+			"song_id", songId)
+		return models.Song{}, err
+	}
+
+	query, args, err := squirrel.Update("songs").
+		Set("deleted_at", nil).
+		Set("version", squirrel.Expr("version + 1")).
+		Set("group_id", squirrel.Expr("COALESCE(group_id, (SELECT g.id FROM groups g WHERE lower(g.name) = lower(songs.group_name)))")).
+		Where(squirrel.Eq{"id": songId}).
+		Where(squirrel.NotEq{"deleted_at": nil}).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM songs live
+			WHERE live.deleted_at IS NULL
+			  AND live.group_name = songs.group_name
+			  AND live.title = songs.title
+			  AND live.release_date IS NOT DISTINCT FROM songs.release_date
+			  AND live.text = songs.text
+			  AND live.link = songs.link)`).
+		Suffix("RETURNING " + songColumns).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for song restore",
+			"error", err,
+			"song_id", songId)
+		return models.Song{}, err
+	}
+
+	song, err := scanSong(tx.QueryRowContext(ctx, query, args...))
+	if err == nil {
+		err = tx.Commit()
+		if err != nil {
+			r.Logger.Info.ErrorContext(ctx, "Failed to commit transaction for song restore",
+				"error", err,
+				"song_id", songId)
+			return models.Song{}, err
+		}
+		return song, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		r.Logger.Info.ErrorContext(ctx, "Failed to restore song",
+			"error", err,
+			"song_id", songId)
+		return models.Song{}, err
+	}
+
+	var trashed bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM songs WHERE id = $1 AND deleted_at IS NOT NULL)", songId).Scan(&trashed)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to check trashed song",
+			"error", err,
+			"song_id", songId)
+		return models.Song{}, err
+	}
+	if trashed {
+		return models.Song{}, ErrSongExists
+	}
+	return models.Song{}, ErrSongNotInTrash
+}
+
+// PurgeTrash окончательно удаляет песни, пролежавшие в корзине дольше
+// retention. Куплеты, тайминги и переводы удаляются каскадом.
+func (r *SongRepository) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	query, args, err := squirrel.Delete("songs").
+		Where(squirrel.Expr("deleted_at <= now() - make_interval(secs => ?)", retention.Seconds())).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to build SQL query for trash purge",
+			"error", err)
+		return 0, err
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		r.Logger.Info.ErrorContext(ctx, "Failed to purge trash",
+			"error", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -6,20 +6,29 @@
 // @BasePath /api
 // @schemes http
 
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description API-ключ в виде "Bearer <ключ>"
+
 package app
 
 import (
 	"context"
-	"fmt"
 	"github.com/go-playground/validator/v10"
 	"github.com/joho/godotenv"
 	"github.com/swaggo/http-swagger"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/principal"
 	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/externalServices"
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/metrics"
 	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/postgres/migration"
 	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/postgres/repository"
 	"github.com/wiqwi12/effective-mobile-test/internal/interface/http/handlers"
 	"github.com/wiqwi12/effective-mobile-test/internal/interface/http/middleware"
 	"github.com/wiqwi12/effective-mobile-test/internal/service"
+	"github.com/wiqwi12/effective-mobile-test/internal/service/jwt"
+	"github.com/wiqwi12/effective-mobile-test/internal/service/ratelimit"
+	"github.com/wiqwi12/effective-mobile-test/internal/service/splitter"
 	"github.com/wiqwi12/effective-mobile-test/pkg"
 	"github.com/wiqwi12/effective-mobile-test/pkg/cfg"
 	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
@@ -29,12 +38,30 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 	//nolint
 	_ "github.com/wiqwi12/effective-mobile-test/docs"
 )
 
+// minAdminApiKeyLen - минимальная длина ADMIN_API_KEY: короткий ключ с
+// правом admin легко подобрать
+const minAdminApiKeyLen = 32
+
+// Лимиты запросов по умолчанию. Запись строже чтения, а запросы, которые
+// обращаются к внешнему сервису метаданных, строже всего: его квота общая
+// на всех клиентов.
+const (
+	defaultReadRateLimit     = "300/m"
+	defaultWriteRateLimit    = "60/m"
+	defaultUpstreamRateLimit = "10/m"
+	// Неудачные попытки входа с одного IP: подбор токена перебором
+	defaultAuthFailureRateLimit = "20/m"
+)
+
 func Run() {
 	if err := godotenv.Load(".env"); err != nil {
 		log.Fatal("Error loading .env file")
@@ -42,7 +69,7 @@ func Run() {
 
 	workdir, err := os.Getwd()
 	if err != nil {
-		fmt.Errorf("failed to get working directory: %w", err)
+		log.Fatalf("failed to get working directory: %s", err)
 	}
 
 	migrPath := filepath.Join(workdir, "internal/infrastructure/postgres/migration")
@@ -78,6 +105,119 @@ func Run() {
 
 	externalServiceApi := os.Getenv("EXTERNAL_SERVICE_API")
 
+	splitConfig, err := splitter.Parse(os.Getenv("VERSE_SPLIT_STRATEGY"))
+	if err != nil {
+		log.Fatalf("invalid VERSE_SPLIT_STRATEGY: %s", err)
+	}
+
+	idempotencyTTL := service.DefaultIdempotencyTTL
+	if raw := os.Getenv("IDEMPOTENCY_TTL"); raw != "" {
+		idempotencyTTL, err = time.ParseDuration(raw)
+		if err != nil || idempotencyTTL <= 0 {
+			log.Fatalf("invalid IDEMPOTENCY_TTL: %q", raw)
+		}
+	}
+
+	trashRetention := service.DefaultTrashRetention
+	if raw := os.Getenv("TRASH_RETENTION"); raw != "" {
+		trashRetention, err = time.ParseDuration(raw)
+		if err != nil || trashRetention <= 0 {
+			log.Fatalf("invalid TRASH_RETENTION: %q", raw)
+		}
+	}
+
+	importConfig := service.ImportConfig{
+		Workers:             service.DefaultImportWorkers,
+		MetadataConcurrency: service.DefaultMetadataConcurrency,
+	}
+	if raw := os.Getenv("IMPORT_WORKERS"); raw != "" {
+		importConfig.Workers, err = strconv.Atoi(raw)
+		if err != nil || importConfig.Workers <= 0 {
+			log.Fatalf("invalid IMPORT_WORKERS: %q", raw)
+		}
+	}
+	if raw := os.Getenv("METADATA_CONCURRENCY"); raw != "" {
+		importConfig.MetadataConcurrency, err = strconv.Atoi(raw)
+		if err != nil || importConfig.MetadataConcurrency <= 0 {
+			log.Fatalf("invalid METADATA_CONCURRENCY: %q", raw)
+		}
+	}
+
+	// Ключ с правом admin из конфигурации нужен, чтобы выпустить первые ключи
+	adminApiKey := os.Getenv("ADMIN_API_KEY")
+	if adminApiKey != "" && len(adminApiKey) < minAdminApiKeyLen {
+		log.Fatalf("ADMIN_API_KEY must be at least %d characters long", minAdminApiKeyLen)
+	}
+
+	// JWT проверяется, если задан хотя бы один источник ключей
+	var jwtKeys []jwt.Key
+	if secret := os.Getenv("JWT_HS256_SECRET"); secret != "" {
+		key, err := jwt.HS256Key(secret)
+		if err != nil {
+			log.Fatalf("invalid JWT_HS256_SECRET: %s", err)
+		}
+		jwtKeys = append(jwtKeys, key)
+	}
+	if path := os.Getenv("JWT_PUBLIC_KEY_FILE"); path != "" {
+		key, err := jwt.LoadRS256PublicKey(path)
+		if err != nil {
+			log.Fatalf("invalid JWT_PUBLIC_KEY_FILE: %s", err)
+		}
+		jwtKeys = append(jwtKeys, key)
+	}
+	if path := os.Getenv("JWT_JWKS_FILE"); path != "" {
+		keys, err := jwt.LoadJWKS(path)
+		if err != nil {
+			log.Fatalf("invalid JWT_JWKS_FILE: %s", err)
+		}
+		jwtKeys = append(jwtKeys, keys...)
+	}
+	var jwtVerifier *jwt.Verifier
+	if len(jwtKeys) > 0 {
+		jwtVerifier, err = jwt.NewVerifier(jwt.Config{
+			Keys:       jwtKeys,
+			Issuer:     os.Getenv("JWT_ISSUER"),
+			Audience:   os.Getenv("JWT_AUDIENCE"),
+			RolesClaim: os.Getenv("JWT_ROLES_CLAIM"),
+		})
+		if err != nil {
+			log.Fatalf("invalid JWT configuration: %s", err)
+		}
+	}
+
+	var readLimit, writeLimit, upstreamLimit, authFailureLimit ratelimit.Limit
+	for _, setting := range []struct {
+		env      string
+		fallback string
+		limit    *ratelimit.Limit
+	}{
+		{"RATE_LIMIT_READ", defaultReadRateLimit, &readLimit},
+		{"RATE_LIMIT_WRITE", defaultWriteRateLimit, &writeLimit},
+		{"RATE_LIMIT_UPSTREAM", defaultUpstreamRateLimit, &upstreamLimit},
+		{"RATE_LIMIT_AUTH_FAILURES", defaultAuthFailureRateLimit, &authFailureLimit},
+	} {
+		raw := setting.fallback
+		if value := os.Getenv(setting.env); value != "" {
+			raw = value
+		}
+		*setting.limit, err = ratelimit.ParseLimit(raw)
+		if err != nil {
+			log.Fatalf("invalid %s: %s", setting.env, err)
+		}
+	}
+
+	// Если задан METRICS_ADDR, метрики отдаются отдельным сервером на этом
+	// адресе, который не публикуется наружу; иначе - на основном порту
+	// только с ролью admin
+	metricsAddr := os.Getenv("METRICS_ADDR")
+
+	var corsOrigins []string
+	for _, origin := range strings.Split(os.Getenv("CORS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			corsOrigins = append(corsOrigins, origin)
+		}
+	}
+
 	db, err := pkg.NewDbConn(psqlCfg)
 	if err != nil {
 		log.Fatal(err)
@@ -90,33 +230,130 @@ func Run() {
 	}
 	logger.Info.Info("migrations applied succsessfully")
 
+	err = metrics.RegisterDB(db, "postgres")
+	if err != nil {
+		log.Fatalf("failed to register database metrics: %s", err)
+	}
+
 	groupRepo := repository.NewGroupRepository(db, logger)
 	songRepo := repository.NewSongRepo(db, logger)
 	MetadataRepo := externalServices.NewExternalRepo(externalServiceApi, logger)
 	verseRepo := repository.NewVerseRepository(db, logger)
-	service := service.NewSongSrvc(songRepo, groupRepo, MetadataRepo, verseRepo, logger)
+	lyricsRepo := repository.NewLyricsRepository(db, logger)
+	translationRepo := repository.NewTranslationRepository(db, logger)
+	idempotencyRepo := repository.NewIdempotencyRepository(db, logger)
+	apiKeyRepo := repository.NewApiKeyRepository(db, logger)
+	groupService := service.NewGroupSrvc(groupRepo, songRepo, logger)
+	idempotencyService := service.NewIdempotencySrvc(idempotencyRepo, idempotencyTTL, logger)
+	songService := service.NewSongSrvc(songRepo, groupRepo, MetadataRepo, verseRepo, lyricsRepo, translationRepo, splitConfig, logger)
+	trashService := service.NewTrashSrvc(songRepo, trashRetention, logger)
+	apiKeyService := service.NewApiKeySrvc(apiKeyRepo, adminApiKey, logger)
+	rateLimitStore := ratelimit.NewMemoryStore()
+	readLimiter := ratelimit.NewLimiter("read", readLimit, rateLimitStore, logger)
+	writeLimiter := ratelimit.NewLimiter("write", writeLimit, rateLimitStore, logger)
+	upstreamLimiter := ratelimit.NewLimiter("upstream", upstreamLimit, rateLimitStore, logger)
+	authFailureLimiter := ratelimit.NewLimiter("auth_failures", authFailureLimit, rateLimitStore, logger)
+	importService := service.NewImportSrvc(songService, importConfig, upstreamLimiter, logger)
 	validator := validator.New()
+	// В ошибках валидации поля называются так же, как в JSON
+	validator.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
 
-	handler := handlers.NewHandler(service, validator)
+	handler := handlers.NewHandler(songService, groupService, idempotencyService, importService, trashService, apiKeyService, jwtVerifier, authFailureLimiter, validator)
+
+	// Роль, нужная для каждого маршрута: viewer читает, editor создаёт и
+	// изменяет, удаление песен, куплетов, переводов и групп, слияние групп и
+	// /api/admin/ - только admin.
+	// limiter - группа лимита запросов: чтение, запись или обращение к сервису
+	// метаданных
+	type route struct {
+		pattern string
+		role    principal.Role
+		limiter *ratelimit.Limiter
+		handler http.HandlerFunc
+	}
+	routes := []route{
+		{"POST /api/song", principal.RoleEditor, upstreamLimiter, handler.Idempotent(handler.CreateSongHandler)},
+		{"GET /api/song/{id}", principal.RoleViewer, readLimiter, handler.GetSongHandler},
+		{"PUT /api/song/{id}", principal.RoleEditor, writeLimiter, handler.UpdateSongHandler},
+		{"PATCH /api/song/{id}", principal.RoleEditor, writeLimiter, handler.PatchSongHandler},
+		{"DELETE /api/song/{id}", principal.RoleAdmin, writeLimiter, handler.DeleteSongHandler},
+		{"POST /api/song/{id}/restore", principal.RoleEditor, writeLimiter, handler.RestoreSongHandler},
+		{"GET /api/trash", principal.RoleViewer, readLimiter, handler.ListTrashHandler},
+		{"GET /api/song/{id}/revisions", principal.RoleViewer, readLimiter, handler.ListRevisionsHandler},
+		{"GET /api/song/{id}/revisions/diff", principal.RoleViewer, readLimiter, handler.DiffRevisionsHandler},
+		{"POST /api/song/{id}/revisions/{rev}/revert", principal.RoleEditor, writeLimiter, handler.RevertSongHandler},
+		{"GET /api/song", principal.RoleViewer, readLimiter, handler.GetSongWithFilter},
+		// Лимит сервиса метаданных импорт расходует построчно, см. ImportSrvc
+		{"POST /api/songs/import", principal.RoleEditor, writeLimiter, handler.ImportSongsHandler},
+		{"GET /api/export", principal.RoleViewer, readLimiter, handler.ExportHandler},
+		{"GET /api/verses/{id}", principal.RoleViewer, readLimiter, handler.GetPaginatedVerses},
+		{"POST /api/verses/preview", principal.RoleViewer, readLimiter, handler.PreviewVersesHandler},
+		{"POST /api/song/{id}/verses", principal.RoleEditor, writeLimiter, handler.InsertVerseHandler},
+		{"GET /api/song/{id}/verses/{n}", principal.RoleViewer, readLimiter, handler.GetVerseHandler},
+		{"PUT /api/song/{id}/verses/{n}", principal.RoleEditor, writeLimiter, handler.UpdateVerseHandler},
+		{"DELETE /api/song/{id}/verses/{n}", principal.RoleAdmin, writeLimiter, handler.DeleteVerseHandler},
+		{"POST /api/song/{id}/verses/{n}/move", principal.RoleEditor, writeLimiter, handler.MoveVerseHandler},
+		{"POST /api/song/{id}/verses/rebuild", principal.RoleEditor, writeLimiter, handler.RebuildVersesHandler},
+		{"POST /api/admin/verses/rebuild", principal.RoleAdmin, writeLimiter, handler.RebuildAllVersesHandler},
+		{"GET /api/admin/keys", principal.RoleAdmin, readLimiter, handler.ListApiKeysHandler},
+		{"POST /api/admin/keys", principal.RoleAdmin, writeLimiter, handler.IssueApiKeyHandler},
+		{"POST /api/admin/keys/{id}/rotate", principal.RoleAdmin, writeLimiter, handler.RotateApiKeyHandler},
+		{"DELETE /api/admin/keys/{id}", principal.RoleAdmin, writeLimiter, handler.RevokeApiKeyHandler},
+		{"GET /api/song/{id}/lyrics", principal.RoleViewer, readLimiter, handler.GetTimedLyricsHandler},
+		{"GET /api/song/{id}/lyrics/at", principal.RoleViewer, readLimiter, handler.LyricsAtHandler},
+		{"GET /api/song/{id}/lyrics/lrc", principal.RoleViewer, readLimiter, handler.ExportLrcHandler},
+		{"PUT /api/song/{id}/lyrics/lrc", principal.RoleEditor, writeLimiter, handler.ImportLrcHandler},
+		{"GET /api/song/{id}/translations", principal.RoleViewer, readLimiter, handler.ListTranslationsHandler},
+		{"GET /api/song/{id}/translations/{lang}", principal.RoleViewer, readLimiter, handler.GetTranslationHandler},
+		{"PUT /api/song/{id}/translations/{lang}", principal.RoleEditor, writeLimiter, handler.SaveTranslationHandler},
+		{"DELETE /api/song/{id}/translations/{lang}", principal.RoleAdmin, writeLimiter, handler.DeleteTranslationHandler},
+		{"GET /api/search/lyrics", principal.RoleViewer, readLimiter, handler.SearchLyricsHandler},
+		{"GET /api/groups", principal.RoleViewer, readLimiter, handler.ListGroupsHandler},
+		{"POST /api/groups", principal.RoleEditor, writeLimiter, handler.CreateGroupHandler},
+		{"GET /api/groups/{id}", principal.RoleViewer, readLimiter, handler.GetGroupHandler},
+		{"PUT /api/groups/{id}", principal.RoleEditor, writeLimiter, handler.RenameGroupHandler},
+		{"DELETE /api/groups/{id}", principal.RoleAdmin, writeLimiter, handler.DeleteGroupHandler},
+		{"POST /api/groups/{id}/merge", principal.RoleAdmin, writeLimiter, handler.MergeGroupsHandler},
+	}
+	if metricsAddr == "" {
+		routes = append(routes, route{"GET /metrics", principal.RoleAdmin, readLimiter, metrics.Handler().ServeHTTP})
+	}
+
+	// Файл импорта читается потоком, ему разрешено тело больше остальных
+	bodyLimits := map[string]int64{"POST /api/songs/import": handlers.MaxImportBodyBytes}
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("POST /api/song", handler.CreateSongHandler)
-	mux.HandleFunc("GET /api/song/{id}", handler.GetSongHandler)
-	mux.HandleFunc("PUT /api/song/{id}", handler.UpdateSongHandler)
-	mux.HandleFunc("DELETE /api/song/{id}", handler.DeleteSongHandler)
-	mux.HandleFunc("GET /api/song", handler.GetSongWithFilter)
-	mux.HandleFunc("GET /api/verses/{id}", handler.GetPaginatedVerses)
-	mux.Handle("/swagger/", httpSwagger.Handler(
+	for _, route := range routes {
+		bodyLimit, ok := bodyLimits[route.pattern]
+		if !ok {
+			bodyLimit = handlers.MaxRequestBodyBytes
+		}
+		mux.HandleFunc(route.pattern, middleware.WithRoute(route.pattern, handler.RateLimit(route.limiter, handler.Require(route.role, handler.LimitBody(bodyLimit, route.handler)))))
+	}
+	mux.Handle("/swagger/", middleware.WithRoute("/swagger/", httpSwagger.Handler(
 		httpSwagger.URL("/swagger/doc.json"),
-	))
+	)))
 
-	headersMWMux := middleware.CommonHeadersMiddleware(mux)
+	headersMWMux := middleware.CommonHeadersMiddleware(handler.Authenticate(mux), corsOrigins)
+	metricsMux := middleware.MetricsMiddleware(headersMWMux)
+	accessLogMux := middleware.AccessLogMiddleware(metricsMux, logger)
 
 	server := &http.Server{
 		Addr:    httpConfig.Host + ":" + httpConfig.Port,
-		Handler: headersMWMux,
+		Handler: accessLogMux,
 	}
 
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	defer stopCleanup()
+	go idempotencyService.RunCleanup(cleanupCtx, min(idempotencyTTL, time.Hour))
+	go trashService.RunPurge(cleanupCtx, min(trashRetention, time.Hour))
+
 	go func() {
 		logger.Debug.Info("Starting Server")
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -124,6 +361,23 @@ func Run() {
 		}
 	}()
 
+	var metricsServer *http.Server
+	if metricsAddr != "" {
+		metricsRoutes := http.NewServeMux()
+		metricsRoutes.Handle("GET /metrics", metrics.Handler())
+		metricsServer = &http.Server{
+			Addr:    metricsAddr,
+			Handler: metricsRoutes,
+		}
+
+		go func() {
+			logger.Debug.Info("Starting metrics server", "addr", metricsAddr)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Metrics server error: %s", err)
+			}
+		}()
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
@@ -136,6 +390,11 @@ func Run() {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatalf("Server shutdown error: %s", err)
 	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			log.Fatalf("Metrics server shutdown error: %s", err)
+		}
+	}
 
 	logger.Debug.Info("Server gracefully stopped")
 }
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"net/http"
+)
+
+// @Summary Выпустить API-ключ
+// @Description Создаёт ключ с правами songs:read (роль viewer), songs:write (editor) или admin. Секрет возвращается в поле token только в этом ответе
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.IssueApiKeyRequest true "Имя и права ключа"
+// @Success 201 {object} dto.ApiKeyResponse "Ключ выпущен"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 401 {object} dto.Problem "Нет действующего токена"
+// @Failure 403 {object} dto.Problem "Нужна роль admin"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/admin/keys [post]
+func (h *Handler) IssueApiKeyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	req := dto.IssueApiKeyRequest{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeMalformed(w, r, err.Error())
+		return
+	}
+
+	err = h.validator.Struct(req)
+	if err != nil {
+		writeValidation(w, r, err)
+		return
+	}
+
+	resp, err := h.apiKeySrvc.IssueKey(r.Context(), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Список API-ключей
+// @Description Возвращает все ключи, включая отозванные, без секретов: prefix - начало ключа, last_used_at - последнее использование с точностью до минуты
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.ApiKeysResponse "Ключи"
+// @Failure 401 {object} dto.Problem "Нет действующего токена"
+// @Failure 403 {object} dto.Problem "Нужна роль admin"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/admin/keys [get]
+func (h *Handler) ListApiKeysHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	resp, err := h.apiKeySrvc.ListKeys(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Ротация API-ключа
+// @Description Выпускает новый секрет для ключа с теми же именем и правами. Старый секрет перестаёт работать сразу
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID ключа" format(uuid)
+// @Success 200 {object} dto.ApiKeyResponse "Новый секрет ключа"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 401 {object} dto.Problem "Нет действующего токена"
+// @Failure 403 {object} dto.Problem "Нужна роль admin"
+// @Failure 404 {object} dto.Problem "Ключ не найден или отозван"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/admin/keys/{id}/rotate [post]
+func (h *Handler) RotateApiKeyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeMalformed(w, r, "invalid api key id")
+		return
+	}
+
+	resp, err := h.apiKeySrvc.RotateKey(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Отозвать API-ключ
+// @Description Ключ перестаёт работать и остаётся в списке с revoked_at
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID ключа" format(uuid)
+// @Success 200 {object} dto.ApiKeyResponse "Ключ отозван"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 401 {object} dto.Problem "Нет действующего токена"
+// @Failure 403 {object} dto.Problem "Нужна роль admin"
+// @Failure 404 {object} dto.Problem "Ключ не найден или уже отозван"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/admin/keys/{id} [delete]
+func (h *Handler) RevokeApiKeyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeMalformed(w, r, "invalid api key id")
+		return
+	}
+
+	resp, err := h.apiKeySrvc.RevokeKey(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
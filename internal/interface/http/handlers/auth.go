@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/actor"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/principal"
+	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	errAuthenticationRequired = apperr.New(apperr.Unauthorized, "unauthorized", "authentication is required")
+	errForbidden              = apperr.New(apperr.Forbidden, "forbidden", "insufficient role")
+)
+
+// scopeRoles - какой роли соответствует право API-ключа.
+var scopeRoles = map[string]principal.Role{
+	models.ScopeSongsRead:  principal.RoleViewer,
+	models.ScopeSongsWrite: principal.RoleEditor,
+	models.ScopeAdmin:      principal.RoleAdmin,
+}
+
+// bearerToken достаёт токен из заголовка Authorization: Bearer.
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// Authenticate определяет, кто выполняет запрос, по JWT или API-ключу из
+// Authorization: Bearer, и кладёт субъект в контекст запроса. Запрос без
+// токена проходит анонимным, права проверяет Require. Неверный токен
+// отклоняется сразу, чтобы клиент не принял 403 за нехватку прав.
+//
+// Лимиты маршрутов применяются уже после проверки токена, поэтому неудачные
+// попытки входа считаются отдельно по IP клиента: исчерпавший лимит IP
+// получает 429, пока лимит не восстановится, и его токены не проверяются.
+func (h *Handler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		client := rateLimitClient(r)
+		if result := h.authFailures.Check(r.Context(), client, time.Now()); !result.Allowed {
+			writeRateLimited(w, r, result)
+			return
+		}
+
+		p, err := h.principalFor(r, token)
+		if err != nil {
+			if apperr.KindOf(err) == apperr.Unauthorized {
+				h.authFailures.Allow(r.Context(), client, time.Now())
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			}
+			writeError(w, r, err)
+			return
+		}
+
+		ctx := principal.WithPrincipal(r.Context(), p)
+		ctx = actor.WithName(ctx, p.Actor())
+		if info, ok := logger.RequestFromContext(ctx); ok {
+			info.User = p.Actor()
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// principalFor проверяет токен. Токен из трёх частей через точку считается
+// JWT, если проверка JWT настроена, остальные - API-ключами.
+func (h *Handler) principalFor(r *http.Request, token string) (principal.Principal, error) {
+	if h.jwtVerifier != nil && strings.Count(token, ".") == 2 {
+		claims, err := h.jwtVerifier.Verify(token, time.Now())
+		if err != nil {
+			return principal.Principal{}, err
+		}
+		p := principal.Principal{Kind: principal.KindUser, Name: claims.Subject}
+		for _, name := range claims.Roles {
+			p.Role = max(p.Role, principal.ParseRole(name))
+		}
+		return p, nil
+	}
+
+	key, err := h.apiKeySrvc.Authenticate(r.Context(), token)
+	if err != nil {
+		return principal.Principal{}, err
+	}
+	p := principal.Principal{Kind: principal.KindApiKey, Name: key.Name}
+	for _, scope := range key.Scopes {
+		p.Role = max(p.Role, scopeRoles[scope])
+	}
+	return p, nil
+}
+
+// Require пропускает к обработчику только субъектов с ролью не ниже role:
+// анонимный запрос получает 401, недостаточная роль - 403.
+func (h *Handler) Require(role principal.Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := principal.FromContext(r.Context())
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			writeError(w, r, errAuthenticationRequired)
+			return
+		}
+		if !p.Allows(role) {
+			writeError(w, r, fmt.Errorf("%w: %s role is required", errForbidden, role))
+			return
+		}
+		next(w, r)
+	}
+}
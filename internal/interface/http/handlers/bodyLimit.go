@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"net/http"
+)
+
+// Пределы размера тела запроса. Песни, тексты и LRC укладываются в
+// MaxRequestBodyBytes с запасом; файл импорта читается потоком построчно
+// и может быть больше.
+const (
+	MaxRequestBodyBytes = 1 << 20
+	MaxImportBodyBytes  = 64 << 20
+)
+
+var errRequestTooLarge = apperr.New(apperr.TooLarge, "request_too_large", "request body is too large")
+
+// LimitBody ограничивает тело запроса limit байтами, чтобы один запрос не
+// мог занять всю память процесса. Запрос с большим Content-Length
+// отклоняется сразу с 413; если длина заранее не известна, чтение тела
+// сверх предела завершается ошибкой.
+func (h *Handler) LimitBody(limit int64, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			writeError(w, r, fmt.Errorf("%w: limit is %d bytes", errRequestTooLarge, limit))
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next(w, r)
+	}
+}
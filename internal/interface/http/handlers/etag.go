@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errIfMatchRequired = apperr.New(apperr.PreconditionRequired, "if_match_required", "If-Match header with the song ETag is required")
+
+// songETag строит ETag песни из её версии. Перевод - другое представление
+// той же версии, поэтому язык добавляется к тегу.
+func songETag(version int, lang string) string {
+	if lang == "" {
+		return `"` + strconv.Itoa(version) + `"`
+	}
+	return `"` + strconv.Itoa(version) + "-" + lang + `"`
+}
+
+// parseIfMatch разбирает If-Match. Слабые теги для If-Match не подходят
+// и пропускаются. ok = false, если заголовка нет.
+func parseIfMatch(header string) (dto.Precondition, bool) {
+	var precondition dto.Precondition
+	if strings.TrimSpace(header) == "" {
+		return precondition, false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			precondition.Any = true
+			continue
+		}
+		if !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) || len(tag) < 2 {
+			continue
+		}
+		raw, _, _ := strings.Cut(tag[1:len(tag)-1], "-")
+		version, err := strconv.Atoi(raw)
+		if err != nil {
+			continue
+		}
+		precondition.Versions = append(precondition.Versions, version)
+	}
+	return precondition, true
+}
+
+// ifNoneMatch сообщает, есть ли etag среди тегов If-None-Match. Сравнение
+// слабое: префикс W/ не учитывается.
+func ifNoneMatch(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeSongModified отвечает 412 с текущим представлением песни, чтобы
+// клиент мог повторить правку поверх него без лишнего GET.
+func writeSongModified(w http.ResponseWriter, resp dto.StandartResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", songETag(resp.Song.Version, ""))
+	w.WriteHeader(http.StatusPreconditionFailed)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// exportStream откладывает заголовки ответа до первой записи: пока ничего
+// не отправлено, ошибку экспорта ещё можно вернуть обычным ответом.
+type exportStream struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (s *exportStream) begin() {
+	if s.started {
+		return
+	}
+	s.started = true
+	s.w.Header().Set("Content-Type", s.contentType)
+	s.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", s.filename))
+	s.w.WriteHeader(http.StatusOK)
+}
+
+type exportWriter interface {
+	Group(group models.Group) error
+	Song(song models.Song, verses []models.Verse) error
+	// Close дописывает конец документа, даже если записей не было
+	Close() error
+	Started() bool
+}
+
+func (s *exportStream) Started() bool {
+	return s.started
+}
+
+type ndjsonExporter struct {
+	exportStream
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExporter) Group(group models.Group) error {
+	e.begin()
+	return e.encoder.Encode(dto.ExportRecord{Type: "group", Group: &group})
+}
+
+func (e *ndjsonExporter) Song(song models.Song, verses []models.Verse) error {
+	e.begin()
+	return e.encoder.Encode(dto.ExportRecord{Type: "song", Song: &dto.ExportedSong{Song: song, Verses: verses}})
+}
+
+func (e *ndjsonExporter) Close() error {
+	e.begin()
+	return nil
+}
+
+// jsonExporter пишет один документ {"groups": [...], "songs": [...]},
+// дописывая элементы массивов по мере поступления.
+type jsonExporter struct {
+	exportStream
+	section string
+	count   int
+}
+
+func (e *jsonExporter) open(section string) error {
+	e.begin()
+	if e.section == section {
+		return nil
+	}
+
+	// Пустой массив групп всё равно выводится, чтобы у документа была
+	// одна и та же форма
+	if e.section == "" && section != "groups" {
+		err := e.open("groups")
+		if err != nil {
+			return err
+		}
+	}
+
+	prefix := "],"
+	if e.section == "" {
+		prefix = "{"
+	}
+	e.section = section
+	e.count = 0
+	_, err := fmt.Fprintf(e.w, "%s%q:[", prefix, section)
+	return err
+}
+
+func (e *jsonExporter) item(value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if e.count > 0 {
+		_, err = e.w.Write([]byte{','})
+		if err != nil {
+			return err
+		}
+	}
+	e.count++
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonExporter) Group(group models.Group) error {
+	err := e.open("groups")
+	if err != nil {
+		return err
+	}
+	return e.item(group)
+}
+
+func (e *jsonExporter) Song(song models.Song, verses []models.Verse) error {
+	err := e.open("songs")
+	if err != nil {
+		return err
+	}
+	return e.item(dto.ExportedSong{Song: song, Verses: verses})
+}
+
+func (e *jsonExporter) Close() error {
+	err := e.open("songs")
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write([]byte("]}\n"))
+	return err
+}
+
+// csvExporter пишет по строке на песню, а с куплетами - по строке на
+// куплет с повторением полей песни. Группы отдельно не выгружаются:
+// group_id и group есть в каждой строке.
+type csvExporter struct {
+	exportStream
+	writer     *csv.Writer
+	withVerses bool
+}
+
+var exportCSVHeader = []string{"song_id", "group_id", "group", "title", "release_date", "link", "split_strategy", "version", "created_at", "updated_at", "text"}
+
+func (e *csvExporter) begin() error {
+	if e.started {
+		return nil
+	}
+	e.exportStream.begin()
+	header := exportCSVHeader
+	if e.withVerses {
+		header = append(header[:len(header):len(header)], "verse_number", "section_type", "verse_text")
+	}
+	return e.writer.Write(header)
+}
+
+func (e *csvExporter) Group(group models.Group) error {
+	return nil
+}
+
+func (e *csvExporter) Song(song models.Song, verses []models.Verse) error {
+	err := e.begin()
+	if err != nil {
+		return err
+	}
+	record := []string{
+		song.Id.String(),
+		song.GroupId.String(),
+		song.GroupName,
+		song.Title,
+		song.ReleaseDate,
+		song.Link,
+		song.SplitStrategy,
+		strconv.Itoa(song.Version),
+		song.CreatedAt.UTC().Format(time.RFC3339),
+		song.UpdatedAt.UTC().Format(time.RFC3339),
+		song.Text,
+	}
+	if !e.withVerses {
+		return e.writer.Write(record)
+	}
+
+	if len(verses) == 0 {
+		return e.writer.Write(append(record, "", "", ""))
+	}
+	for _, verse := range verses {
+		err = e.writer.Write(append(record[:len(record):len(record)], strconv.Itoa(verse.VerseNumber), verse.SectionType, verse.Text))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *csvExporter) Close() error {
+	err := e.begin()
+	if err != nil {
+		return err
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func newExportWriter(w http.ResponseWriter, request dto.ExportRequest) exportWriter {
+	switch request.Format {
+	case dto.ExportCSV:
+		return &csvExporter{
+			exportStream: exportStream{w: w, contentType: "text/csv; charset=utf-8", filename: "library.csv"},
+			writer:       csv.NewWriter(w),
+			withVerses:   request.WithVerses,
+		}
+	case dto.ExportJSON:
+		return &jsonExporter{
+			exportStream: exportStream{w: w, contentType: "application/json", filename: "library.json"},
+		}
+	default:
+		return &ndjsonExporter{
+			exportStream: exportStream{w: w, contentType: ndjsonMediaType, filename: "library.ndjson"},
+			encoder:      json.NewEncoder(w),
+		}
+	}
+}
+
+// parseExportRequest разбирает формат и флаги экспорта, а остальные
+// параметры - как фильтры поиска песен.
+func parseExportRequest(query url.Values) (dto.ExportRequest, []dto.FieldError) {
+	request := dto.ExportRequest{Format: dto.ExportNDJSON}
+	var fieldErrors []dto.FieldError
+
+	filters := url.Values{}
+	for name, values := range query {
+		if name != "format" && name != "include_verses" {
+			filters[name] = values
+		}
+	}
+	for _, name := range []string{"cursor", "limit"} {
+		if filters.Has(name) {
+			fieldErrors = append(fieldErrors, dto.FieldError{Field: name, Error: "not supported for export"})
+			filters.Del(name)
+		}
+	}
+
+	switch format := query.Get("format"); format {
+	case "":
+	case dto.ExportNDJSON, dto.ExportCSV, dto.ExportJSON:
+		request.Format = format
+	default:
+		fieldErrors = append(fieldErrors, dto.FieldError{
+			Field: "format",
+			Error: fmt.Sprintf("must be one of %s, %s, %s", dto.ExportNDJSON, dto.ExportCSV, dto.ExportJSON),
+		})
+	}
+
+	if raw := query.Get("include_verses"); raw != "" {
+		withVerses, err := strconv.ParseBool(raw)
+		if err != nil {
+			fieldErrors = append(fieldErrors, dto.FieldError{Field: "include_verses", Error: "must be a boolean"})
+		}
+		request.WithVerses = withVerses
+	}
+	request.WithGroups = request.Format != dto.ExportCSV
+
+	filter, filterErrors := parseSongFilter(filters)
+	request.Filter = filter
+	fieldErrors = append(fieldErrors, filterErrors...)
+
+	return request, fieldErrors
+}
+
+// @Summary Экспорт библиотеки
+// @Description Выгружает группы, песни и, по запросу, куплеты потоком, без загрузки всей библиотеки в память. Принимает те же фильтры, что и поиск песен (кроме limit и cursor). ndjson - строки {"type": "group"|"song", ...}; json - документ {"groups": [...], "songs": [...]}; csv - строка на песню или, с include_verses, на куплет. Если ошибка случилась после начала выгрузки, соединение обрывается
+// @Tags export
+// @Security BearerAuth
+// @Produce application/x-ndjson
+// @Produce json
+// @Produce text/csv
+// @Param format query string false "Формат выгрузки (по умолчанию ndjson)" Enums(ndjson, csv, json)
+// @Param include_verses query bool false "Выгрузить куплеты песен"
+// @Param title query string false "Название песни"
+// @Param group query []string false "Исполнитель, можно указать несколько раз" collectionFormat(multi)
+// @Param match query string false "Режим сравнения title и group" Enums(contains, prefix, exact)
+// @Param released_from query string false "Дата релиза от (YYYY-MM-DD)"
+// @Param released_to query string false "Дата релиза до (YYYY-MM-DD)"
+// @Param q query string false "Подстрока в названии или исполнителе"
+// @Param text query string false "Подстрока в тексте песни"
+// @Param link query string false "Ссылка на песню"
+// @Param sort query string false "Сортировка песен, например group_name,-release_date"
+// @Success 200 {object} dto.ExportRecord "Поток записей экспорта"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/export [get]
+func (h *Handler) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	request, fieldErrors := parseExportRequest(r.URL.Query())
+	if len(fieldErrors) > 0 {
+		writeFieldErrors(w, r, fieldErrors...)
+		return
+	}
+
+	exporter := newExportWriter(w, request)
+	err := h.srvc.ExportLibrary(r.Context(), request, exporter)
+	if err == nil {
+		err = exporter.Close()
+	}
+	if err != nil {
+		if !exporter.Started() {
+			writeError(w, r, err)
+			return
+		}
+		// Заголовки уже отправлены: обрываем ответ, чтобы клиент не принял
+		// неполную выгрузку за целую
+		panic(http.ErrAbortHandler)
+	}
+}
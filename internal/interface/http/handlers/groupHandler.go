@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"github.com/wiqwi12/effective-mobile-test/internal/service"
+	"net/http"
+	"strconv"
+)
+
+// @Summary Создать группу
+// @Description Создает нового исполнителя
+// @Tags groups
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateGroupRequest true "Название группы"
+// @Success 201 {object} dto.GroupResponse "Группа создана"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 409 {object} dto.Problem "Группа уже существует"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/groups [post]
+func (h *Handler) CreateGroupHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req dto.CreateGroupRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeMalformed(w, r, err.Error())
+		return
+	}
+
+	err = h.validator.Struct(req)
+	if err != nil {
+		writeValidation(w, r, err)
+		return
+	}
+
+	resp, err := h.groupSrvc.CreateGroup(r.Context(), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Получить список групп
+// @Description Возвращает исполнителей по алфавиту с количеством песен
+// @Tags groups
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Номер страницы (по умолчанию 1)"
+// @Param limit query int false "Групп на странице (по умолчанию 20, максимум 100)"
+// @Success 200 {object} dto.GroupsResponse "Список групп"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/groups [get]
+func (h *Handler) ListGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	req := dto.GroupsListRequest{
+		Page:  1,
+		Limit: service.DefaultGroupsLimit,
+	}
+	query := r.URL.Query()
+	if raw := query.Get("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil {
+			writeFieldErrors(w, r, dto.FieldError{Field: "page", Error: "must be an integer"})
+			return
+		}
+		req.Page = page
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			writeFieldErrors(w, r, dto.FieldError{Field: "limit", Error: "must be an integer"})
+			return
+		}
+		req.Limit = limit
+	}
+
+	err := h.validator.Struct(req)
+	if err != nil {
+		writeValidation(w, r, err)
+		return
+	}
+
+	resp, err := h.groupSrvc.ListGroups(r.Context(), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Получить группу
+// @Description Возвращает группу, количество её песен и дискографию по дате релиза
+// @Tags groups
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID группы" format(uuid)
+// @Success 200 {object} dto.GroupDetailResponse "Данные группы"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Группа не найдена"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/groups/{id} [get]
+func (h *Handler) GetGroupHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	groupId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeMalformed(w, r, "invalid group id")
+		return
+	}
+
+	resp, err := h.groupSrvc.GetGroup(r.Context(), groupId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Переименовать группу
+// @Description Меняет название группы и обновляет его у всех её песен
+// @Tags groups
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "ID группы" format(uuid)
+// @Param request body dto.RenameGroupRequest true "Новое название"
+// @Success 200 {object} dto.GroupResponse "Группа переименована"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Группа не найдена"
+// @Failure 409 {object} dto.Problem "Название уже занято"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/groups/{id} [put]
+func (h *Handler) RenameGroupHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	groupId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeMalformed(w, r, "invalid group id")
+		return
+	}
+
+	var req dto.RenameGroupRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeMalformed(w, r, err.Error())
+		return
+	}
+
+	err = h.validator.Struct(req)
+	if err != nil {
+		writeValidation(w, r, err)
+		return
+	}
+
+	resp, err := h.groupSrvc.RenameGroup(r.Context(), groupId, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Удалить группу
+// @Description Удаляет группу. Если у группы есть песни, в том числе в корзине, удаление выполняется только с cascade=true: живые песни уходят в корзину, а при восстановлении группа создаётся заново по названию
+// @Tags groups
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID группы" format(uuid)
+// @Param cascade query bool false "Перенести песни группы в корзину"
+// @Success 200 {object} dto.GroupResponse "Группа удалена"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Группа не найдена"
+// @Failure 409 {object} dto.Problem "У группы есть песни или песни в корзине"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/groups/{id} [delete]
+func (h *Handler) DeleteGroupHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	groupId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeMalformed(w, r, "invalid group id")
+		return
+	}
+
+	var cascade bool
+	if raw := r.URL.Query().Get("cascade"); raw != "" {
+		cascade, err = strconv.ParseBool(raw)
+		if err != nil {
+			writeFieldErrors(w, r, dto.FieldError{Field: "cascade", Error: "must be a boolean"})
+			return
+		}
+	}
+
+	resp, err := h.groupSrvc.DeleteGroup(r.Context(), groupId, cascade)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Объединить группы
+// @Description Переносит все песни исходных групп в целевую в одной транзакции и удаляет исходные группы. Их названия сохраняются как алиасы целевой группы, поэтому новые песни с этими названиями попадут в неё
+// @Tags groups
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "ID целевой группы" format(uuid)
+// @Param request body dto.MergeGroupsRequest true "ID объединяемых групп"
+// @Success 200 {object} dto.MergeGroupsResponse "Группы объединены"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Группа не найдена"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/groups/{id}/merge [post]
+func (h *Handler) MergeGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	groupId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeMalformed(w, r, "invalid group id")
+		return
+	}
+
+	var req dto.MergeGroupsRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeMalformed(w, r, err.Error())
+		return
+	}
+
+	err = h.validator.Struct(req)
+	if err != nil {
+		writeValidation(w, r, err)
+		return
+	}
+
+	resp, err := h.groupSrvc.MergeGroups(r.Context(), groupId, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
@@ -1,104 +1,129 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/postgres/repository"
 	"github.com/wiqwi12/effective-mobile-test/internal/service"
+	"github.com/wiqwi12/effective-mobile-test/internal/service/jwt"
+	"github.com/wiqwi12/effective-mobile-test/internal/service/ratelimit"
+	"io"
 	"net/http"
+	"strconv"
 )
 
 type Handler struct {
-	srvc      *service.SongSrvc
-	validator *validator.Validate
+	srvc            *service.SongSrvc
+	groupSrvc       *service.GroupSrvc
+	idempotencySrvc *service.IdempotencySrvc
+	importSrvc      *service.ImportSrvc
+	trashSrvc       *service.TrashSrvc
+	apiKeySrvc      *service.ApiKeySrvc
+	jwtVerifier     *jwt.Verifier // nil - вход по JWT выключен
+	// authFailures ограничивает неудачные попытки входа с одного IP
+	authFailures *ratelimit.Limiter
+	validator    *validator.Validate
 }
 
-func NewHandler(srvc *service.SongSrvc, validator *validator.Validate) *Handler {
-	return &Handler{srvc: srvc, validator: validator}
+func NewHandler(srvc *service.SongSrvc, groupSrvc *service.GroupSrvc, idempotencySrvc *service.IdempotencySrvc, importSrvc *service.ImportSrvc, trashSrvc *service.TrashSrvc, apiKeySrvc *service.ApiKeySrvc, jwtVerifier *jwt.Verifier, authFailures *ratelimit.Limiter, validator *validator.Validate) *Handler {
+	return &Handler{srvc: srvc, groupSrvc: groupSrvc, idempotencySrvc: idempotencySrvc, importSrvc: importSrvc, trashSrvc: trashSrvc, apiKeySrvc: apiKeySrvc, jwtVerifier: jwtVerifier, authFailures: authFailures, validator: validator}
 }
 
 // @Summary Создать новую песню
-// @Description Создает новую песню в базе данных
+// @Description Создает новую песню в базе данных. С заголовком Idempotency-Key повтор запроса с тем же ключом и телом возвращает сохранённый ответ (с заголовком Idempotent-Replayed), а тот же ключ с другим телом отклоняется
 // @Tags songs
+// @Security BearerAuth
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Ключ идемпотентности, до 255 символов"
 // @Param request body dto.CreateSongRequest true "Данные для создания песни"
-// @Success 200 {object} dto.StandartResponse "Песня успешно создана"
-// @Failure 400 {object} dto.StandartResponse "Ошибка в запросе"
+// @Success 201 {object} dto.StandartResponse "Песня успешно создана"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня не найдена в сервисе метаданных"
+// @Failure 409 {object} dto.Problem "Песня уже существует или запрос с этим ключом ещё выполняется"
+// @Failure 422 {object} dto.Problem "Ошибка валидации или ключ использован с другим телом"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Failure 502 {object} dto.Problem "Сервис метаданных недоступен"
 // @Router /api/song [post]
 func (h *Handler) CreateSongHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
-	var resp dto.StandartResponse
 
 	var req dto.CreateSongRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		resp.Message = "some error occured"
-		resp.Error = err.Error()
-		json.NewEncoder(w).Encode(resp)
+		writeMalformed(w, r, err.Error())
 		return
 	}
 
 	err = h.validator.Struct(req)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		resp.Message = "something wrong with request"
-		resp.Error = err.Error()
-		json.NewEncoder(w).Encode(resp)
+		writeValidation(w, r, err)
 		return
 	}
 
-	resp, err = h.srvc.CreateSong(context.Background(), req)
+	resp, err := h.srvc.CreateSong(r.Context(), req)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(resp)
+		writeError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", songETag(resp.Song.Version, ""))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
 }
 
 // @Summary Получить песню по ID
-// @Description Возвращает данные песни по её ID
+// @Description Возвращает данные песни по её ID. Если есть перевод на язык из lang или Accept-Language, название и текст отдаются в переводе, иначе в оригинале. В заголовке ETag - версия песни, её нужно передать в If-Match при изменении и удалении
 // @Tags songs
+// @Security BearerAuth
 // @Produce json
 // @Param id path string true "ID песни" format(uuid)
+// @Param lang query string false "Язык перевода, иначе берётся из Accept-Language"
+// @Param Accept-Language header string false "Предпочитаемый язык перевода"
+// @Param If-None-Match header string false "ETag сохранённой копии"
 // @Success 200 {object} dto.StandartResponse "Данные песни"
-// @Failure 400 {object} dto.StandartResponse "Ошибка в запросе"
+// @Success 304 "Песня не изменилась"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня не найдена"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
 // @Router /api/song/{id} [get]
 func (h *Handler) GetSongHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	var resp dto.StandartResponse
 
 	songId, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		resp.Message = "invalid song id"
-		resp.Error = err.Error()
-		json.NewEncoder(w).Encode(resp)
+		writeMalformed(w, r, "invalid song id")
 		return
 	}
 
 	req := dto.GetSongByIdRequest{
-		Id: songId,
+		Id:    songId,
+		Langs: preferredLangs(r),
 	}
 	err = h.validator.Struct(req)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		resp.Message = "invalid song id"
-		resp.Error = err.Error()
-		json.NewEncoder(w).Encode(resp)
+		writeValidation(w, r, err)
 		return
 	}
 
-	resp, err = h.srvc.GetSongById(context.Background(), req)
+	resp, err := h.srvc.GetSongById(r.Context(), req)
+	setLangHeaders(w, resp.Lang)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		resp.Message = "some error occured"
-		resp.Error = err.Error()
+		writeError(w, r, err)
+		return
+	}
+
+	etag := songETag(resp.Song.Version, resp.Lang)
+	w.Header().Set("ETag", etag)
+	if ifNoneMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 	json.NewEncoder(w).Encode(resp)
@@ -106,81 +131,183 @@ func (h *Handler) GetSongHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Обновить песню
-// @Description Обновляет данные существующей песни
+// @Description Обновляет данные существующей песни. Требует If-Match с ETag из GET; если песню успели изменить, возвращается 412 с её текущей версией
 // @Tags songs
+// @Security BearerAuth
 // @Accept json
 // @Produce json
 // @Param id path string true "ID песни" format(uuid)
+// @Param If-Match header string true "ETag песни"
 // @Param request body dto.UpdateSongRequest true "Данные для обновления песни"
 // @Success 200 {object} dto.StandartResponse "Песня успешно обновлена"
-// @Failure 400 {object} dto.StandartResponse "Ошибка в запросе"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня не найдена"
+// @Failure 412 {object} dto.StandartResponse "Песню успели изменить, в ответе текущая версия"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 428 {object} dto.Problem "Нет заголовка If-Match"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
 // @Router /api/song/{id} [put]
 func (h *Handler) UpdateSongHandler(w http.ResponseWriter, r *http.Request) {
 
-	var resp dto.StandartResponse
 	w.Header().Set("Content-Type", "application/json")
 
 	id, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		resp.Message = "invalid song id"
-		resp.Error = err.Error()
-		json.NewEncoder(w).Encode(resp)
+		writeMalformed(w, r, "invalid song id")
+		return
+	}
+
+	precondition, ok := parseIfMatch(r.Header.Get("If-Match"))
+	if !ok {
+		writeError(w, r, errIfMatchRequired)
 		return
 	}
 
 	req := dto.UpdateSongRequest{}
-	json.NewDecoder(r.Body).Decode(&req)
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeMalformed(w, r, err.Error())
+		return
+	}
+	req.Precondition = precondition
+
+	resp, err := h.srvc.UpdateSong(r.Context(), req, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrSongModified) && resp.Song.Id != uuid.Nil {
+			writeSongModified(w, resp)
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", songETag(resp.Song.Version, ""))
+	json.NewEncoder(w).Encode(resp)
+	return
+}
+
+// @Summary Частично обновить песню
+// @Description Применяет JSON Merge Patch (RFC 7386): переданные поля заменяются, null очищает поле, отсутствующие поля не меняются. Очистить можно release_date, text, link и split_strategy. Требует If-Match с ETag из GET
+// @Tags songs
+// @Security BearerAuth
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Param If-Match header string true "ETag песни"
+// @Param request body dto.UpdateSongRequest true "Изменяемые поля песни"
+// @Success 200 {object} dto.StandartResponse "Песня успешно обновлена"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня не найдена"
+// @Failure 412 {object} dto.StandartResponse "Песню успели изменить, в ответе текущая версия"
+// @Failure 415 {object} dto.Problem "Неподдерживаемый Content-Type"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 428 {object} dto.Problem "Нет заголовка If-Match"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id} [patch]
+func (h *Handler) PatchSongHandler(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeMalformed(w, r, "invalid song id")
+		return
+	}
+
+	if !isMergePatch(r) {
+		writeUnsupportedMediaType(w, r, mergePatchMediaType)
+		return
+	}
+
+	precondition, ok := parseIfMatch(r.Header.Get("If-Match"))
+	if !ok {
+		writeError(w, r, errIfMatchRequired)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeMalformed(w, r, err.Error())
+		return
+	}
+
+	patch, fieldErrors, err := parseSongPatch(body)
+	if err != nil {
+		writeMalformed(w, r, err.Error())
+		return
+	}
+	if len(fieldErrors) > 0 {
+		writeFieldErrors(w, r, fieldErrors...)
+		return
+	}
+	patch.Precondition = precondition
 
-	resp, err = h.srvc.UpdateSong(context.Background(), req, id)
+	resp, err := h.srvc.PatchSong(r.Context(), id, patch)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(resp)
+		if errors.Is(err, repository.ErrSongModified) && resp.Song.Id != uuid.Nil {
+			writeSongModified(w, resp)
+			return
+		}
+		writeError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", songETag(resp.Song.Version, ""))
 	json.NewEncoder(w).Encode(resp)
 	return
 }
 
 // @Summary Удалить песню
-// @Description Удаляет песню из базы данных
+// @Description Переносит песню в корзину: до очистки её можно восстановить через POST /api/song/{id}/restore. Требует If-Match с ETag из GET; если песню успели изменить, возвращается 412 с её текущей версией
 // @Tags songs
+// @Security BearerAuth
 // @Produce json
 // @Param id path string true "ID песни" format(uuid)
+// @Param If-Match header string true "ETag песни"
 // @Success 200 {object} dto.StandartResponse "Песня успешно удалена"
-// @Failure 400 {object} dto.StandartResponse "Ошибка в запросе"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня не найдена"
+// @Failure 412 {object} dto.StandartResponse "Песню успели изменить, в ответе текущая версия"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 428 {object} dto.Problem "Нет заголовка If-Match"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
 // @Router /api/song/{id} [delete]
 func (h *Handler) DeleteSongHandler(w http.ResponseWriter, r *http.Request) {
 
-	resp := dto.StandartResponse{}
 	w.Header().Set("Content-Type", "application/json")
 
 	id, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		resp.Message = "invalid song id"
-		resp.Error = err.Error()
-		json.NewEncoder(w).Encode(resp)
+		writeMalformed(w, r, "invalid song id")
+		return
+	}
+
+	precondition, ok := parseIfMatch(r.Header.Get("If-Match"))
+	if !ok {
+		writeError(w, r, errIfMatchRequired)
 		return
 	}
 
 	request := dto.DeleteSongByIdRequest{
-		Id: id,
+		Id:           id,
+		Precondition: precondition,
 	}
 	err = h.validator.Struct(request)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		resp.Message = "invalid song id"
-		resp.Error = err.Error()
-		json.NewEncoder(w).Encode(resp)
+		writeValidation(w, r, err)
 		return
 	}
 
-	resp, err = h.srvc.DeleteSong(context.Background(), request)
+	resp, err := h.srvc.DeleteSong(r.Context(), request)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(resp)
+		if errors.Is(err, repository.ErrSongModified) && resp.Song.Id != uuid.Nil {
+			writeSongModified(w, resp)
+			return
+		}
+		writeError(w, r, err)
 		return
 	}
 
@@ -189,25 +316,38 @@ func (h *Handler) DeleteSongHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Получить песни с фильтрацией
-// @Description Возвращает список песен, соответствующих фильтрам
+// @Description Возвращает список песен, соответствующих фильтрам. Название и исполнитель сравниваются без учёта регистра
 // @Tags songs
-// @Accept json
+// @Security BearerAuth
 // @Produce json
-// @Param request body dto.FilteredRequest true "Параметры фильтрации"
+// @Param title query string false "Название песни"
+// @Param group query []string false "Исполнитель, можно указать несколько раз" collectionFormat(multi)
+// @Param match query string false "Режим сравнения title и group: contains (по умолчанию), prefix, exact" Enums(contains, prefix, exact)
+// @Param released_from query string false "Дата релиза не раньше (YYYY-MM-DD)"
+// @Param released_to query string false "Дата релиза не позже (YYYY-MM-DD)"
+// @Param q query string false "Поиск подстроки в названии и исполнителе"
+// @Param text query string false "Поиск подстроки в тексте песни"
+// @Param link query string false "Ссылка"
+// @Param limit query int false "Количество песен на странице (по умолчанию 20, максимум 100)"
+// @Param cursor query string false "Курсор следующей страницы из next_cursor"
+// @Param sort query string false "Поля сортировки через запятую: title, release_date, created_at, group_name; минус - по убыванию"
 // @Success 200 {object} dto.SongsResponse "Список найденных песен"
-// @Failure 400 {object} dto.SongsResponse "Ошибка в запросе"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
 // @Router /api/song [get]
 func (h *Handler) GetSongWithFilter(w http.ResponseWriter, r *http.Request) {
-	resp := dto.SongsResponse{}
 	w.Header().Set("Content-Type", "application/json")
 
-	req := dto.FilteredRequest{}
-	json.NewDecoder(r.Body).Decode(&req)
+	req, fieldErrors := parseSongFilter(r.URL.Query())
+	if len(fieldErrors) > 0 {
+		writeFieldErrors(w, r, fieldErrors...)
+		return
+	}
 
-	resp, err := h.srvc.GetSongWithFilter(context.Background(), req)
+	resp, err := h.srvc.GetSongWithFilter(r.Context(), req)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(resp)
+		writeError(w, r, err)
 		return
 	}
 
@@ -216,74 +356,75 @@ func (h *Handler) GetSongWithFilter(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Получить куплеты песни с пагинацией
-// @Description Возвращает куплеты песни с указанной пагинацией
+// @Description Возвращает куплеты песни с указанной пагинацией. У каждого куплета есть тип секции (verse, chorus, pre_chorus, bridge, intro, outro) и repeat_of - id куплета, который он повторяет. section_type фильтрует куплеты по типу. Страница за пределами списка возвращается пустой
 // @Tags verses
-// @Accept json
+// @Security BearerAuth
 // @Produce json
 // @Param id path string true "ID песни" format(uuid)
-// @Param request body dto.PaginatedVersesRequest true "Параметры пагинации"
+// @Param page query int false "Номер страницы (по умолчанию 1)"
+// @Param limit query int false "Куплетов на странице (по умолчанию 10, максимум 50)"
+// @Param section_type query string false "Тип секции" Enums(verse, chorus, pre_chorus, bridge, intro, outro)
+// @Param lang query string false "Язык перевода, иначе берётся из Accept-Language"
+// @Param side_by_side query bool false "Вернуть перевод рядом с оригиналом"
+// @Param Accept-Language header string false "Предпочитаемый язык перевода"
 // @Success 200 {object} dto.PaginatedVersesResponse "Список куплетов песни"
-// @Failure 400 {object} dto.StandartResponse "Ошибка в запросе"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня не найдена"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
 // @Router /api/verses/{id} [get]
 func (h *Handler) GetPaginatedVerses(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	songId, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(dto.StandartResponse{
-			Error:   err.Error(),
-			Message: "Invalid song ID",
-		})
+		writeMalformed(w, r, "invalid song id")
 		return
 	}
 
-	var req dto.PaginatedVersesRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(dto.StandartResponse{
-			Error:   err.Error(),
-			Message: "Failed to decode request body",
-		})
-		return
+	query := r.URL.Query()
+	req := dto.PaginatedVersesRequest{
+		SongId:      songId,
+		Page:        1,
+		Limit:       service.DefaultVersesLimit,
+		SectionType: query.Get("section_type"),
+		Langs:       preferredLangs(r),
+	}
+	if raw := query.Get("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil {
+			writeFieldErrors(w, r, dto.FieldError{Field: "page", Error: "must be an integer"})
+			return
+		}
+		req.Page = page
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			writeFieldErrors(w, r, dto.FieldError{Field: "limit", Error: "must be an integer"})
+			return
+		}
+		req.Limit = limit
+	}
+	if raw := query.Get("side_by_side"); raw != "" {
+		req.SideBySide, err = strconv.ParseBool(raw)
+		if err != nil {
+			writeFieldErrors(w, r, dto.FieldError{Field: "side_by_side", Error: "must be a boolean"})
+			return
+		}
 	}
 
 	err = h.validator.Struct(req)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(dto.StandartResponse{
-			Error:   err.Error(),
-			Message: "Invalid song ID",
-		})
-	}
-
-	req.SongId = songId
-
-	if req.Page < 1 {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(dto.StandartResponse{
-			Error:   "page must be at least 1",
-			Message: "Validation failed",
-		})
+		writeValidation(w, r, err)
 		return
 	}
 
-	if req.Limit < 1 {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(dto.StandartResponse{
-			Error:   "limit must be at least 1",
-			Message: "Validation failed",
-		})
-		return
-	}
-	
-	resp, err := h.srvc.GetPaginatedVerses(context.Background(), req)
+	resp, err := h.srvc.GetPaginatedVerses(r.Context(), req)
+	setLangHeaders(w, resp.Lang)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(dto.StandartResponse{
-			Error:   err.Error(),
-			Message: "Failed to get paginated verses",
-		})
+		writeError(w, r, err)
 		return
 	}
 
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/principal"
+	"io"
+	"net/http"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+// replayedHeaders - заголовки ответа, которые сохраняются вместе с телом.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// responseRecorder пишет ответ клиенту и одновременно запоминает его,
+// чтобы сохранить для повторов с тем же Idempotency-Key.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(data []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(data)
+	return rec.ResponseWriter.Write(data)
+}
+
+// requestHash - отпечаток запроса: повтор с тем же ключом должен совпадать
+// с первым запросом по методу, пути и телу.
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.Path+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Idempotent обрабатывает заголовок Idempotency-Key: первый ответ
+// сохраняется, повтор с тем же ключом и телом получает его без повторного
+// выполнения, а тот же ключ с другим телом отклоняется. Ключи хранятся
+// отдельно для каждого субъекта, так что клиент не получит чужой ответ,
+// угадав ключ. Ответы 5xx не сохраняются - такой запрос можно повторить
+// с тем же ключом.
+func (h *Handler) Idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentRequestBytes+1))
+		if err != nil {
+			writeMalformed(w, r, err.Error())
+			return
+		}
+		if len(body) > maxIdempotentRequestBytes {
+			writeMalformed(w, r, "request body is too large")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var owner string
+		if p, ok := principal.FromContext(r.Context()); ok {
+			owner = p.Actor()
+		}
+
+		record, err := h.idempotencySrvc.Begin(r.Context(), owner, key, requestHash(r, body))
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if record != nil {
+			for name, value := range record.Headers {
+				w.Header().Set(name, value)
+			}
+			w.Header().Set(idempotentReplayedHeader, "true")
+			w.WriteHeader(record.StatusCode)
+			w.Write(record.Body)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		completed := false
+		defer func() {
+			if !completed {
+				h.idempotencySrvc.Release(context.WithoutCancel(r.Context()), owner, key)
+			}
+		}()
+
+		next(rec, r)
+
+		if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+			return
+		}
+
+		headers := make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := w.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+
+		err = h.idempotencySrvc.Complete(context.WithoutCancel(r.Context()), owner, key, rec.status, headers, rec.body.Bytes())
+		completed = err == nil
+	}
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	ndjsonMediaType = "application/x-ndjson"
+	csvMediaType    = "text/csv"
+
+	maxImportLineBytes = 64 << 10
+)
+
+// importParsers - разборщики файла импорта по Content-Type. Каждый
+// передаёт строки в emit и прекращает чтение, если emit вернул false.
+var importParsers = map[string]func(body io.Reader, validate *validator.Validate, emit func(dto.ImportRow) bool){
+	ndjsonMediaType:      parseNDJSONImport,
+	"application/ndjson": parseNDJSONImport,
+	"application/jsonl":  parseNDJSONImport,
+	csvMediaType:         parseCSVImport,
+	"application/csv":    parseCSVImport,
+}
+
+// @Summary Импорт песен
+// @Description Создаёт песни из NDJSON (строки {"group": "...", "title": "..."}) или CSV (колонки group,title, заголовок необязателен). Метаданные запрашиваются параллельно. Отчёт отдаётся потоком NDJSON по мере обработки: по строке на каждую строку файла (статус created, duplicate, metadata_missing, invalid, failed или rate_limited, порядок не сохраняется) и последняя строка {"summary": {...}}. Каждая строка, которой нужен сервис метаданных, расходует лимит RATE_LIMIT_UPSTREAM; строки сверх лимита получают статус rate_limited
+// @Tags songs
+// @Security BearerAuth
+// @Accept application/x-ndjson
+// @Accept text/csv
+// @Produce application/x-ndjson
+// @Param request body string true "Строки group/title"
+// @Success 200 {object} dto.ImportRowResult "Поток результатов по строкам, последняя строка - dto.ImportSummaryLine"
+// @Failure 415 {object} dto.Problem "Неподдерживаемый Content-Type"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Router /api/songs/import [post]
+func (h *Handler) ImportSongsHandler(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	parse, ok := importParsers[mediaType]
+	if !ok {
+		writeUnsupportedMediaType(w, r, ndjsonMediaType, csvMediaType)
+		return
+	}
+
+	// Отчёт пишется, пока файл ещё читается
+	rc := http.NewResponseController(w)
+	rc.EnableFullDuplex()
+
+	ctx := r.Context()
+	rows := make(chan dto.ImportRow)
+	go func() {
+		defer close(rows)
+		parse(r.Body, h.validator, func(row dto.ImportRow) bool {
+			select {
+			case rows <- row:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	w.Header().Set("Content-Type", ndjsonMediaType)
+	w.WriteHeader(http.StatusOK)
+	rc.Flush()
+
+	encoder := json.NewEncoder(w)
+	summary := h.importSrvc.ImportSongs(ctx, rateLimitClient(r), rows, func(result dto.ImportRowResult) {
+		encoder.Encode(result)
+		rc.Flush()
+	})
+	encoder.Encode(dto.ImportSummaryLine{Summary: summary})
+}
+
+func parseNDJSONImport(body io.Reader, validate *validator.Validate, emit func(dto.ImportRow) bool) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), maxImportLineBytes)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		row := dto.ImportRow{Line: line}
+		err := json.Unmarshal(scanner.Bytes(), &row.Request)
+		if err != nil {
+			row.Error = "invalid JSON: " + err.Error()
+		} else {
+			row.Error = validateImportRow(validate, row.Request)
+		}
+		if !emit(row) {
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		emit(dto.ImportRow{Line: line + 1, Error: importReadError(err)})
+	}
+}
+
+func parseCSVImport(body io.Reader, validate *validator.Validate, emit func(dto.ImportRow) bool) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	first := true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			row := dto.ImportRow{Error: importReadError(err)}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				row.Line = parseErr.Line
+			}
+			emit(row)
+			return
+		}
+
+		line, _ := reader.FieldPos(0)
+		if first {
+			first = false
+			if len(record) == 2 && strings.EqualFold(strings.TrimSpace(record[0]), "group") && strings.EqualFold(strings.TrimSpace(record[1]), "title") {
+				continue
+			}
+		}
+
+		row := dto.ImportRow{Line: line}
+		if len(record) != 2 {
+			row.Error = "expected 2 columns: group,title"
+		} else {
+			row.Request = dto.CreateSongRequest{
+				Group: strings.TrimSpace(record[0]),
+				Title: strings.TrimSpace(record[1]),
+			}
+			row.Error = validateImportRow(validate, row.Request)
+		}
+		if !emit(row) {
+			return
+		}
+	}
+}
+
+// validateImportRow проверяет строку теми же правилами, что и POST
+// /api/song, и возвращает текст ошибки или пустую строку.
+func validateImportRow(validate *validator.Validate, request dto.CreateSongRequest) string {
+	err := validate.Struct(request)
+	if err == nil {
+		return ""
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err.Error()
+	}
+	messages := make([]string, len(validationErrors))
+	for i, fieldErr := range validationErrors {
+		messages[i] = fieldErr.Field() + " failed on " + fieldErr.Tag()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func importReadError(err error) string {
+	if errors.Is(err, bufio.ErrTooLong) {
+		return "line is too long, import stopped"
+	}
+	return "failed to read import: " + err.Error() + ", import stopped"
+}
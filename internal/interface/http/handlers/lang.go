@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"github.com/wiqwi12/effective-mobile-test/internal/service"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// preferredLangs возвращает языки перевода по убыванию приоритета. Параметр
+// ?lang= (можно несколько через запятую) важнее заголовка Accept-Language.
+// Из Accept-Language берётся только самый приоритетный язык: язык оригинала
+// неизвестен, и без этого пользователь с "ru, en;q=0.8" получил бы английский
+// перевод русской песни. Некорректные теги и "*" пропускаются.
+func preferredLangs(r *http.Request) []string {
+	if raw := r.URL.Query().Get("lang"); raw != "" {
+		var langs []string
+		for _, tag := range strings.Split(raw, ",") {
+			if lang, err := service.NormalizeLang(tag); err == nil {
+				langs = append(langs, lang)
+			}
+		}
+		return langs
+	}
+
+	type weighted struct {
+		lang string
+		q    float64
+	}
+	var prefs []weighted
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		lang, err := service.NormalizeLang(tag)
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			q, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		prefs = append(prefs, weighted{lang: lang, q: q})
+	}
+	sort.SliceStable(prefs, func(i, j int) bool {
+		return prefs[i].q > prefs[j].q
+	})
+
+	if len(prefs) == 0 {
+		return nil
+	}
+	return []string{prefs[0].lang}
+}
+
+// setLangHeaders сообщает кешам, что ответ зависит от Accept-Language,
+// и указывает язык перевода, если он был подставлен.
+func setLangHeaders(w http.ResponseWriter, lang string) {
+	w.Header().Add("Vary", "Accept-Language")
+	if lang != "" {
+		w.Header().Set("Content-Language", lang)
+	}
+}
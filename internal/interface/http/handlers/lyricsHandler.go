@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"github.com/wiqwi12/effective-mobile-test/internal/service"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// @Summary Получить строки с таймингами
+// @Description Возвращает строки текста песни с таймингами в миллисекундах. У строк, тайминг которых потерялся после правки текста, time_ms отсутствует
+// @Tags lyrics
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Success 200 {object} dto.TimedLyricsResponse "Строки с таймингами"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня или тайминги не найдены"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/lyrics [get]
+func (h *Handler) GetTimedLyricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	songId, _, ok := parseVersePath(w, r, false)
+	if !ok {
+		return
+	}
+
+	resp, err := h.srvc.GetTimedLyrics(r.Context(), songId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Импортировать LRC
+// @Description Привязывает тайминги из LRC к строкам текста песни. Принимает JSON или LRC как есть с Content-Type text/plain (тогда replace_text передаётся в query). Если у песни нет текста или replace_text=true, текст и куплеты заменяются текстом из LRC
+// @Tags lyrics
+// @Security BearerAuth
+// @Accept json
+// @Accept plain
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Param request body dto.ImportLrcRequest true "LRC"
+// @Param replace_text query bool false "Заменить текст песни (для text/plain)"
+// @Success 200 {object} dto.TimedLyricsResponse "Тайминги импортированы"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня не найдена"
+// @Failure 412 {object} dto.Problem "Текст песни изменился во время импорта"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/lyrics/lrc [put]
+func (h *Handler) ImportLrcHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	songId, _, ok := parseVersePath(w, r, false)
+	if !ok {
+		return
+	}
+
+	var req dto.ImportLrcRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "text/plain" {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeMalformed(w, r, err.Error())
+			return
+		}
+		req.Lrc = string(body)
+		if raw := r.URL.Query().Get("replace_text"); raw != "" {
+			req.ReplaceText, err = strconv.ParseBool(raw)
+			if err != nil {
+				writeFieldErrors(w, r, dto.FieldError{Field: "replace_text", Error: "must be a boolean"})
+				return
+			}
+		}
+	} else {
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			writeMalformed(w, r, err.Error())
+			return
+		}
+	}
+
+	err := h.validator.Struct(req)
+	if err != nil {
+		writeValidation(w, r, err)
+		return
+	}
+
+	resp, err := h.srvc.ImportLrc(r.Context(), songId, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Экспортировать LRC
+// @Description Возвращает тайминги песни в формате LRC. Строки без тайминга пропускаются
+// @Tags lyrics
+// @Security BearerAuth
+// @Produce plain
+// @Param id path string true "ID песни" format(uuid)
+// @Success 200 {string} string "LRC"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня или тайминги не найдены"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/lyrics/lrc [get]
+func (h *Handler) ExportLrcHandler(w http.ResponseWriter, r *http.Request) {
+	songId, _, ok := parseVersePath(w, r, false)
+	if !ok {
+		return
+	}
+
+	text, err := h.srvc.ExportLrc(r.Context(), songId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+songId.String()+`.lrc"`)
+	io.WriteString(w, text)
+}
+
+// @Summary Строка на позиции воспроизведения
+// @Description Возвращает строку, которая звучит в момент t, и next следующих строк. Если t раньше первой строки, current пустой
+// @Tags lyrics
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Param t query number true "Позиция воспроизведения в секундах"
+// @Param next query int false "Сколько следующих строк вернуть (по умолчанию 3, максимум 20)"
+// @Success 200 {object} dto.LyricsAtResponse "Текущая и следующие строки"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня или тайминги не найдены"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/lyrics/at [get]
+func (h *Handler) LyricsAtHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	songId, _, ok := parseVersePath(w, r, false)
+	if !ok {
+		return
+	}
+
+	req := dto.LyricsAtRequest{
+		SongId: songId,
+		Next:   service.DefaultNextLines,
+	}
+	query := r.URL.Query()
+	t, err := strconv.ParseFloat(query.Get("t"), 64)
+	if err != nil || math.IsNaN(t) || math.IsInf(t, 0) {
+		writeFieldErrors(w, r, dto.FieldError{Field: "t", Error: "must be a number of seconds"})
+		return
+	}
+	req.Time = t
+	if raw := query.Get("next"); raw != "" {
+		next, err := strconv.Atoi(raw)
+		if err != nil {
+			writeFieldErrors(w, r, dto.FieldError{Field: "next", Error: "must be an integer"})
+			return
+		}
+		req.Next = next
+	}
+
+	err = h.validator.Struct(req)
+	if err != nil {
+		writeValidation(w, r, err)
+		return
+	}
+
+	resp, err := h.srvc.GetLyricsAt(r.Context(), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
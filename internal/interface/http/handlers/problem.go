@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"net/http"
+)
+
+const (
+	problemTypeBase = "/problems/"
+
+	codeMalformedRequest = "malformed_request"
+	codeValidationFailed = "validation_failed"
+)
+
+var kindStatus = map[apperr.Kind]int{
+	apperr.Internal:             http.StatusInternalServerError,
+	apperr.Validation:           http.StatusUnprocessableEntity,
+	apperr.NotFound:             http.StatusNotFound,
+	apperr.Conflict:             http.StatusConflict,
+	apperr.Unavailable:          http.StatusBadGateway,
+	apperr.PreconditionFailed:   http.StatusPreconditionFailed,
+	apperr.PreconditionRequired: http.StatusPreconditionRequired,
+	apperr.Unauthorized:         http.StatusUnauthorized,
+	apperr.Forbidden:            http.StatusForbidden,
+	apperr.TooManyRequests:      http.StatusTooManyRequests,
+	apperr.TooLarge:             http.StatusRequestEntityTooLarge,
+}
+
+// writeError отвечает ошибкой сервиса: статус выбирается по виду ошибки.
+// Подробности внутренних ошибок и ошибок внешнего сервиса (адреса, ошибки
+// базы) клиенту не показываются.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	kind := apperr.KindOf(err)
+	detail := err.Error()
+	switch kind {
+	case apperr.Internal:
+		detail = "internal server error"
+	case apperr.Unavailable:
+		detail = apperr.MessageOf(err)
+	}
+
+	writeProblem(w, r, dto.Problem{
+		Status: kindStatus[kind],
+		Code:   apperr.CodeOf(err),
+		Detail: detail,
+	})
+}
+
+// writeMalformed отвечает на запрос, который не удалось разобрать:
+// невалидный JSON или id в пути.
+func writeMalformed(w http.ResponseWriter, r *http.Request, detail string) {
+	writeProblem(w, r, dto.Problem{
+		Status: http.StatusBadRequest,
+		Code:   codeMalformedRequest,
+		Detail: detail,
+	})
+}
+
+// writeValidation отвечает на запрос, не прошедший валидацию, с ошибкой
+// по каждому полю.
+func writeValidation(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		writeFieldErrors(w, r)
+		return
+	}
+
+	fields := make([]dto.FieldError, len(validationErrors))
+	for i, fieldErr := range validationErrors {
+		message := "failed on " + fieldErr.Tag()
+		if fieldErr.Param() != "" {
+			message = fmt.Sprintf("failed on %s=%s", fieldErr.Tag(), fieldErr.Param())
+		}
+		fields[i] = dto.FieldError{Field: fieldErr.Field(), Error: message}
+	}
+	writeFieldErrors(w, r, fields...)
+}
+
+func writeFieldErrors(w http.ResponseWriter, r *http.Request, fields ...dto.FieldError) {
+	writeProblem(w, r, dto.Problem{
+		Status: http.StatusUnprocessableEntity,
+		Code:   codeValidationFailed,
+		Detail: "request validation failed",
+		Fields: fields,
+	})
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, problem dto.Problem) {
+	problem.Type = problemTypeBase + problem.Code
+	problem.Title = http.StatusText(problem.Status)
+	problem.Instance = r.URL.Path
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
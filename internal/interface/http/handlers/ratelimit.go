@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/principal"
+	"github.com/wiqwi12/effective-mobile-test/internal/service/ratelimit"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var errRateLimited = apperr.New(apperr.TooManyRequests, "rate_limited", "rate limit exceeded")
+
+// RateLimit ограничивает частоту запросов клиента к группе маршрутов limiter.
+// Клиент - субъект запроса (API-ключ или пользователь JWT), для анонимных
+// запросов - IP. В каждом ответе состояние лимита отдаётся в X-RateLimit-*,
+// при превышении - 429 с Retry-After.
+func (h *Handler) RateLimit(limiter *ratelimit.Limiter, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result := limiter.Allow(r.Context(), rateLimitClient(r), time.Now())
+		if result.Limit == 0 {
+			next(w, r)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			writeRateLimited(w, r, result)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// writeRateLimited отвечает 429 с Retry-After.
+func writeRateLimited(w http.ResponseWriter, r *http.Request, result ratelimit.Result) {
+	retryAfter := ceilSeconds(result.RetryAfter)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	writeError(w, r, fmt.Errorf("%w: retry in %d seconds", errRateLimited, retryAfter))
+}
+
+// rateLimitClient определяет, чей лимит расходует запрос. X-Forwarded-For не
+// учитывается: его может подставить сам клиент.
+func rateLimitClient(r *http.Request) string {
+	if p, ok := principal.FromContext(r.Context()); ok {
+		return p.Actor()
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// ceilSeconds округляет до целых секунд вверх, чтобы клиент, выждавший
+// Retry-After, не получил 429 снова.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/postgres/repository"
+	"github.com/wiqwi12/effective-mobile-test/internal/service"
+	"net/http"
+	"strconv"
+)
+
+// @Summary История песни
+// @Description Возвращает ревизии песни, начиная с последней. Ревизия создаётся при каждом изменении полей или текста песни и хранит снимок полей, список изменённых полей, время и автора, если он известен
+// @Tags revisions
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Param page query int false "Номер страницы (по умолчанию 1)"
+// @Param limit query int false "Ревизий на странице (по умолчанию 20, максимум 100)"
+// @Success 200 {object} dto.RevisionsResponse "Ревизии песни"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня не найдена"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/revisions [get]
+func (h *Handler) ListRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeMalformed(w, r, "invalid song id")
+		return
+	}
+
+	req := dto.RevisionsListRequest{
+		SongId: id,
+		Page:   1,
+		Limit:  service.DefaultRevisionsLimit,
+	}
+	query := r.URL.Query()
+	if raw := query.Get("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil {
+			writeFieldErrors(w, r, dto.FieldError{Field: "page", Error: "must be an integer"})
+			return
+		}
+		req.Page = page
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			writeFieldErrors(w, r, dto.FieldError{Field: "limit", Error: "must be an integer"})
+			return
+		}
+		req.Limit = limit
+	}
+
+	err = h.validator.Struct(req)
+	if err != nil {
+		writeValidation(w, r, err)
+		return
+	}
+
+	resp, err := h.srvc.ListRevisions(r.Context(), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Сравнить ревизии песни
+// @Description Построчный дифф текста и список изменённых полей между ревизиями from и to. Без to сравнивается последняя ревизия, без from - предыдущая перед to. from может быть больше to, тогда дифф показывает откат
+// @Tags revisions
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Param from query int false "Исходная ревизия"
+// @Param to query int false "Конечная ревизия"
+// @Success 200 {object} dto.RevisionDiffResponse "Изменения между ревизиями"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня или ревизия не найдена"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/revisions/diff [get]
+func (h *Handler) DiffRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeMalformed(w, r, "invalid song id")
+		return
+	}
+
+	req := dto.RevisionDiffRequest{SongId: id}
+	query := r.URL.Query()
+	var fieldErrors []dto.FieldError
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{{"from", &req.From}, {"to", &req.To}} {
+		raw := query.Get(param.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			fieldErrors = append(fieldErrors, dto.FieldError{Field: param.name, Error: "must be an integer"})
+			continue
+		}
+		*param.value = value
+	}
+	if len(fieldErrors) > 0 {
+		writeFieldErrors(w, r, fieldErrors...)
+		return
+	}
+
+	err = h.validator.Struct(req)
+	if err != nil {
+		writeValidation(w, r, err)
+		return
+	}
+
+	resp, err := h.srvc.DiffRevisions(r.Context(), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Откатить песню к ревизии
+// @Description Возвращает песне поля и текст из ревизии и пересобирает куплеты. Откат создаёт новую ревизию. Требует If-Match с ETag из GET; если песню успели изменить, возвращается 412 с её текущей версией
+// @Tags revisions
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Param rev path int true "Номер ревизии"
+// @Param If-Match header string true "ETag песни"
+// @Success 200 {object} dto.StandartResponse "Песня откачена"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня или ревизия не найдена"
+// @Failure 412 {object} dto.StandartResponse "Песню успели изменить, в ответе текущая версия"
+// @Failure 428 {object} dto.Problem "Нет заголовка If-Match"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/revisions/{rev}/revert [post]
+func (h *Handler) RevertSongHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeMalformed(w, r, "invalid song id")
+		return
+	}
+
+	revision, err := strconv.Atoi(r.PathValue("rev"))
+	if err != nil || revision < 1 {
+		writeMalformed(w, r, "revision must be a positive integer")
+		return
+	}
+
+	precondition, ok := parseIfMatch(r.Header.Get("If-Match"))
+	if !ok {
+		writeError(w, r, errIfMatchRequired)
+		return
+	}
+
+	req := dto.RevertSongRequest{
+		SongId:       id,
+		Revision:     revision,
+		Precondition: precondition,
+	}
+
+	resp, err := h.srvc.RevertSong(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, repository.ErrSongModified) && resp.Song.Id != uuid.Nil {
+			writeSongModified(w, resp)
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", songETag(resp.Song.Version, ""))
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// @Summary Полнотекстовый поиск по текстам песен
+// @Description Ищет песни по тексту с учётом русской и английской морфологии. Возвращает песни по убыванию релевантности, номера совпавших куплетов и фрагменты с подсветкой
+// @Tags search
+// @Security BearerAuth
+// @Produce json
+// @Param q query string true "Поисковый запрос, поддерживается синтаксис websearch (\"фраза\", -исключение, or)"
+// @Param lang query string false "Язык морфологии: ru или en. По умолчанию оба" Enums(ru, en)
+// @Param limit query int false "Количество песен (по умолчанию 20, максимум 50)"
+// @Param offset query int false "Смещение"
+// @Success 200 {object} dto.LyricsSearchResponse "Найденные песни"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/search/lyrics [get]
+func (h *Handler) SearchLyricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	req := dto.LyricsSearchRequest{
+		Query: strings.TrimSpace(query.Get("q")),
+		Lang:  query.Get("lang"),
+	}
+
+	var fieldErrors []dto.FieldError
+	if req.Query == "" {
+		fieldErrors = append(fieldErrors, dto.FieldError{Field: "q", Error: "is required"})
+	}
+	if req.Lang != "" && req.Lang != "ru" && req.Lang != "en" {
+		fieldErrors = append(fieldErrors, dto.FieldError{Field: "lang", Error: "must be ru or en"})
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			fieldErrors = append(fieldErrors, dto.FieldError{Field: "limit", Error: "must be a positive integer"})
+		}
+		req.Limit = limit
+	}
+	if raw := query.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			fieldErrors = append(fieldErrors, dto.FieldError{Field: "offset", Error: "must be a non-negative integer"})
+		}
+		req.Offset = offset
+	}
+
+	if len(fieldErrors) > 0 {
+		writeFieldErrors(w, r, fieldErrors...)
+		return
+	}
+
+	resp, err := h.srvc.SearchLyrics(r.Context(), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	mergePatchMediaType = "application/merge-patch+json"
+
+	maxSongFieldLength = 255
+
+	codeUnsupportedMediaType = "unsupported_media_type"
+)
+
+var errNotAnObject = errors.New("merge patch must be a JSON object")
+
+// songPatchFields - поля, которые можно менять через PATCH, и можно ли
+// их очистить через null.
+var songPatchFields = map[string]bool{
+	"group":          false,
+	"title":          false,
+	"release_date":   true,
+	"text":           true,
+	"link":           true,
+	"split_strategy": true,
+}
+
+// parseSongPatch разбирает JSON Merge Patch песни и проверяет каждое поле.
+// Ошибка возвращается, если тело не JSON-объект; ошибки полей - списком.
+func parseSongPatch(body []byte) (dto.SongPatch, []dto.FieldError, error) {
+	var patch dto.SongPatch
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return patch, nil, errNotAnObject
+		}
+		return patch, nil, err
+	}
+	if raw == nil {
+		return patch, nil, errNotAnObject
+	}
+
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fieldErrors []dto.FieldError
+	for _, name := range names {
+		nullable, known := songPatchFields[name]
+		if !known {
+			fieldErrors = append(fieldErrors, dto.FieldError{Field: name, Error: "unknown field"})
+			continue
+		}
+
+		var value *string
+		if bytes.Equal(bytes.TrimSpace(raw[name]), []byte("null")) {
+			if !nullable {
+				fieldErrors = append(fieldErrors, dto.FieldError{Field: name, Error: "cannot be cleared"})
+				continue
+			}
+			value = new(string)
+		} else {
+			value = new(string)
+			if err := json.Unmarshal(raw[name], value); err != nil {
+				fieldErrors = append(fieldErrors, dto.FieldError{Field: name, Error: "must be a string or null"})
+				continue
+			}
+			if message := validateSongPatchField(name, *value); message != "" {
+				fieldErrors = append(fieldErrors, dto.FieldError{Field: name, Error: message})
+				continue
+			}
+		}
+
+		switch name {
+		case "group":
+			patch.GroupName = value
+		case "title":
+			patch.Title = value
+		case "release_date":
+			patch.ReleaseDate = value
+		case "text":
+			patch.Text = value
+		case "link":
+			patch.Link = value
+		case "split_strategy":
+			patch.SplitStrategy = value
+		}
+	}
+
+	return patch, fieldErrors, nil
+}
+
+// validateSongPatchField возвращает текст ошибки для непустого значения
+// поля или пустую строку, если значение подходит.
+func validateSongPatchField(name, value string) string {
+	switch name {
+	case "group", "title":
+		if strings.TrimSpace(value) == "" {
+			return "must not be empty"
+		}
+		if utf8.RuneCountInString(value) > maxSongFieldLength {
+			return "must be at most 255 characters"
+		}
+	case "release_date":
+		if _, err := time.Parse(dateLayout, value); err != nil {
+			return "must be a date in YYYY-MM-DD format"
+		}
+	case "link":
+		link, err := url.ParseRequestURI(value)
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" {
+			return "must be an http or https URL"
+		}
+	case "split_strategy":
+		if value == "" {
+			return "must not be empty, use null to reset"
+		}
+	}
+	return ""
+}
+
+// isMergePatch проверяет Content-Type запроса: кроме merge-patch+json
+// принимается обычный application/json.
+func isMergePatch(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == mergePatchMediaType || mediaType == "application/json"
+}
+
+// writeUnsupportedMediaType отвечает 415 и перечисляет в Accept-Patch или
+// detail типы, которые принимает эндпоинт.
+func writeUnsupportedMediaType(w http.ResponseWriter, r *http.Request, accepted ...string) {
+	if r.Method == http.MethodPatch {
+		w.Header().Set("Accept-Patch", strings.Join(accepted, ", "))
+	}
+	writeProblem(w, r, dto.Problem{
+		Status: http.StatusUnsupportedMediaType,
+		Code:   codeUnsupportedMediaType,
+		Detail: "content type must be one of: " + strings.Join(accepted, ", "),
+	})
+}
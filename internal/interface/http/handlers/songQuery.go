@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+var songFilterParams = map[string]bool{
+	"title":         false,
+	"group":         true,
+	"released_from": false,
+	"released_to":   false,
+	"q":             false,
+	"text":          false,
+	"link":          false,
+	"match":         false,
+	"limit":         false,
+	"cursor":        false,
+	"sort":          false,
+}
+
+// parseSongFilter собирает фильтры из query-строки и возвращает
+// ошибку по каждому некорректному параметру, а не только по первому.
+func parseSongFilter(query url.Values) (dto.FilteredRequest, []dto.FieldError) {
+	var req dto.FilteredRequest
+	var fieldErrors []dto.FieldError
+
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		values := query[name]
+		multi, known := songFilterParams[name]
+		if !known {
+			fieldErrors = append(fieldErrors, dto.FieldError{Field: name, Error: "unknown parameter"})
+			continue
+		}
+		if !multi && len(values) > 1 {
+			fieldErrors = append(fieldErrors, dto.FieldError{Field: name, Error: "must be specified only once"})
+		}
+	}
+
+	req.Title = query.Get("title")
+	req.Query = query.Get("q")
+	req.Text = query.Get("text")
+	req.Link = query.Get("link")
+	req.Cursor = query.Get("cursor")
+	req.Sort = query.Get("sort")
+
+	for _, group := range query["group"] {
+		if group == "" {
+			fieldErrors = append(fieldErrors, dto.FieldError{Field: "group", Error: "must not be empty"})
+			continue
+		}
+		req.Groups = append(req.Groups, group)
+	}
+
+	req.Match = query.Get("match")
+	switch req.Match {
+	case "", dto.MatchContains, dto.MatchPrefix, dto.MatchExact:
+	default:
+		fieldErrors = append(fieldErrors, dto.FieldError{
+			Field: "match",
+			Error: fmt.Sprintf("must be one of %s, %s, %s", dto.MatchContains, dto.MatchPrefix, dto.MatchExact),
+		})
+	}
+
+	var from, to time.Time
+	if raw := query.Get("released_from"); raw != "" {
+		parsed, err := time.Parse(dateLayout, raw)
+		if err != nil {
+			fieldErrors = append(fieldErrors, dto.FieldError{Field: "released_from", Error: "must be a date in YYYY-MM-DD format"})
+		} else {
+			from = parsed
+			req.ReleasedFrom = raw
+		}
+	}
+	if raw := query.Get("released_to"); raw != "" {
+		parsed, err := time.Parse(dateLayout, raw)
+		if err != nil {
+			fieldErrors = append(fieldErrors, dto.FieldError{Field: "released_to", Error: "must be a date in YYYY-MM-DD format"})
+		} else {
+			to = parsed
+			req.ReleasedTo = raw
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		fieldErrors = append(fieldErrors, dto.FieldError{Field: "released_to", Error: "must not be earlier than released_from"})
+	}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			fieldErrors = append(fieldErrors, dto.FieldError{Field: "limit", Error: "must be a positive integer"})
+		} else {
+			req.Limit = limit
+		}
+	}
+
+	return req, fieldErrors
+}
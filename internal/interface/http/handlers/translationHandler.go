@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"net/http"
+)
+
+// @Summary Получить список переводов
+// @Description Возвращает языки, на которые переведена песня, и количество переведённых куплетов
+// @Tags translations
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Success 200 {object} dto.TranslationsResponse "Переводы"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня не найдена"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/translations [get]
+func (h *Handler) ListTranslationsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	songId, _, ok := parseVersePath(w, r, false)
+	if !ok {
+		return
+	}
+
+	resp, err := h.srvc.ListTranslations(r.Context(), songId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Получить перевод
+// @Description Возвращает перевод песни и её куплетов на язык lang
+// @Tags translations
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Param lang path string true "Язык перевода, например en или pt-br"
+// @Success 200 {object} dto.TranslationResponse "Перевод"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня или перевод не найдены"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/translations/{lang} [get]
+func (h *Handler) GetTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	songId, _, ok := parseVersePath(w, r, false)
+	if !ok {
+		return
+	}
+
+	resp, err := h.srvc.GetTranslation(r.Context(), songId, r.PathValue("lang"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Сохранить перевод
+// @Description Создаёт или заменяет перевод песни. Текст перевода разбивается на куплеты той же стратегией, что и оригинал, куплеты можно передать и явно. Количество куплетов должно совпадать с оригиналом
+// @Tags translations
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Param lang path string true "Язык перевода, например en или pt-br"
+// @Param request body dto.SaveTranslationRequest true "Перевод"
+// @Success 200 {object} dto.TranslationResponse "Перевод сохранён"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня не найдена"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/translations/{lang} [put]
+func (h *Handler) SaveTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	songId, _, ok := parseVersePath(w, r, false)
+	if !ok {
+		return
+	}
+
+	var req dto.SaveTranslationRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeMalformed(w, r, err.Error())
+		return
+	}
+
+	err = h.validator.Struct(req)
+	if err != nil {
+		writeValidation(w, r, err)
+		return
+	}
+
+	resp, err := h.srvc.SaveTranslation(r.Context(), songId, r.PathValue("lang"), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Удалить перевод
+// @Description Удаляет перевод песни и её куплетов на язык lang
+// @Tags translations
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Param lang path string true "Язык перевода"
+// @Success 200 {object} dto.TranslationResponse "Перевод удалён"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Перевод не найден"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/translations/{lang} [delete]
+func (h *Handler) DeleteTranslationHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	songId, _, ok := parseVersePath(w, r, false)
+	if !ok {
+		return
+	}
+
+	resp, err := h.srvc.DeleteTranslation(r.Context(), songId, r.PathValue("lang"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"github.com/wiqwi12/effective-mobile-test/internal/service"
+	"net/http"
+	"strconv"
+)
+
+// @Summary Корзина
+// @Description Возвращает удалённые песни, начиная с удалённых последними. purge_at - момент, после которого песня будет удалена окончательно
+// @Tags trash
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Номер страницы (по умолчанию 1)"
+// @Param limit query int false "Песен на странице (по умолчанию 20, максимум 100)"
+// @Success 200 {object} dto.TrashResponse "Песни в корзине"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/trash [get]
+func (h *Handler) ListTrashHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	req := dto.TrashListRequest{
+		Page:  1,
+		Limit: service.DefaultTrashLimit,
+	}
+	query := r.URL.Query()
+	if raw := query.Get("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil {
+			writeFieldErrors(w, r, dto.FieldError{Field: "page", Error: "must be an integer"})
+			return
+		}
+		req.Page = page
+	}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			writeFieldErrors(w, r, dto.FieldError{Field: "limit", Error: "must be an integer"})
+			return
+		}
+		req.Limit = limit
+	}
+
+	err := h.validator.Struct(req)
+	if err != nil {
+		writeValidation(w, r, err)
+		return
+	}
+
+	resp, err := h.trashSrvc.ListTrash(r.Context(), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Восстановить песню
+// @Description Возвращает песню из корзины вместе с куплетами, таймингами и переводами. Если за это время создали такую же песню, возвращается 409
+// @Tags trash
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Success 200 {object} dto.StandartResponse "Песня восстановлена"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песни нет в корзине"
+// @Failure 409 {object} dto.Problem "Такая песня уже существует"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/restore [post]
+func (h *Handler) RestoreSongHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeMalformed(w, r, "invalid song id")
+		return
+	}
+
+	resp, err := h.trashSrvc.RestoreSong(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", songETag(resp.Song.Version, ""))
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"net/http"
+	"strconv"
+)
+
+// parseVersePath разбирает {id} и {n} из пути. Если номер куплета в пути
+// не нужен, withNumber = false.
+func parseVersePath(w http.ResponseWriter, r *http.Request, withNumber bool) (uuid.UUID, int, bool) {
+	songId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeMalformed(w, r, "invalid song id")
+		return uuid.Nil, 0, false
+	}
+
+	if !withNumber {
+		return songId, 0, true
+	}
+
+	number, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil || number < 1 {
+		writeMalformed(w, r, "verse number must be a positive integer")
+		return uuid.Nil, 0, false
+	}
+
+	return songId, number, true
+}
+
+// @Summary Получить куплет
+// @Description Возвращает один куплет песни по номеру
+// @Tags verses
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Param n path int true "Номер куплета"
+// @Param lang query string false "Язык перевода, иначе берётся из Accept-Language"
+// @Param side_by_side query bool false "Вернуть перевод рядом с оригиналом"
+// @Param Accept-Language header string false "Предпочитаемый язык перевода"
+// @Success 200 {object} dto.VerseResponse "Куплет"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня или куплет не найдены"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/verses/{n} [get]
+func (h *Handler) GetVerseHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	songId, number, ok := parseVersePath(w, r, true)
+	if !ok {
+		return
+	}
+
+	var sideBySide bool
+	if raw := r.URL.Query().Get("side_by_side"); raw != "" {
+		var err error
+		sideBySide, err = strconv.ParseBool(raw)
+		if err != nil {
+			writeFieldErrors(w, r, dto.FieldError{Field: "side_by_side", Error: "must be a boolean"})
+			return
+		}
+	}
+
+	resp, err := h.srvc.GetVerse(r.Context(), songId, number, preferredLangs(r), sideBySide)
+	setLangHeaders(w, resp.Lang)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Изменить куплет
+// @Description Заменяет текст куплета, текст песни пересобирается из куплетов
+// @Tags verses
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Param n path int true "Номер куплета"
+// @Param request body dto.UpdateVerseRequest true "Новый текст куплета"
+// @Success 200 {object} dto.VerseResponse "Куплет обновлён"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня или куплет не найдены"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/verses/{n} [put]
+func (h *Handler) UpdateVerseHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	songId, number, ok := parseVersePath(w, r, true)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateVerseRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeMalformed(w, r, err.Error())
+		return
+	}
+
+	err = h.validator.Struct(req)
+	if err != nil {
+		writeValidation(w, r, err)
+		return
+	}
+
+	resp, err := h.srvc.UpdateVerse(r.Context(), songId, number, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Добавить куплет
+// @Description Вставляет куплет на позицию position, последующие куплеты сдвигаются. Без position куплет добавляется в конец
+// @Tags verses
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Param request body dto.InsertVerseRequest true "Текст и позиция куплета"
+// @Success 201 {object} dto.VerseResponse "Куплет добавлен"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня не найдена"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/verses [post]
+func (h *Handler) InsertVerseHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	songId, _, ok := parseVersePath(w, r, false)
+	if !ok {
+		return
+	}
+
+	var req dto.InsertVerseRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeMalformed(w, r, err.Error())
+		return
+	}
+
+	err = h.validator.Struct(req)
+	if err != nil {
+		writeValidation(w, r, err)
+		return
+	}
+
+	resp, err := h.srvc.InsertVerse(r.Context(), songId, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Удалить куплет
+// @Description Удаляет куплет, последующие куплеты сдвигаются на его место
+// @Tags verses
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Param n path int true "Номер куплета"
+// @Success 200 {object} dto.VerseResponse "Куплет удалён"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня или куплет не найдены"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/verses/{n} [delete]
+func (h *Handler) DeleteVerseHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	songId, number, ok := parseVersePath(w, r, true)
+	if !ok {
+		return
+	}
+
+	resp, err := h.srvc.DeleteVerse(r.Context(), songId, number)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Переместить куплет
+// @Description Переносит куплет на позицию to, куплеты между старой и новой позицией сдвигаются
+// @Tags verses
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Param n path int true "Номер куплета"
+// @Param request body dto.MoveVerseRequest true "Новая позиция"
+// @Success 200 {object} dto.VerseResponse "Куплет перемещён"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня или куплет не найдены"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/verses/{n}/move [post]
+func (h *Handler) MoveVerseHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	songId, number, ok := parseVersePath(w, r, true)
+	if !ok {
+		return
+	}
+
+	var req dto.MoveVerseRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeMalformed(w, r, err.Error())
+		return
+	}
+
+	err = h.validator.Struct(req)
+	if err != nil {
+		writeValidation(w, r, err)
+		return
+	}
+
+	resp, err := h.srvc.MoveVerse(r.Context(), songId, number, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Пересобрать куплеты песни
+// @Description Заново разбивает текст песни на куплеты и заменяет ими сохранённые
+// @Tags verses
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID песни" format(uuid)
+// @Success 200 {object} dto.RebuildVersesResponse "Куплеты пересобраны"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 404 {object} dto.Problem "Песня не найдена"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/song/{id}/verses/rebuild [post]
+func (h *Handler) RebuildVersesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	songId, _, ok := parseVersePath(w, r, false)
+	if !ok {
+		return
+	}
+
+	resp, err := h.srvc.RebuildVerses(r.Context(), songId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// @Summary Пересобрать куплеты всех песен
+// @Description Административная операция: заново разбивает на куплеты тексты всех песен библиотеки. Ход пересборки отдаётся потоком NDJSON: после каждой пачки песен строка {"processed": N, "failed": M} с накопленными счётчиками, последняя строка {"summary": {...}} с id песен, которые пересобрать не удалось. Если обход прерван (клиент отключился или база недоступна), в summary заполнено поле error. Разрыв соединения останавливает пересборку перед следующей песней
+// @Tags admin
+// @Security BearerAuth
+// @Produce application/x-ndjson
+// @Success 200 {object} dto.RebuildProgressLine "Поток строк хода пересборки, последняя строка - dto.RebuildSummaryLine"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Router /api/admin/verses/rebuild [post]
+func (h *Handler) RebuildAllVersesHandler(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", ndjsonMediaType)
+	w.WriteHeader(http.StatusOK)
+	rc.Flush()
+
+	encoder := json.NewEncoder(w)
+	summary, _ := h.srvc.RebuildAllVerses(r.Context(), func(progress dto.RebuildProgressLine) {
+		encoder.Encode(progress)
+		rc.Flush()
+	})
+	encoder.Encode(dto.RebuildSummaryLine{Summary: summary})
+}
+
+// @Summary Предпросмотр разбиения на куплеты
+// @Description Показывает, как текст будет разбит на куплеты, ничего не сохраняя. Стратегии: blank_line, section_headers, fixed_lines:N. Без strategy используется глобальная из VERSE_SPLIT_STRATEGY
+// @Tags verses
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body dto.PreviewVersesRequest true "Текст и стратегия"
+// @Success 200 {object} dto.PreviewVersesResponse "Куплеты"
+// @Failure 400 {object} dto.Problem "Некорректный запрос"
+// @Failure 422 {object} dto.Problem "Ошибка валидации"
+// @Failure 429 {object} dto.Problem "Превышен лимит запросов"
+// @Failure 500 {object} dto.Problem "Внутренняя ошибка"
+// @Router /api/verses/preview [post]
+func (h *Handler) PreviewVersesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req dto.PreviewVersesRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeMalformed(w, r, err.Error())
+		return
+	}
+
+	err = h.validator.Struct(req)
+	if err != nil {
+		writeValidation(w, r, err)
+		return
+	}
+
+	resp, err := h.srvc.PreviewVerses(req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
+	"net/http"
+	"time"
+)
+
+const (
+	RequestIdHeader = "X-Request-ID"
+
+	// maxRequestIdLen - длиннее клиентский X-Request-ID не принимается,
+	// чтобы не раздувать логи
+	maxRequestIdLen = 128
+)
+
+// statusRecorder запоминает статус и размер ответа для журнала доступа.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(data []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(data)
+	rec.bytes += n
+	return n, err
+}
+
+// responseStatus - статус ответа для журнала и метрик. recovered - значение
+// паники обработчика. При панике net/http обрывает соединение, поэтому
+// запрос учитывается как 500, кроме http.ErrAbortHandler после отправки
+// заголовков: так обработчик намеренно обрывает уже начатый ответ.
+func (rec *statusRecorder) responseStatus(recovered any) int {
+	switch {
+	case recovered == http.ErrAbortHandler && rec.status != 0:
+		return rec.status
+	case recovered != nil:
+		return http.StatusInternalServerError
+	case rec.status == 0:
+		return http.StatusOK
+	}
+	return rec.status
+}
+
+// Unwrap нужен http.ResponseController: потоковые ответы импорта
+// сбрасываются клиенту через Flush исходного ResponseWriter.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// AccessLogMiddleware присваивает запросу X-Request-ID (принимает присланный
+// клиентом или генерирует новый), кладёт данные запроса в контекст, чтобы
+// они попадали во все записи лога, и пишет одну строку журнала доступа со
+// статусом, размером ответа и временем обработки, в том числе для
+// запросов, обработчик которых запаниковал.
+func AccessLogMiddleware(next http.Handler, log *logger.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestId := r.Header.Get(RequestIdHeader)
+		if !validRequestId(requestId) {
+			requestId = uuid.New().String()
+		}
+		w.Header().Set(RequestIdHeader, requestId)
+
+		info := &logger.RequestInfo{
+			RequestId: requestId,
+			Method:    r.Method,
+		}
+		ctx := logger.WithRequest(r.Context(), info)
+
+		rec := &statusRecorder{ResponseWriter: w}
+
+		// Запись делается и при панике обработчика, после чего паника
+		// передаётся дальше в net/http
+		defer func() {
+			recovered := recover()
+			attrs := []any{
+				"path", r.URL.Path,
+				"status", rec.responseStatus(recovered),
+				"bytes", rec.bytes,
+				"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+				"remote_addr", r.RemoteAddr,
+			}
+
+			if recovered == nil {
+				log.Info.InfoContext(ctx, "request completed", attrs...)
+				return
+			}
+			if recovered != http.ErrAbortHandler {
+				attrs = append(attrs, "panic", fmt.Sprint(recovered))
+			}
+			log.Info.ErrorContext(ctx, "request aborted", attrs...)
+			panic(recovered)
+		}()
+
+		next.ServeHTTP(rec, r.WithContext(ctx))
+	})
+}
+
+// WithRoute записывает шаблон маршрута в данные запроса для логов: по
+// шаблону, в отличие от пути с id, записи удобно группировать.
+func WithRoute(pattern string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if info, ok := logger.RequestFromContext(r.Context()); ok {
+			info.Route = pattern
+		}
+		next(w, r)
+	}
+}
+
+// validRequestId пропускает только короткие идентификаторы из печатных
+// символов без пробелов: значение попадает в логи и заголовок ответа.
+func validRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLen {
+		return false
+	}
+	for _, c := range []byte(id) {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,17 +1,32 @@
 package middleware
 
-import "net/http"
+import (
+	"net/http"
+	"slices"
+)
+
+// CommonHeadersMiddleware выставляет общие заголовки и CORS. Браузерам
+// разрешены только источники из allowedOrigins; "*" в списке разрешает любой
+// источник. Пустой список - CORS выключен, API доступен только не из браузера
+// или с того же источника.
+func CommonHeadersMiddleware(next http.Handler, allowedOrigins []string) http.Handler {
+	allowAny := slices.Contains(allowedOrigins, "*")
 
-func CommonHeadersMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
 		w.Header().Set("Content-Type", "application/json")
 
 		w.Header().Set("X-Content-Type-Options", "nosniff")
 
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		origin := r.Header.Get("Origin")
+		if origin != "" && (allowAny || slices.Contains(allowedOrigins, origin)) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, If-None-Match, Idempotency-Key, X-Request-ID")
+			w.Header().Set("Access-Control-Expose-Headers", "ETag, Idempotent-Replayed, WWW-Authenticate, X-Request-ID, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset")
+		}
+		// Ответ зависит от Origin, кешам нельзя отдавать его другому источнику
+		w.Header().Add("Vary", "Origin")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
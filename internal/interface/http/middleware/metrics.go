@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/metrics"
+	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
+	"net/http"
+	"time"
+)
+
+// MetricsMiddleware считает запросы и время их обработки по шаблону
+// маршрута и статусу. Шаблон маршрута берётся из данных запроса, поэтому
+// middleware ставится внутри AccessLogMiddleware.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		rec := &statusRecorder{ResponseWriter: w}
+
+		// Запрос учитывается и при панике обработчика, после чего паника
+		// передаётся дальше
+		defer func() {
+			recovered := recover()
+
+			var route string
+			if info, ok := logger.RequestFromContext(r.Context()); ok {
+				route = info.Route
+			}
+			metrics.ObserveHTTPRequest(r.Method, route, rec.responseStatus(recovered), time.Since(start))
+
+			if recovered != nil {
+				panic(recovered)
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+	})
+}
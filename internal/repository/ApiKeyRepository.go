@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"time"
+)
+
+type ApiKeyRepository interface {
+	CreateKey(ctx context.Context, key models.ApiKey, keyHash string) (models.ApiKey, error)
+	GetKeyByHash(ctx context.Context, keyHash string) (models.ApiKey, error)
+	ListKeys(ctx context.Context) ([]models.ApiKey, error)
+	RotateKey(ctx context.Context, keyId uuid.UUID, prefix, keyHash string) (models.ApiKey, error)
+	RevokeKey(ctx context.Context, keyId uuid.UUID) (models.ApiKey, error)
+	TouchKey(ctx context.Context, keyId uuid.UUID, precision time.Duration) error
+}
@@ -1,8 +1,20 @@
 package repository
 
-import "github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+)
 
 type GroupRepository interface {
-	GetGroupByName(name string) (models.Group, error) //todo заменить на Реквест с фильтрами
-	CreateGroup(group models.Group) error
+	GetGroupByName(ctx context.Context, name string) (models.Group, error) //todo заменить на Реквест с фильтрами
+	GetGroupById(ctx context.Context, groupId uuid.UUID) (models.Group, error)
+	CreateGroup(ctx context.Context, group models.Group) (models.Group, error)
+	ListGroups(ctx context.Context, page, limit int) ([]dto.GroupSummary, int, error)
+	RenameGroup(ctx context.Context, groupId uuid.UUID, name string) error
+	DeleteGroup(ctx context.Context, groupId uuid.UUID, cascade bool) error
+	ResolveGroup(ctx context.Context, name string) (models.Group, error)
+	GetGroupAliases(ctx context.Context, groupId uuid.UUID) ([]string, error)
+	MergeGroups(ctx context.Context, targetId uuid.UUID, sourceIds []uuid.UUID) (models.Group, []string, int64, error)
 }
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"time"
+)
+
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (models.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, key string, statusCode int, headers map[string]string, body []byte) error
+	Release(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
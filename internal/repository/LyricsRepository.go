@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+)
+
+type LyricsRepository interface {
+	GetLines(ctx context.Context, songId uuid.UUID) ([]models.LyricLine, error)
+	GetLinesAt(ctx context.Context, songId uuid.UUID, timeMs int64, next int) (*models.LyricLine, []models.LyricLine, error)
+	ReplaceLines(ctx context.Context, songId uuid.UUID, version int, lines []models.LyricLine) error
+}
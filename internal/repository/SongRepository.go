@@ -5,16 +5,24 @@ import (
 	"github.com/google/uuid"
 	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
 	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"time"
 )
 
 type SongRepository interface {
 	CreateSong(ctx context.Context, song models.Song) error
-	UpdateSong(ctx context.Context, song models.Song) error
-	DeleteSong(ctx context.Context, songId uuid.UUID) error
+	UpdateSong(ctx context.Context, song models.Song, verses []models.Verse, lines []models.LyricLine) error
+	DeleteSong(ctx context.Context, songId uuid.UUID, version int) error
 	GetSongById(ctx context.Context, songId uuid.UUID) (models.Song, error)
 	GetSongExsistsById(ctx context.Context, songId uuid.UUID) (bool, error) // можно было сделать проверку через sqlNoRows но я чет подзабил
 	GetSongTextById(ctx context.Context, songId uuid.UUID) (string, error)
 	GetSong(ctx context.Context, song models.Song) (models.Song, error)
 	SongExistsByDetails(ctx context.Context, song models.Song) (bool, error)
-	GetSongsWithFilter(ctx context.Context, request dto.FilteredRequest) ([]models.Song, error)
+	GetSongIdsAfter(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error)
+	GetSongsWithFilter(ctx context.Context, request dto.FilteredRequest) ([]models.Song, string, error)
+	ListTrash(ctx context.Context, page, limit int) ([]dto.TrashedSong, int, error)
+	RestoreSong(ctx context.Context, songId uuid.UUID) (models.Song, error)
+	PurgeTrash(ctx context.Context, retention time.Duration) (int64, error)
+	ListRevisions(ctx context.Context, songId uuid.UUID, page, limit int) ([]models.SongRevision, int, error)
+	GetRevision(ctx context.Context, songId uuid.UUID, revision int) (models.SongRevision, error)
+	ExportLibrary(ctx context.Context, request dto.ExportRequest, onlyMatching bool, emitGroup func(models.Group) error, emitSong func(models.Song, []models.Verse) error) error
 }
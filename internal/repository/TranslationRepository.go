@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+)
+
+type TranslationRepository interface {
+	ListTranslations(ctx context.Context, songId uuid.UUID) ([]dto.TranslationSummary, error)
+	GetLangs(ctx context.Context, songId uuid.UUID) ([]string, error)
+	GetTranslation(ctx context.Context, songId uuid.UUID, lang string) (models.SongTranslation, error)
+	GetVerseTranslations(ctx context.Context, songId uuid.UUID, lang string, numbers []int) ([]models.VerseTranslation, error)
+	SaveTranslation(ctx context.Context, translation models.SongTranslation, verses []string) (models.SongTranslation, error)
+	DeleteTranslation(ctx context.Context, songId uuid.UUID, lang string) error
+}
@@ -2,10 +2,17 @@ package repository
 
 import (
 	"context"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
 	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
 )
 
 type VersesRepository interface {
-	AddVerses(ctx context.Context, req dto.AddVersesRequest) error
+	AddVerses(ctx context.Context, songId uuid.UUID, build models.VersesBuilder) (int, error)
 	GetPaginatedVerses(ctx context.Context, request dto.PaginatedVersesRequest) (dto.PaginatedVersesResponse, error)
+	GetVerse(ctx context.Context, songId uuid.UUID, number int) (models.Verse, error)
+	UpdateVerse(ctx context.Context, songId uuid.UUID, number int, text, sectionType string, realign models.LyricsRealigner) (models.Verse, error)
+	InsertVerse(ctx context.Context, songId uuid.UUID, position int, text, sectionType string, realign models.LyricsRealigner) (models.Verse, error)
+	DeleteVerse(ctx context.Context, songId uuid.UUID, number int, realign models.LyricsRealigner) error
+	MoveVerse(ctx context.Context, songId uuid.UUID, from, to int, realign models.LyricsRealigner) (models.Verse, error)
 }
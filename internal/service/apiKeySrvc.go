@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/postgres/repository"
+	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
+	"slices"
+	"time"
+)
+
+const (
+	// apiKeyTokenPrefix отличает ключи этого сервиса в логах и сканерах секретов
+	apiKeyTokenPrefix = "mlk_"
+	// apiKeyShownPrefix - сколько первых символов ключа хранится открыто
+	apiKeyShownPrefix = 12
+	// apiKeyUsagePrecision - с какой точностью обновляется last_used_at
+	apiKeyUsagePrecision = time.Minute
+
+	// BootstrapKeyName - имя ключа из ADMIN_API_KEY, он не хранится в базе
+	BootstrapKeyName = "bootstrap"
+)
+
+var ErrUnauthorized = apperr.New(apperr.Unauthorized, "invalid_token", "api key is invalid or revoked")
+
+type ApiKeySrvc struct {
+	Repo   *repository.ApiKeyRepository
+	Logger *logger.Logger
+	// bootstrapHash - хеш ключа из конфигурации, которым выпускают первые
+	// ключи. Пусто - такого ключа нет
+	bootstrapHash string
+}
+
+func NewApiKeySrvc(repo *repository.ApiKeyRepository, bootstrapKey string, logger *logger.Logger) *ApiKeySrvc {
+	srvc := &ApiKeySrvc{
+		Repo:   repo,
+		Logger: logger,
+	}
+	if bootstrapKey != "" {
+		srvc.bootstrapHash = hashApiKey(bootstrapKey)
+	}
+	return srvc
+}
+
+// Authenticate находит действующий ключ по секрету из запроса и отмечает
+// его использование.
+func (s *ApiKeySrvc) Authenticate(ctx context.Context, token string) (models.ApiKey, error) {
+	if token == "" {
+		return models.ApiKey{}, ErrUnauthorized
+	}
+
+	keyHash := hashApiKey(token)
+	if s.bootstrapHash != "" && subtle.ConstantTimeCompare([]byte(keyHash), []byte(s.bootstrapHash)) == 1 {
+		return models.ApiKey{Name: BootstrapKeyName, Scopes: []string{models.ScopeAdmin}}, nil
+	}
+
+	key, err := s.Repo.GetKeyByHash(ctx, keyHash)
+	if err != nil {
+		if errors.Is(err, repository.ErrApiKeyNotFound) {
+			return models.ApiKey{}, ErrUnauthorized
+		}
+		return models.ApiKey{}, err
+	}
+
+	// Не удалось записать время использования - не повод отказывать в запросе
+	_ = s.Repo.TouchKey(ctx, key.Id, apiKeyUsagePrecision)
+
+	return key, nil
+}
+
+func (s *ApiKeySrvc) IssueKey(ctx context.Context, request dto.IssueApiKeyRequest) (dto.ApiKeyResponse, error) {
+	var resp dto.ApiKeyResponse
+
+	token, err := newApiKeyToken()
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to generate api key",
+			"error", err)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	scopes := slices.Clone(request.Scopes)
+	slices.Sort(scopes)
+
+	key := models.ApiKey{
+		Id:     uuid.New(),
+		Name:   request.Name,
+		Prefix: token[:apiKeyShownPrefix],
+		Scopes: slices.Compact(scopes),
+	}
+	key, err = s.Repo.CreateKey(ctx, key, hashApiKey(token))
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Key = key
+	resp.Token = token
+	resp.Message = "Api key issued, store the token: it won't be shown again"
+	return resp, nil
+}
+
+func (s *ApiKeySrvc) ListKeys(ctx context.Context) (dto.ApiKeysResponse, error) {
+	var resp dto.ApiKeysResponse
+
+	keys, err := s.Repo.ListKeys(ctx)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Keys = keys
+	return resp, nil
+}
+
+// RotateKey выпускает новый секрет для ключа, старый перестаёт работать сразу.
+func (s *ApiKeySrvc) RotateKey(ctx context.Context, keyId uuid.UUID) (dto.ApiKeyResponse, error) {
+	var resp dto.ApiKeyResponse
+
+	token, err := newApiKeyToken()
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to generate api key",
+			"error", err)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	key, err := s.Repo.RotateKey(ctx, keyId, token[:apiKeyShownPrefix], hashApiKey(token))
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Key = key
+	resp.Token = token
+	resp.Message = "Api key rotated, store the token: it won't be shown again"
+	return resp, nil
+}
+
+func (s *ApiKeySrvc) RevokeKey(ctx context.Context, keyId uuid.UUID) (dto.ApiKeyResponse, error) {
+	var resp dto.ApiKeyResponse
+
+	key, err := s.Repo.RevokeKey(ctx, keyId)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Key = key
+	resp.Message = "Api key revoked"
+	return resp, nil
+}
+
+// newApiKeyToken генерирует секрет ключа: 32 случайных байта в base64url.
+func newApiKeyToken() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return apiKeyTokenPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// hashApiKey - SHA-256 ключа. У случайного 256-битного секрета перебор
+// невозможен, поэтому медленный хеш вроде bcrypt не нужен и поиск по хешу
+// остаётся одним индексным запросом.
+func hashApiKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,58 @@
+package diff
+
+import (
+	"github.com/wiqwi12/effective-mobile-test/internal/service/lcs"
+	"github.com/wiqwi12/effective-mobile-test/internal/service/splitter"
+	"strings"
+)
+
+// Op - что произошло со строкой при переходе от старого текста к новому.
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// Line - строка диффа. From и To - номера строки в старом и новом тексте
+// начиная с 1, ноль - строки в этом тексте нет.
+type Line struct {
+	Op   Op
+	Text string
+	From int
+	To   int
+}
+
+// SplitLines разбивает текст на строки так же, как разбиватель куплетов:
+// переводы строк нормализуются, пробелы в конце строк не учитываются.
+func SplitLines(text string) []string {
+	text = splitter.Normalize(text)
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// Lines строит построчный дифф по наибольшей общей подпоследовательности.
+// Если тексты слишком велики для таблицы LCS (см. lcs.MaxCells), отличающаяся
+// середина выводится как "удалить всё, вставить всё".
+func Lines(from, to []string) []Line {
+	result := make([]Line, 0, len(from)+len(to))
+
+	i, j := 0, 0
+	for _, pair := range append(lcs.Match(from, to), [2]int{len(from), len(to)}) {
+		for ; i < pair[0]; i++ {
+			result = append(result, Line{Op: Delete, Text: from[i], From: i + 1})
+		}
+		for ; j < pair[1]; j++ {
+			result = append(result, Line{Op: Insert, Text: to[j], To: j + 1})
+		}
+		if i < len(from) && j < len(to) {
+			result = append(result, Line{Op: Equal, Text: from[i], From: i + 1, To: j + 1})
+			i++
+			j++
+		}
+	}
+	return result
+}
@@ -0,0 +1,185 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSplitLines(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "пустой текст", text: " \n ", want: nil},
+		{name: "экранированные переводы строк", text: `a\nb`, want: []string{"a", "b"}},
+		{name: "пробелы в конце строк", text: "a  \r\nb\t", want: []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitLines(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitLines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		from []string
+		to   []string
+		want []Line
+	}{
+		{
+			name: "тексты совпадают",
+			from: []string{"a", "b"},
+			to:   []string{"a", "b"},
+			want: []Line{
+				{Op: Equal, Text: "a", From: 1, To: 1},
+				{Op: Equal, Text: "b", From: 2, To: 2},
+			},
+		},
+		{
+			name: "строка изменена",
+			from: []string{"a", "b", "c"},
+			to:   []string{"a", "B", "c"},
+			want: []Line{
+				{Op: Equal, Text: "a", From: 1, To: 1},
+				{Op: Delete, Text: "b", From: 2},
+				{Op: Insert, Text: "B", To: 2},
+				{Op: Equal, Text: "c", From: 3, To: 3},
+			},
+		},
+		{
+			name: "вставка сдвигает номера строк",
+			from: []string{"a", "c"},
+			to:   []string{"a", "b", "c"},
+			want: []Line{
+				{Op: Equal, Text: "a", From: 1, To: 1},
+				{Op: Insert, Text: "b", To: 2},
+				{Op: Equal, Text: "c", From: 2, To: 3},
+			},
+		},
+		{
+			name: "общая строка в середине",
+			from: []string{"x", "a", "y"},
+			to:   []string{"z", "a", "w"},
+			want: []Line{
+				{Op: Delete, Text: "x", From: 1},
+				{Op: Insert, Text: "z", To: 1},
+				{Op: Equal, Text: "a", From: 2, To: 2},
+				{Op: Delete, Text: "y", From: 3},
+				{Op: Insert, Text: "w", To: 3},
+			},
+		},
+		{
+			name: "из пустого текста",
+			from: nil,
+			to:   []string{"a"},
+			want: []Line{{Op: Insert, Text: "a", To: 1}},
+		},
+		{
+			name: "в пустой текст",
+			from: []string{"a"},
+			to:   nil,
+			want: []Line{{Op: Delete, Text: "a", From: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Lines(tt.from, tt.to)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lines() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// replay восстанавливает оба текста по диффу и проверяет номера строк.
+func replay(t *testing.T, lines []Line) (from, to []string) {
+	t.Helper()
+	for _, line := range lines {
+		if line.Op != Insert {
+			from = append(from, line.Text)
+			if line.From != len(from) {
+				t.Fatalf("%+v: From = %d, want %d", line, line.From, len(from))
+			}
+		}
+		if line.Op != Delete {
+			to = append(to, line.Text)
+			if line.To != len(to) {
+				t.Fatalf("%+v: To = %d, want %d", line, line.To, len(to))
+			}
+		}
+	}
+	return from, to
+}
+
+func TestLinesReplay(t *testing.T) {
+	from := []string{"intro", "a", "b", "chorus", "c", "chorus", "outro"}
+	to := []string{"intro", "b", "chorus", "new", "c", "a", "chorus", "outro", "end"}
+
+	lines := Lines(from, to)
+	gotFrom, gotTo := replay(t, lines)
+	if !reflect.DeepEqual(gotFrom, from) || !reflect.DeepEqual(gotTo, to) {
+		t.Fatalf("replay() = %q, %q, want %q, %q", gotFrom, gotTo, from, to)
+	}
+
+	equal := 0
+	for _, line := range lines {
+		if line.Op == Equal {
+			equal++
+		}
+	}
+	// Наибольшая общая подпоследовательность: intro, b, chorus, c, chorus, outro
+	if equal != 6 {
+		t.Errorf("equal lines = %d, want 6", equal)
+	}
+}
+
+func TestLinesOverCellLimit(t *testing.T) {
+	// Общие начало и конец отрезаются до проверки лимита, а середина
+	// (2001+1)*(2001+1) клеток в таблицу не помещается
+	const n = 2001
+	from := []string{"head"}
+	to := []string{"head"}
+	for i := 0; i < n; i++ {
+		from = append(from, fmt.Sprintf("old %d", i))
+		to = append(to, fmt.Sprintf("new %d", i))
+	}
+	from = append(from, "shared", "tail")
+	to = append(to, "tail")
+
+	lines := Lines(from, to)
+	gotFrom, gotTo := replay(t, lines)
+	if !reflect.DeepEqual(gotFrom, from) || !reflect.DeepEqual(gotTo, to) {
+		t.Fatal("replay() does not restore the texts")
+	}
+
+	want := []Op{Equal}
+	for i := 0; i < n+1; i++ {
+		want = append(want, Delete)
+	}
+	for i := 0; i < n; i++ {
+		want = append(want, Insert)
+	}
+	want = append(want, Equal)
+
+	ops := make([]Op, len(lines))
+	for i, line := range lines {
+		ops[i] = line.Op
+	}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("ops over the cell limit: got %d lines, want delete all then insert all", len(ops))
+	}
+	if last := lines[len(lines)-1]; last.From != n+3 || last.To != n+2 {
+		t.Errorf("tail line = %+v, want From %d To %d", last, n+3, n+2)
+	}
+}
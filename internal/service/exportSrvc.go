@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"github.com/wiqwi12/effective-mobile-test/pkg"
+)
+
+// ExportSink получает записи экспорта по мере чтения из базы: сначала
+// группы, затем песни.
+type ExportSink interface {
+	Group(group models.Group) error
+	Song(song models.Song, verses []models.Verse) error
+}
+
+// ExportLibrary выгружает библиотеку в sink. Без фильтров выгружаются все
+// группы, с фильтрами - только группы найденных песен. Группы и песни
+// читаются из одного снимка базы.
+func (s *SongSrvc) ExportLibrary(ctx context.Context, request dto.ExportRequest, sink ExportSink) error {
+	err := s.SongRepo.ExportLibrary(ctx, request, !pkg.IsEmpty(request.Filter), sink.Group, sink.Song)
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to export library",
+			"error", err)
+		return err
+	}
+
+	return nil
+}
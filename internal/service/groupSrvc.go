@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/postgres/repository"
+	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
+)
+
+const (
+	DefaultGroupsLimit = 20
+	MaxGroupsLimit     = 100
+)
+
+var (
+	ErrGroupExists  = repository.ErrGroupExists
+	ErrInvalidMerge = apperr.New(apperr.Validation, "invalid_merge", "invalid merge request")
+)
+
+type GroupSrvc struct {
+	GroupRepo *repository.GroupRepository
+	SongRepo  *repository.SongRepository
+	Logger    *logger.Logger
+}
+
+func NewGroupSrvc(groupRepo *repository.GroupRepository, songRepo *repository.SongRepository, logger *logger.Logger) *GroupSrvc {
+	return &GroupSrvc{
+		GroupRepo: groupRepo,
+		SongRepo:  songRepo,
+		Logger:    logger,
+	}
+}
+
+func (s *GroupSrvc) CreateGroup(ctx context.Context, request dto.CreateGroupRequest) (dto.GroupResponse, error) {
+	var resp dto.GroupResponse
+
+	exists, err := s.nameTaken(ctx, request.Name, uuid.Nil)
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to check if group exists",
+			"error", err,
+			"group_name", request.Name)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+	if exists {
+		resp.Message = "group already exists"
+		resp.Error = ErrGroupExists.Error()
+		return resp, ErrGroupExists
+	}
+
+	group := models.Group{
+		Id:   uuid.New(),
+		Name: request.Name,
+	}
+	group, err = s.GroupRepo.CreateGroup(ctx, group)
+	if errors.Is(err, ErrGroupExists) {
+		resp.Message = "group already exists"
+		resp.Error = err.Error()
+		return resp, err
+	}
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to create group",
+			"error", err,
+			"group_name", request.Name)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Group = group
+	resp.Message = "Group succsessfully created"
+	return resp, nil
+}
+
+func (s *GroupSrvc) ListGroups(ctx context.Context, request dto.GroupsListRequest) (dto.GroupsResponse, error) {
+	var resp dto.GroupsResponse
+
+	if request.Limit > MaxGroupsLimit {
+		request.Limit = MaxGroupsLimit
+	}
+
+	groups, total, err := s.GroupRepo.ListGroups(ctx, request.Page, request.Limit)
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to list groups",
+			"error", err)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Groups = groups
+	resp.Page = request.Page
+	resp.Limit = request.Limit
+	resp.Total = total
+	return resp, nil
+}
+
+func (s *GroupSrvc) GetGroup(ctx context.Context, groupId uuid.UUID) (dto.GroupDetailResponse, error) {
+	var resp dto.GroupDetailResponse
+
+	group, err := s.GroupRepo.GetGroupById(ctx, groupId)
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to get group by ID",
+			"error", err,
+			"group_id", groupId)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	aliases, err := s.GroupRepo.GetGroupAliases(ctx, groupId)
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to get group aliases",
+			"error", err,
+			"group_id", groupId)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	songs, err := s.SongRepo.GetSongsByGroupId(ctx, groupId)
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to get group songs",
+			"error", err,
+			"group_id", groupId)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Group = group
+	resp.Aliases = aliases
+	resp.SongCount = len(songs)
+	resp.Discography = make([]dto.DiscographyRecord, 0, len(songs))
+	for _, song := range songs {
+		resp.Discography = append(resp.Discography, dto.DiscographyRecord{
+			SongId:      song.Id,
+			Title:       song.Title,
+			ReleaseDate: song.ReleaseDate,
+			Link:        song.Link,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *GroupSrvc) RenameGroup(ctx context.Context, groupId uuid.UUID, request dto.RenameGroupRequest) (dto.GroupResponse, error) {
+	var resp dto.GroupResponse
+
+	group, err := s.GroupRepo.GetGroupById(ctx, groupId)
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to get group by ID",
+			"error", err,
+			"group_id", groupId)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	if group.Name == request.Name {
+		resp.Group = group
+		resp.Message = "Group succsessfully renamed"
+		return resp, nil
+	}
+
+	exists, err := s.nameTaken(ctx, request.Name, groupId)
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to check if group exists",
+			"error", err,
+			"group_name", request.Name)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+	if exists {
+		resp.Message = "group with this name already exists"
+		resp.Error = ErrGroupExists.Error()
+		return resp, ErrGroupExists
+	}
+
+	err = s.GroupRepo.RenameGroup(ctx, groupId, request.Name)
+	if errors.Is(err, ErrGroupExists) {
+		resp.Message = "group with this name already exists"
+		resp.Error = err.Error()
+		return resp, err
+	}
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to rename group",
+			"error", err,
+			"group_id", groupId)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	group.Name = request.Name
+	resp.Group = group
+	resp.Message = "Group succsessfully renamed"
+	return resp, nil
+}
+
+func (s *GroupSrvc) DeleteGroup(ctx context.Context, groupId uuid.UUID, cascade bool) (dto.GroupResponse, error) {
+	var resp dto.GroupResponse
+
+	err := s.GroupRepo.DeleteGroup(ctx, groupId, cascade)
+	if err != nil {
+		if errors.Is(err, repository.ErrGroupHasSongs) {
+			resp.Message = "group still has songs, use cascade=true to move them to the trash"
+		} else if errors.Is(err, repository.ErrGroupHasTrashedSongs) {
+			resp.Message = "group has songs in the trash, use cascade=true to detach them"
+		} else {
+			s.Logger.Info.ErrorContext(ctx, "Failed to delete group",
+				"error", err,
+				"group_id", groupId)
+			resp.Message = "some error occured"
+		}
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Message = "Group succsessfully deleted"
+	return resp, nil
+}
+
+// MergeGroups объединяет дубликаты в одну группу. Названия исходных групп
+// становятся алиасами, поэтому CreateSong с ними попадёт в целевую группу.
+func (s *GroupSrvc) MergeGroups(ctx context.Context, targetId uuid.UUID, request dto.MergeGroupsRequest) (dto.MergeGroupsResponse, error) {
+	var resp dto.MergeGroupsResponse
+
+	seen := make(map[uuid.UUID]bool)
+	for _, sourceId := range request.SourceIds {
+		if sourceId == targetId {
+			resp.Message = "group cannot be merged into itself"
+			resp.Error = ErrInvalidMerge.Error()
+			return resp, ErrInvalidMerge
+		}
+		if seen[sourceId] {
+			resp.Message = "source_ids must be unique"
+			resp.Error = ErrInvalidMerge.Error()
+			return resp, ErrInvalidMerge
+		}
+		seen[sourceId] = true
+	}
+
+	group, aliases, moved, err := s.GroupRepo.MergeGroups(ctx, targetId, request.SourceIds)
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to merge groups",
+			"error", err,
+			"group_id", targetId)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	s.Logger.Info.InfoContext(ctx, "Groups merged",
+		"group_id", targetId,
+		"aliases", aliases,
+		"moved_songs", moved)
+
+	resp.Group = group
+	resp.Aliases = aliases
+	resp.MovedSongs = moved
+	resp.Message = "Groups succsessfully merged"
+	return resp, nil
+}
+
+// nameTaken учитывает и названия групп, и алиасы, чтобы новое название
+// не перехватывало песни, которые уже резолвятся в другую группу.
+func (s *GroupSrvc) nameTaken(ctx context.Context, name string, groupId uuid.UUID) (bool, error) {
+	group, err := s.GroupRepo.ResolveGroup(ctx, name)
+	if err == nil {
+		return group.Id != groupId, nil
+	}
+	if errors.Is(err, repository.ErrGroupNotFound) {
+		return false, nil
+	}
+	return false, err
+}
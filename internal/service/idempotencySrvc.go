@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/postgres/repository"
+	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
+	"time"
+)
+
+const (
+	DefaultIdempotencyTTL = 24 * time.Hour
+	MaxIdempotencyKeyLen  = 255
+	// IdempotencyLease - через сколько незавершённый ключ можно занять
+	// заново. Запросы с ключом выполняются намного быстрее, так что ключ,
+	// занятый дольше, остался от упавшего процесса.
+	IdempotencyLease = 5 * time.Minute
+)
+
+var (
+	ErrInvalidIdempotencyKey    = apperr.New(apperr.Validation, "invalid_idempotency_key", "idempotency key must be 1-255 characters long")
+	ErrIdempotencyKeyReused     = apperr.New(apperr.Validation, "idempotency_key_reused", "idempotency key was already used with a different request")
+	ErrIdempotencyKeyInProgress = apperr.New(apperr.Conflict, "idempotency_key_in_progress", "request with this idempotency key is still in progress")
+)
+
+type IdempotencySrvc struct {
+	Repo   *repository.IdempotencyRepository
+	TTL    time.Duration
+	Logger *logger.Logger
+}
+
+func NewIdempotencySrvc(repo *repository.IdempotencyRepository, ttl time.Duration, logger *logger.Logger) *IdempotencySrvc {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return &IdempotencySrvc{
+		Repo:   repo,
+		TTL:    ttl,
+		Logger: logger,
+	}
+}
+
+// Begin занимает ключ клиента owner за запросом. Если запрос с этим ключом
+// уже выполнен, возвращается сохранённый ответ для повтора; nil означает,
+// что запрос нужно выполнить и затем вызвать Complete или Release.
+func (s *IdempotencySrvc) Begin(ctx context.Context, owner, key, requestHash string) (*models.IdempotencyRecord, error) {
+	if key == "" || len(key) > MaxIdempotencyKeyLen {
+		return nil, ErrInvalidIdempotencyKey
+	}
+
+	record, reserved, err := s.Repo.Reserve(ctx, owner, key, requestHash, s.TTL, min(IdempotencyLease, s.TTL))
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	if record.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if !record.Completed() {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	return &record, nil
+}
+
+func (s *IdempotencySrvc) Complete(ctx context.Context, owner, key string, statusCode int, headers map[string]string, body []byte) error {
+	return s.Repo.Complete(ctx, owner, key, statusCode, headers, body)
+}
+
+func (s *IdempotencySrvc) Release(ctx context.Context, owner, key string) error {
+	return s.Repo.Release(ctx, owner, key)
+}
+
+// RunCleanup удаляет истёкшие ключи раз в interval, пока не отменён ctx.
+func (s *IdempotencySrvc) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.Repo.DeleteExpired(ctx)
+			if err != nil {
+				continue
+			}
+			if deleted > 0 {
+				s.Logger.Debug.Info("expired idempotency keys deleted", "count", deleted)
+			}
+		}
+	}
+}
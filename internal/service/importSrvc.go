@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/externalServices"
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/metrics"
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/postgres/repository"
+	"github.com/wiqwi12/effective-mobile-test/internal/service/ratelimit"
+	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultImportWorkers       = 8
+	DefaultMetadataConcurrency = 4
+)
+
+type ImportConfig struct {
+	// Workers - сколько строк одного импорта обрабатывается параллельно
+	Workers int
+	// MetadataConcurrency - сколько запросов к сервису метаданных может
+	// выполняться одновременно во всех импортах
+	MetadataConcurrency int
+}
+
+type ImportSrvc struct {
+	SongSrvc *SongSrvc
+	Config   ImportConfig
+	// Upstream - лимит запросов к сервису метаданных. Импорт расходует его
+	// построчно, как если бы каждая строка была отдельным POST /api/song
+	Upstream *ratelimit.Limiter
+	Logger   *logger.Logger
+
+	metadataSlots chan struct{}
+}
+
+func NewImportSrvc(songSrvc *SongSrvc, config ImportConfig, upstream *ratelimit.Limiter, logger *logger.Logger) *ImportSrvc {
+	if config.Workers <= 0 {
+		config.Workers = DefaultImportWorkers
+	}
+	if config.MetadataConcurrency <= 0 {
+		config.MetadataConcurrency = DefaultMetadataConcurrency
+	}
+	return &ImportSrvc{
+		SongSrvc:      songSrvc,
+		Config:        config,
+		Upstream:      upstream,
+		Logger:        logger,
+		metadataSlots: make(chan struct{}, config.MetadataConcurrency),
+	}
+}
+
+// ImportSongs создаёт песни из rows пулом воркеров и вызывает report для
+// каждой обработанной строки. report не вызывается параллельно. Импорт
+// останавливается, когда закрыт rows или отменён ctx. Запросы к сервису
+// метаданных расходуют лимит client; строки сверх лимита отмечаются
+// rate_limited, и их можно отправить повторно.
+func (s *ImportSrvc) ImportSongs(ctx context.Context, client string, rows <-chan dto.ImportRow, report func(dto.ImportRowResult)) dto.ImportSummary {
+	var summary dto.ImportSummary
+	var reportMu sync.Mutex
+
+	var seenMu sync.Mutex
+	seen := make(map[string]int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.Config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var row dto.ImportRow
+				var ok bool
+				select {
+				case <-ctx.Done():
+					return
+				case row, ok = <-rows:
+					if !ok {
+						return
+					}
+				}
+
+				var result dto.ImportRowResult
+				if row.Error == "" {
+					key := importKey(row.Request)
+					seenMu.Lock()
+					first, duplicate := seen[key]
+					if !duplicate {
+						seen[key] = row.Line
+					}
+					seenMu.Unlock()
+
+					if duplicate {
+						metrics.DuplicateRejected()
+						result = importResult(row, dto.ImportDuplicate)
+						result.Error = fmt.Sprintf("duplicate of line %d", first)
+					} else {
+						result = s.importRow(ctx, client, row)
+					}
+				} else {
+					result = importResult(row, dto.ImportInvalid)
+					result.Error = row.Error
+				}
+
+				reportMu.Lock()
+				summary.Add(result.Status)
+				report(result)
+				reportMu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return summary
+}
+
+func (s *ImportSrvc) importRow(ctx context.Context, client string, row dto.ImportRow) dto.ImportRowResult {
+	limit := s.Upstream.Allow(ctx, client, time.Now())
+	if !limit.Allowed {
+		result := importResult(row, dto.ImportRateLimited)
+		result.Error = fmt.Sprintf("metadata rate limit exceeded, retry in %d seconds", int(math.Ceil(limit.RetryAfter.Seconds())))
+		return result
+	}
+
+	select {
+	case s.metadataSlots <- struct{}{}:
+	case <-ctx.Done():
+		return importError(row, ctx.Err())
+	}
+	details, err := s.SongSrvc.MusicMetadataRepo.GetSongDetails(ctx, row.Request)
+	<-s.metadataSlots
+	if err != nil {
+		return importError(row, err)
+	}
+
+	// Параллельные строки одной новой группы сходятся в одну группу через
+	// уникальный индекс по названию, см. resolveGroup
+	resp, err := s.SongSrvc.storeSong(ctx, row.Request, details)
+	if err != nil {
+		return importError(row, err)
+	}
+
+	result := importResult(row, dto.ImportCreated)
+	result.SongId = &resp.Song.Id
+	return result
+}
+
+func importResult(row dto.ImportRow, status string) dto.ImportRowResult {
+	return dto.ImportRowResult{
+		Line:   row.Line,
+		Group:  row.Request.Group,
+		Title:  row.Request.Title,
+		Status: status,
+	}
+}
+
+// importError переводит ошибку создания песни в статус строки. Подробности
+// внутренних ошибок в отчёт не попадают, как и в ответах API.
+func importError(row dto.ImportRow, err error) dto.ImportRowResult {
+	status := dto.ImportFailed
+	switch {
+	case errors.Is(err, repository.ErrSongExists):
+		status = dto.ImportDuplicate
+	case errors.Is(err, externalServices.ErrMetadataNotFound):
+		status = dto.ImportMetadataMissing
+	case apperr.KindOf(err) == apperr.Validation:
+		status = dto.ImportInvalid
+	}
+
+	result := importResult(row, status)
+	result.Code = apperr.CodeOf(err)
+	switch apperr.KindOf(err) {
+	case apperr.Internal:
+		result.Error = "internal server error"
+	case apperr.Unavailable:
+		result.Error = apperr.MessageOf(err)
+	default:
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// importKey - ключ для поиска повторов внутри одного импорта.
+func importKey(request dto.CreateSongRequest) string {
+	return strings.ToLower(strings.TrimSpace(request.Group)) + "\x00" + strings.ToLower(strings.TrimSpace(request.Title))
+}
@@ -0,0 +1,300 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+
+	// leeway - допустимое расхождение часов с выпустившим токен сервисом
+	leeway = time.Minute
+
+	minHS256SecretLen = 32
+)
+
+var ErrInvalidToken = apperr.New(apperr.Unauthorized, "invalid_token", "invalid bearer token")
+
+// Key - ключ проверки подписи. Для HS256 заполнен Secret, для RS256 - Public.
+// Пустой Kid подходит к токену с любым kid.
+type Key struct {
+	Kid    string
+	Alg    string
+	Secret []byte
+	Public *rsa.PublicKey
+}
+
+// Config - ключи и ожидаемые значения claims. Пустые Issuer и Audience не
+// проверяются, роли читаются из claim RolesClaim (по умолчанию roles).
+type Config struct {
+	Keys       []Key
+	Issuer     string
+	Audience   string
+	RolesClaim string
+}
+
+// Claims - проверенные данные токена, нужные для авторизации.
+type Claims struct {
+	Subject string
+	Roles   []string
+}
+
+type Verifier struct {
+	config Config
+}
+
+func NewVerifier(config Config) (*Verifier, error) {
+	if len(config.Keys) == 0 {
+		return nil, errors.New("no keys to verify tokens")
+	}
+	if config.RolesClaim == "" {
+		config.RolesClaim = "roles"
+	}
+	return &Verifier{config: config}, nil
+}
+
+// Verify проверяет подпись, срок действия, издателя и аудиторию токена.
+// Алгоритм определяется ключом, а не только заголовком токена: HS256-токен
+// не проверяется публичным RSA-ключом как секретом.
+func (v *Verifier) Verify(token string, now time.Time) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+	if header.Alg != AlgHS256 && header.Alg != AlgRS256 {
+		return Claims{}, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	if !v.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], signature) {
+		return Claims{}, fmt.Errorf("%w: signature verification failed", ErrInvalidToken)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &raw); err != nil {
+		return Claims{}, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	return v.checkClaims(raw, now)
+}
+
+func (v *Verifier) verifySignature(alg, kid, signingInput string, signature []byte) bool {
+	digest := sha256.Sum256([]byte(signingInput))
+	for _, key := range v.config.Keys {
+		if key.Alg != alg || (kid != "" && key.Kid != "" && key.Kid != kid) {
+			continue
+		}
+		switch alg {
+		case AlgHS256:
+			mac := hmac.New(sha256.New, key.Secret)
+			mac.Write([]byte(signingInput))
+			if hmac.Equal(mac.Sum(nil), signature) {
+				return true
+			}
+		case AlgRS256:
+			if rsa.VerifyPKCS1v15(key.Public, crypto.SHA256, digest[:], signature) == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (v *Verifier) checkClaims(raw map[string]json.RawMessage, now time.Time) (Claims, error) {
+	var claims Claims
+
+	var exp, nbf float64
+	if err := json.Unmarshal(raw["exp"], &exp); err != nil || exp == 0 {
+		return Claims{}, fmt.Errorf("%w: exp claim is required", ErrInvalidToken)
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(leeway)) {
+		return Claims{}, fmt.Errorf("%w: token is expired", ErrInvalidToken)
+	}
+	if _, ok := raw["nbf"]; ok {
+		if err := json.Unmarshal(raw["nbf"], &nbf); err != nil {
+			return Claims{}, fmt.Errorf("%w: malformed nbf claim", ErrInvalidToken)
+		}
+		if now.Add(leeway).Before(time.Unix(int64(nbf), 0)) {
+			return Claims{}, fmt.Errorf("%w: token is not valid yet", ErrInvalidToken)
+		}
+	}
+
+	if v.config.Issuer != "" {
+		var issuer string
+		json.Unmarshal(raw["iss"], &issuer)
+		if issuer != v.config.Issuer {
+			return Claims{}, fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+		}
+	}
+	if v.config.Audience != "" {
+		audiences := stringList(raw["aud"])
+		found := false
+		for _, audience := range audiences {
+			found = found || audience == v.config.Audience
+		}
+		if !found {
+			return Claims{}, fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+		}
+	}
+
+	json.Unmarshal(raw["sub"], &claims.Subject)
+	if claims.Subject == "" {
+		return Claims{}, fmt.Errorf("%w: sub claim is required", ErrInvalidToken)
+	}
+	claims.Roles = stringList(raw[v.config.RolesClaim])
+
+	return claims, nil
+}
+
+// stringList читает claim, который может быть строкой или массивом строк,
+// как aud в RFC 7519.
+func stringList(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var single string
+	if json.Unmarshal(raw, &single) == nil {
+		return []string{single}
+	}
+	var list []string
+	json.Unmarshal(raw, &list)
+	return list
+}
+
+func decodeSegment(segment string, target any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// HS256Key - ключ для токенов, подписанных общим секретом.
+func HS256Key(secret string) (Key, error) {
+	if len(secret) < minHS256SecretLen {
+		return Key{}, fmt.Errorf("HS256 secret must be at least %d bytes long", minHS256SecretLen)
+	}
+	return Key{Alg: AlgHS256, Secret: []byte(secret)}, nil
+}
+
+// LoadRS256PublicKey читает публичный RSA-ключ в PEM (PKIX или PKCS#1).
+func LoadRS256PublicKey(path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, errors.New("no PEM block found")
+	}
+
+	var public *rsa.PublicKey
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		var parsed any
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+		if err == nil {
+			var ok bool
+			if public, ok = parsed.(*rsa.PublicKey); !ok {
+				err = errors.New("public key is not an RSA key")
+			}
+		}
+	}
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{Alg: AlgRS256, Public: public}, nil
+}
+
+// LoadJWKS читает ключи из локального JWKS-файла (RFC 7517). Ключи RSA
+// используются для RS256, oct - для HS256; ключи для шифрования пропускаются.
+func LoadJWKS(path string) ([]Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			K   string `json:"k"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	var keys []Key
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		switch jwk.Kty {
+		case "RSA":
+			if jwk.Alg != "" && jwk.Alg != AlgRS256 {
+				continue
+			}
+			n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: invalid modulus: %w", jwk.Kid, err)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+			if err != nil || len(e) == 0 || len(e) > 4 {
+				return nil, fmt.Errorf("key %q: invalid exponent", jwk.Kid)
+			}
+			public := &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+			keys = append(keys, Key{Kid: jwk.Kid, Alg: AlgRS256, Public: public})
+		case "oct":
+			if jwk.Alg != "" && jwk.Alg != AlgHS256 {
+				continue
+			}
+			secret, err := base64.RawURLEncoding.DecodeString(jwk.K)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: invalid secret: %w", jwk.Kid, err)
+			}
+			if len(secret) < minHS256SecretLen {
+				return nil, fmt.Errorf("key %q: HS256 secret must be at least %d bytes long", jwk.Kid, minHS256SecretLen)
+			}
+			keys = append(keys, Key{Kid: jwk.Kid, Alg: AlgHS256, Secret: secret})
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys in JWKS")
+	}
+	return keys, nil
+}
@@ -0,0 +1,390 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+var (
+	rsaKeyOnce sync.Once
+	rsaKey     *rsa.PrivateKey
+)
+
+func testRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	rsaKeyOnce.Do(func() {
+		var err error
+		rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			panic(err)
+		}
+	})
+	return rsaKey
+}
+
+func segment(t *testing.T, value any) string {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// signer подписывает signing input токена и возвращает подпись.
+type signer func(t *testing.T, input string) []byte
+
+func hs256(secret []byte) signer {
+	return func(t *testing.T, input string) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(input))
+		return mac.Sum(nil)
+	}
+}
+
+func rs256(t *testing.T, input string) []byte {
+	digest := sha256.Sum256([]byte(input))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, testRSAKey(t), crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+func unsigned(t *testing.T, input string) []byte {
+	return nil
+}
+
+func token(t *testing.T, header, claims map[string]any, sign signer) string {
+	t.Helper()
+	input := segment(t, header) + "." + segment(t, claims)
+	return input + "." + base64.RawURLEncoding.EncodeToString(sign(t, input))
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	publicPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PUBLIC KEY",
+		Bytes: x509.MarshalPKCS1PublicKey(&testRSAKey(t).PublicKey),
+	})
+
+	hsKey := Key{Alg: AlgHS256, Secret: []byte(testSecret)}
+	rsKey := Key{Alg: AlgRS256, Public: &testRSAKey(t).PublicKey}
+
+	// claims возвращает действующие claims с изменениями из overrides;
+	// значение nil удаляет claim
+	claims := func(overrides map[string]any) map[string]any {
+		result := map[string]any{
+			"sub":   "alice",
+			"exp":   now.Add(time.Hour).Unix(),
+			"roles": []string{"editor"},
+		}
+		for name, value := range overrides {
+			if value == nil {
+				delete(result, name)
+				continue
+			}
+			result[name] = value
+		}
+		return result
+	}
+	hsHeader := map[string]any{"alg": AlgHS256, "typ": "JWT"}
+	rsHeader := map[string]any{"alg": AlgRS256, "typ": "JWT"}
+
+	tests := []struct {
+		name   string
+		config Config
+		token  string
+		want   Claims
+		// wantErr - подстрока ошибки, пусто - токен должен пройти проверку
+		wantErr string
+	}{
+		{
+			name:   "HS256",
+			config: Config{Keys: []Key{hsKey}},
+			token:  token(t, hsHeader, claims(nil), hs256([]byte(testSecret))),
+			want:   Claims{Subject: "alice", Roles: []string{"editor"}},
+		},
+		{
+			name:   "RS256",
+			config: Config{Keys: []Key{rsKey}},
+			token:  token(t, rsHeader, claims(nil), rs256),
+			want:   Claims{Subject: "alice", Roles: []string{"editor"}},
+		},
+		{
+			name:    "alg none",
+			config:  Config{Keys: []Key{hsKey, rsKey}},
+			token:   token(t, map[string]any{"alg": "none"}, claims(nil), unsigned),
+			wantErr: "unsupported algorithm",
+		},
+		{
+			name:    "неизвестный алгоритм",
+			config:  Config{Keys: []Key{hsKey}},
+			token:   token(t, map[string]any{"alg": "HS512"}, claims(nil), hs256([]byte(testSecret))),
+			wantErr: "unsupported algorithm",
+		},
+		{
+			name:    "HS256 с публичным ключом RS256 в качестве секрета",
+			config:  Config{Keys: []Key{rsKey}},
+			token:   token(t, hsHeader, claims(nil), hs256(publicPEM)),
+			wantErr: "signature verification failed",
+		},
+		{
+			name:    "неверный секрет",
+			config:  Config{Keys: []Key{hsKey}},
+			token:   token(t, hsHeader, claims(nil), hs256([]byte(strings.Repeat("x", 32)))),
+			wantErr: "signature verification failed",
+		},
+		{
+			name:    "kid не совпадает",
+			config:  Config{Keys: []Key{{Kid: "a", Alg: AlgHS256, Secret: []byte(testSecret)}}},
+			token:   token(t, map[string]any{"alg": AlgHS256, "kid": "b"}, claims(nil), hs256([]byte(testSecret))),
+			wantErr: "signature verification failed",
+		},
+		{
+			name:   "kid совпадает",
+			config: Config{Keys: []Key{{Kid: "b", Alg: AlgHS256, Secret: []byte("other")}, {Kid: "a", Alg: AlgHS256, Secret: []byte(testSecret)}}},
+			token:  token(t, map[string]any{"alg": AlgHS256, "kid": "a"}, claims(nil), hs256([]byte(testSecret))),
+			want:   Claims{Subject: "alice", Roles: []string{"editor"}},
+		},
+		{
+			name:   "ключ без kid подходит к любому kid",
+			config: Config{Keys: []Key{hsKey}},
+			token:  token(t, map[string]any{"alg": AlgHS256, "kid": "any"}, claims(nil), hs256([]byte(testSecret))),
+			want:   Claims{Subject: "alice", Roles: []string{"editor"}},
+		},
+		{
+			name:    "подделанные claims",
+			config:  Config{Keys: []Key{hsKey}},
+			token:   tamper(t, token(t, hsHeader, claims(nil), hs256([]byte(testSecret))), claims(map[string]any{"roles": []string{"admin"}})),
+			wantErr: "signature verification failed",
+		},
+		{
+			name:    "истёк",
+			config:  Config{Keys: []Key{hsKey}},
+			token:   token(t, hsHeader, claims(map[string]any{"exp": now.Add(-2 * time.Minute).Unix()}), hs256([]byte(testSecret))),
+			wantErr: "expired",
+		},
+		{
+			name:   "истёк в пределах leeway",
+			config: Config{Keys: []Key{hsKey}},
+			token:  token(t, hsHeader, claims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()}), hs256([]byte(testSecret))),
+			want:   Claims{Subject: "alice", Roles: []string{"editor"}},
+		},
+		{
+			name:    "без exp",
+			config:  Config{Keys: []Key{hsKey}},
+			token:   token(t, hsHeader, claims(map[string]any{"exp": nil}), hs256([]byte(testSecret))),
+			wantErr: "exp claim is required",
+		},
+		{
+			name:    "nbf в будущем",
+			config:  Config{Keys: []Key{hsKey}},
+			token:   token(t, hsHeader, claims(map[string]any{"nbf": now.Add(2 * time.Minute).Unix()}), hs256([]byte(testSecret))),
+			wantErr: "not valid yet",
+		},
+		{
+			name:   "nbf в пределах leeway",
+			config: Config{Keys: []Key{hsKey}},
+			token:  token(t, hsHeader, claims(map[string]any{"nbf": now.Add(30 * time.Second).Unix()}), hs256([]byte(testSecret))),
+			want:   Claims{Subject: "alice", Roles: []string{"editor"}},
+		},
+		{
+			name:   "издатель совпадает",
+			config: Config{Keys: []Key{hsKey}, Issuer: "https://auth.example.com"},
+			token:  token(t, hsHeader, claims(map[string]any{"iss": "https://auth.example.com"}), hs256([]byte(testSecret))),
+			want:   Claims{Subject: "alice", Roles: []string{"editor"}},
+		},
+		{
+			name:    "чужой издатель",
+			config:  Config{Keys: []Key{hsKey}, Issuer: "https://auth.example.com"},
+			token:   token(t, hsHeader, claims(map[string]any{"iss": "https://evil.example.com"}), hs256([]byte(testSecret))),
+			wantErr: "unexpected issuer",
+		},
+		{
+			name:    "без издателя",
+			config:  Config{Keys: []Key{hsKey}, Issuer: "https://auth.example.com"},
+			token:   token(t, hsHeader, claims(nil), hs256([]byte(testSecret))),
+			wantErr: "unexpected issuer",
+		},
+		{
+			name:   "аудитория строкой",
+			config: Config{Keys: []Key{hsKey}, Audience: "music-library"},
+			token:  token(t, hsHeader, claims(map[string]any{"aud": "music-library"}), hs256([]byte(testSecret))),
+			want:   Claims{Subject: "alice", Roles: []string{"editor"}},
+		},
+		{
+			name:   "аудитория в массиве",
+			config: Config{Keys: []Key{hsKey}, Audience: "music-library"},
+			token:  token(t, hsHeader, claims(map[string]any{"aud": []string{"other", "music-library"}}), hs256([]byte(testSecret))),
+			want:   Claims{Subject: "alice", Roles: []string{"editor"}},
+		},
+		{
+			name:    "чужая аудитория в массиве",
+			config:  Config{Keys: []Key{hsKey}, Audience: "music-library"},
+			token:   token(t, hsHeader, claims(map[string]any{"aud": []string{"other"}}), hs256([]byte(testSecret))),
+			wantErr: "unexpected audience",
+		},
+		{
+			name:    "без аудитории",
+			config:  Config{Keys: []Key{hsKey}, Audience: "music-library"},
+			token:   token(t, hsHeader, claims(nil), hs256([]byte(testSecret))),
+			wantErr: "unexpected audience",
+		},
+		{
+			name:    "без sub",
+			config:  Config{Keys: []Key{hsKey}},
+			token:   token(t, hsHeader, claims(map[string]any{"sub": nil}), hs256([]byte(testSecret))),
+			wantErr: "sub claim is required",
+		},
+		{
+			name:   "роли из настроенного claim строкой",
+			config: Config{Keys: []Key{hsKey}, RolesClaim: "app_roles"},
+			token:  token(t, hsHeader, claims(map[string]any{"app_roles": "admin"}), hs256([]byte(testSecret))),
+			want:   Claims{Subject: "alice", Roles: []string{"admin"}},
+		},
+		{
+			name:    "не три части",
+			config:  Config{Keys: []Key{hsKey}},
+			token:   "abc.def",
+			wantErr: "malformed token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := NewVerifier(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := verifier.Verify(tt.token, now)
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrInvalidToken) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Verify() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Verify() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// tamper заменяет claims токена, сохраняя заголовок и подпись.
+func tamper(t *testing.T, token string, claims map[string]any) string {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	parts[1] = segment(t, claims)
+	return strings.Join(parts, ".")
+}
+
+func TestLoadJWKS(t *testing.T) {
+	public := &testRSAKey(t).PublicKey
+	rsaJWK := func(kid, use, alg string) map[string]any {
+		return map[string]any{
+			"kty": "RSA",
+			"kid": kid,
+			"use": use,
+			"alg": alg,
+			"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}
+	}
+	octJWK := func(kid string, secret string) map[string]any {
+		return map[string]any{
+			"kty": "oct",
+			"kid": kid,
+			"k":   base64.RawURLEncoding.EncodeToString([]byte(secret)),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		keys    []map[string]any
+		want    []Key
+		wantErr string
+	}{
+		{
+			name: "ключи для шифрования пропускаются",
+			keys: []map[string]any{rsaJWK("enc", "enc", ""), rsaJWK("sig", "sig", AlgRS256), octJWK("hs", testSecret)},
+			want: []Key{
+				{Kid: "sig", Alg: AlgRS256, Public: public},
+				{Kid: "hs", Alg: AlgHS256, Secret: []byte(testSecret)},
+			},
+		},
+		{
+			name: "ключи других алгоритмов пропускаются",
+			keys: []map[string]any{rsaJWK("ps", "", "PS256"), rsaJWK("rs", "", "")},
+			want: []Key{{Kid: "rs", Alg: AlgRS256, Public: public}},
+		},
+		{
+			name:    "короткий секрет oct",
+			keys:    []map[string]any{octJWK("short", "secret")},
+			wantErr: "at least 32 bytes",
+		},
+		{
+			name:    "только ключи для шифрования",
+			keys:    []map[string]any{rsaJWK("enc", "enc", "")},
+			wantErr: "no signing keys",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(map[string]any{"keys": tt.keys})
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(t.TempDir(), "jwks.json")
+			if err := os.WriteFile(path, data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadJWKS(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadJWKS() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadJWKS() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadJWKS() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHS256KeyRejectsShortSecret(t *testing.T) {
+	if _, err := HS256Key("short"); err == nil {
+		t.Error("HS256Key() accepted a short secret")
+	}
+	if _, err := HS256Key(testSecret); err != nil {
+		t.Errorf("HS256Key() error = %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+// Package lcs сопоставляет два списка строк по наибольшей общей
+// подпоследовательности. На нём построены дифф ревизий и перенос таймингов
+// LRC на изменённый текст.
+package lcs
+
+// MaxCells ограничивает таблицу LCS: (len(a)+1)*(len(b)+1) клеток по 4 байта,
+// то есть до 4 МБ на вызов. Тексты песен в неё укладываются с запасом.
+const MaxCells = 1_000_000
+
+// Match возвращает пары индексов совпавших элементов a и b по возрастанию.
+// Общие начало и конец сопоставляются до построения таблицы. Если середина
+// в MaxCells не укладывается, в ней совпадений не ищется - её элементы
+// остаются без пары, а расход памяти не растёт квадратично.
+func Match(a, b []string) [][2]int {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	pairs := make([][2]int, 0, prefix+suffix)
+	for i := 0; i < prefix; i++ {
+		pairs = append(pairs, [2]int{i, i})
+	}
+	pairs = append(pairs, middle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], prefix)...)
+	for i := 0; i < suffix; i++ {
+		pairs = append(pairs, [2]int{len(a) - suffix + i, len(b) - suffix + i})
+	}
+	return pairs
+}
+
+// middle ищет совпадения между общими началом и концом. offset - длина
+// общего начала, на неё сдвигаются индексы.
+func middle(a, b []string, offset int) [][2]int {
+	if len(a) == 0 || len(b) == 0 || (len(a)+1)*(len(b)+1) > MaxCells {
+		return nil
+	}
+
+	// table[i*width+j] - длина общей подпоследовательности a[i:] и b[j:]
+	width := len(b) + 1
+	table := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i*width+j] = table[(i+1)*width+j+1] + 1
+			} else {
+				table[i*width+j] = max(table[(i+1)*width+j], table[i*width+j+1])
+			}
+		}
+	}
+
+	var pairs [][2]int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			pairs = append(pairs, [2]int{offset + i, offset + j})
+			i++
+			j++
+		case table[(i+1)*width+j] >= table[i*width+j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
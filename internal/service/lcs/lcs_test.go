@@ -0,0 +1,63 @@
+package lcs
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+		want [][2]int
+	}{
+		{name: "пустые списки", want: [][2]int{}},
+		{name: "совпадают", a: []string{"a", "b"}, b: []string{"a", "b"}, want: [][2]int{{0, 0}, {1, 1}}},
+		{name: "нет общих", a: []string{"a"}, b: []string{"b"}, want: [][2]int{}},
+		{
+			name: "вставка в середину",
+			a:    []string{"a", "c"},
+			b:    []string{"a", "b", "c"},
+			want: [][2]int{{0, 0}, {1, 2}},
+		},
+		{
+			name: "перестановка",
+			a:    []string{"x", "a", "b", "c", "y"},
+			b:    []string{"z", "c", "a", "b", "w"},
+			want: [][2]int{{1, 2}, {2, 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Match(tt.a, tt.b)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchOverCellLimit(t *testing.T) {
+	// Середина (1000+1)*(1000+1) клеток не помещается в MaxCells, но общие
+	// начало и конец сопоставляются
+	const n = 1000
+	a := []string{"head"}
+	b := []string{"head"}
+	for i := 0; i < n; i++ {
+		a = append(a, fmt.Sprintf("line %d", i))
+		b = append(b, fmt.Sprintf("line %d", n-1-i))
+	}
+	a = append(a, "tail")
+	b = append(b, "tail")
+
+	want := [][2]int{{0, 0}, {n + 1, n + 1}}
+	if got := Match(a, b); !reflect.DeepEqual(got, want) {
+		t.Errorf("Match() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,217 @@
+package lrc
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/service/lcs"
+	"github.com/wiqwi12/effective-mobile-test/internal/service/splitter"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidLrc    = apperr.New(apperr.Validation, "invalid_lrc", "lrc contains no timed lines")
+	ErrInvalidOffset = apperr.New(apperr.Validation, "invalid_lrc_offset", "lrc offset must be an integer")
+)
+
+var (
+	timeTag  = regexp.MustCompile(`^\[(\d+):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	metaTag  = regexp.MustCompile(`^\[([a-zA-Z]+):(.*)\]$`)
+	wordTags = regexp.MustCompile(`<\d+:\d{1,2}(?:[.:]\d{1,3})?>`)
+)
+
+// Entry - одна строка LRC. Строка с несколькими метками времени
+// разворачивается в несколько записей.
+type Entry struct {
+	TimeMs int64
+	Text   string
+}
+
+// Parse разбирает LRC и возвращает строки, отсортированные по времени.
+// Учитывается тег [offset:], остальные теги метаданных и строки без
+// меток времени пропускаются. Пословные метки <mm:ss.xx> вырезаются.
+func Parse(text string) ([]Entry, error) {
+	var entries []Entry
+	var offset int64
+
+	for _, line := range strings.Split(splitter.Normalize(text), "\n") {
+		line = strings.TrimSpace(line)
+
+		var times []int64
+		for {
+			match := timeTag.FindStringSubmatch(line)
+			if match == nil {
+				break
+			}
+			times = append(times, tagMs(match))
+			line = line[len(match[0]):]
+		}
+
+		if len(times) == 0 {
+			if meta := metaTag.FindStringSubmatch(line); meta != nil && strings.EqualFold(meta[1], "offset") {
+				value, err := strconv.ParseInt(strings.TrimSpace(meta[2]), 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %q", ErrInvalidOffset, meta[2])
+				}
+				offset = value
+			}
+			continue
+		}
+
+		line = strings.TrimSpace(wordTags.ReplaceAllString(line, ""))
+		for _, t := range times {
+			entries = append(entries, Entry{TimeMs: t, Text: line})
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, ErrInvalidLrc
+	}
+
+	// Положительный offset по спецификации сдвигает текст раньше
+	for i := range entries {
+		entries[i].TimeMs = max(entries[i].TimeMs-offset, 0)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].TimeMs < entries[j].TimeMs
+	})
+
+	return entries, nil
+}
+
+func tagMs(match []string) int64 {
+	minutes, _ := strconv.ParseInt(match[1], 10, 64)
+	seconds, _ := strconv.ParseInt(match[2], 10, 64)
+	ms := (minutes*60 + seconds) * 1000
+
+	// Дробная часть: одна цифра - десятые, две - сотые, три - миллисекунды
+	if fraction := match[3]; fraction != "" {
+		value, _ := strconv.ParseInt(fraction, 10, 64)
+		for i := len(fraction); i < 3; i++ {
+			value *= 10
+		}
+		ms += value
+	}
+	return ms
+}
+
+// Text собирает текст песни из строк LRC. Пустые строки LRC разделяют
+// куплеты.
+func Text(entries []Entry) string {
+	var verses []string
+	var current []string
+	for _, entry := range entries {
+		if entry.Text == "" {
+			if len(current) > 0 {
+				verses = append(verses, strings.Join(current, "\n"))
+				current = nil
+			}
+			continue
+		}
+		current = append(current, entry.Text)
+	}
+	if len(current) > 0 {
+		verses = append(verses, strings.Join(current, "\n"))
+	}
+	return strings.Join(verses, models.VerseSeparator)
+}
+
+// Format выводит строки с таймингом в формате LRC. Строки без тайминга
+// пропускаются.
+func Format(song models.Song, lines []models.LyricLine) string {
+	var b strings.Builder
+	if song.Title != "" {
+		fmt.Fprintf(&b, "[ti:%s]\n", song.Title)
+	}
+	if song.GroupName != "" {
+		fmt.Fprintf(&b, "[ar:%s]\n", song.GroupName)
+	}
+	for _, line := range lines {
+		if line.TimeMs == nil {
+			continue
+		}
+		fmt.Fprintf(&b, "%s%s\n", formatTime(*line.TimeMs), line.Text)
+	}
+	return b.String()
+}
+
+func formatTime(ms int64) string {
+	return fmt.Sprintf("[%02d:%02d.%02d]", ms/60000, ms/1000%60, ms%1000/10)
+}
+
+// TextLines возвращает строки текста песни, к которым привязываются
+// тайминги: без пустых строк и заголовков секций вида [Chorus].
+func TextLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(splitter.Normalize(text), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || splitter.IsHeader(line) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Align переносит тайминги timed на строки текста text. Строки сопоставляются
+// по наибольшей общей подпоследовательности, поэтому правка одной строки
+// не сбивает тайминги остальных. Строки без пары остаются без тайминга, в том
+// числе все изменённые строки, если текст не укладывается в lcs.MaxCells.
+// Возвращает строки и количество строк с таймингом.
+func Align(songId uuid.UUID, timed []Entry, text string) ([]models.LyricLine, int) {
+	lines := TextLines(text)
+
+	var sources []Entry
+	for _, entry := range timed {
+		if entry.Text != "" {
+			sources = append(sources, entry)
+		}
+	}
+
+	a := make([]string, len(sources))
+	for i, entry := range sources {
+		a[i] = lineKey(entry.Text)
+	}
+	b := make([]string, len(lines))
+	for i, line := range lines {
+		b[i] = lineKey(line)
+	}
+
+	result := make([]models.LyricLine, len(lines))
+	for i, line := range lines {
+		result[i] = models.LyricLine{
+			Id:         uuid.New(),
+			SongId:     songId,
+			LineNumber: i + 1,
+			Text:       line,
+		}
+	}
+
+	matched := 0
+	for _, pair := range lcs.Match(a, b) {
+		timeMs := sources[pair[0]].TimeMs
+		result[pair[1]].TimeMs = &timeMs
+		matched++
+	}
+	return result, matched
+}
+
+// Entries возвращает строки с таймингом в виде записей LRC для повторного
+// выравнивания.
+func Entries(lines []models.LyricLine) []Entry {
+	entries := make([]Entry, 0, len(lines))
+	for _, line := range lines {
+		if line.TimeMs != nil {
+			entries = append(entries, Entry{TimeMs: *line.TimeMs, Text: line.Text})
+		}
+	}
+	return entries
+}
+
+func lineKey(line string) string {
+	return strings.ToLower(strings.Join(strings.Fields(line), " "))
+}
@@ -0,0 +1,198 @@
+package lrc
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		lrc     string
+		want    []Entry
+		wantErr error
+	}{
+		{
+			name: "несколько меток на строке",
+			lrc:  "[00:01.00]first\n[00:02.00][00:04.50]chorus\n[00:03.00]second",
+			want: []Entry{
+				{TimeMs: 1000, Text: "first"},
+				{TimeMs: 2000, Text: "chorus"},
+				{TimeMs: 3000, Text: "second"},
+				{TimeMs: 4500, Text: "chorus"},
+			},
+		},
+		{
+			name: "дробная часть из одной, двух и трёх цифр",
+			lrc:  "[01:02.5]a\n[01:03.25]b\n[01:04.125]c\n[01:05:30]d\n[01:06]e",
+			want: []Entry{
+				{TimeMs: 62500, Text: "a"},
+				{TimeMs: 63250, Text: "b"},
+				{TimeMs: 64125, Text: "c"},
+				{TimeMs: 65300, Text: "d"},
+				{TimeMs: 66000, Text: "e"},
+			},
+		},
+		{
+			name: "метаданные и некорректные теги пропускаются",
+			lrc:  "[ti:Song]\n[ar:Group]\n[xx:yy]broken\n[00:01.00\n[00:02.00]ok\nplain text",
+			want: []Entry{{TimeMs: 2000, Text: "ok"}},
+		},
+		{
+			name: "пословные метки вырезаются",
+			lrc:  "[00:01.00]<00:01.00>Hello <00:01.50>world",
+			want: []Entry{{TimeMs: 1000, Text: "Hello world"}},
+		},
+		{
+			name: "offset сдвигает текст раньше, но не до отрицательного времени",
+			lrc:  "[offset:1500]\n[00:01.00]a\n[00:03.00]b",
+			want: []Entry{
+				{TimeMs: 0, Text: "a"},
+				{TimeMs: 1500, Text: "b"},
+			},
+		},
+		{
+			name: "пустая строка с меткой сохраняется",
+			lrc:  "[00:01.00]a\n[00:02.00]\n[00:03.00]b",
+			want: []Entry{
+				{TimeMs: 1000, Text: "a"},
+				{TimeMs: 2000, Text: ""},
+				{TimeMs: 3000, Text: "b"},
+			},
+		},
+		{
+			name:    "некорректный offset",
+			lrc:     "[offset:soon]\n[00:01.00]a",
+			wantErr: ErrInvalidOffset,
+		},
+		{
+			name:    "нет строк с метками",
+			lrc:     "[ti:Song]\nplain text",
+			wantErr: ErrInvalidLrc,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.lrc)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlign(t *testing.T) {
+	timed := []Entry{
+		{TimeMs: 1000, Text: "Line A"},
+		{TimeMs: 2000, Text: "Line B"},
+		{TimeMs: 3000, Text: "Line C"},
+	}
+
+	// -1 - строка без тайминга
+	tests := []struct {
+		name  string
+		text  string
+		times []int64
+	}{
+		{
+			name:  "текст не изменился",
+			text:  "Line A\nLine B\nLine C",
+			times: []int64{1000, 2000, 3000},
+		},
+		{
+			name:  "строка изменена",
+			text:  "Line A\nLine B changed\nLine C",
+			times: []int64{1000, -1, 3000},
+		},
+		{
+			name:  "строка вставлена",
+			text:  "Line A\nNew line\nLine B\nLine C",
+			times: []int64{1000, -1, 2000, 3000},
+		},
+		{
+			name:  "строка удалена",
+			text:  "Line A\nLine C",
+			times: []int64{1000, 3000},
+		},
+		{
+			name:  "строка перенесена в начало",
+			text:  "Line C\nLine A\nLine B",
+			times: []int64{-1, 1000, 2000},
+		},
+		{
+			name:  "заголовки и пустые строки не считаются строками",
+			text:  "[Chorus]\nLine A\n\nLine B\n\nLine C",
+			times: []int64{1000, 2000, 3000},
+		},
+		{
+			name:  "регистр и пробелы не учитываются",
+			text:  "line  a\nLINE B \nLine C",
+			times: []int64{1000, 2000, 3000},
+		},
+	}
+
+	songId := uuid.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, matched := Align(songId, timed, tt.text)
+
+			times := make([]int64, len(lines))
+			wantMatched := 0
+			for i, line := range lines {
+				times[i] = -1
+				if line.TimeMs != nil {
+					times[i] = *line.TimeMs
+				}
+				if line.LineNumber != i+1 || line.SongId != songId {
+					t.Errorf("line %d: number = %d, song = %s", i, line.LineNumber, line.SongId)
+				}
+			}
+			for _, ms := range tt.times {
+				if ms >= 0 {
+					wantMatched++
+				}
+			}
+
+			if !reflect.DeepEqual(times, tt.times) {
+				t.Errorf("Align() times = %v, want %v", times, tt.times)
+			}
+			if matched != wantMatched {
+				t.Errorf("Align() matched = %d, want %d", matched, wantMatched)
+			}
+		})
+	}
+}
+
+func TestAlignRepeatedEdits(t *testing.T) {
+	songId := uuid.New()
+	entries, err := Parse("[00:01.00]Line A\n[00:02.00]Line B\n[00:03.00]Line C\n[00:04.00]Line D")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Каждая правка выравнивается по результату предыдущей, как при
+	// последовательной правке куплетов
+	lines, _ := Align(songId, entries, "Line A\nLine B\nLine X\nLine C\nLine D")
+	lines, _ = Align(songId, Entries(lines), "Line A\nLine X\nLine C\nLine D")
+	lines, matched := Align(songId, Entries(lines), "Line A\nLine X\nLine D")
+
+	want := map[string]int64{"Line A": 1000, "Line D": 4000}
+	if matched != len(want) {
+		t.Fatalf("matched = %d, want %d", matched, len(want))
+	}
+	for _, line := range lines {
+		ms, timedLine := want[line.Text]
+		switch {
+		case timedLine && (line.TimeMs == nil || *line.TimeMs != ms):
+			t.Errorf("%q: time = %v, want %d", line.Text, line.TimeMs, ms)
+		case !timedLine && line.TimeMs != nil:
+			t.Errorf("%q: time = %d, want none", line.Text, *line.TimeMs)
+		}
+	}
+}
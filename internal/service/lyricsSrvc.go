@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/metrics"
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/postgres/repository"
+	"github.com/wiqwi12/effective-mobile-test/internal/service/lrc"
+	"math"
+	"strings"
+	"time"
+)
+
+const DefaultNextLines = 3
+
+// ImportLrc привязывает тайминги из LRC к строкам текста песни. Если у песни
+// нет текста или передан replace_text, текст и куплеты заменяются текстом из LRC.
+func (s *SongSrvc) ImportLrc(ctx context.Context, songId uuid.UUID, request dto.ImportLrcRequest) (dto.TimedLyricsResponse, error) {
+	var resp dto.TimedLyricsResponse
+
+	entries, err := lrc.Parse(request.Lrc)
+	if err != nil {
+		resp.Message = "Validation failed"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	song, err := s.getSong(ctx, songId)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	var lines []models.LyricLine
+	var timed int
+	if request.ReplaceText || strings.TrimSpace(song.Text) == "" {
+		song.Text = lrc.Text(entries)
+		song.UpdatedAt = time.Now()
+		lines, timed = lrc.Align(songId, entries, song.Text)
+		verses := s.buildVerses(ctx, song)
+		err = s.SongRepo.UpdateSong(ctx, song, verses, lines)
+		if err == nil {
+			metrics.VersesProcessed(len(verses))
+		}
+	} else {
+		lines, timed = lrc.Align(songId, entries, song.Text)
+		err = s.LyricsRepo.ReplaceLines(ctx, songId, song.Version, lines)
+	}
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to import lrc",
+			"error", err,
+			"song_id", songId)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Lines = lines
+	resp.TimedLines = timed
+	resp.UntimedLines = len(lines) - timed
+	resp.Message = "Lyrics succsessfully imported"
+	return resp, nil
+}
+
+func (s *SongSrvc) GetTimedLyrics(ctx context.Context, songId uuid.UUID) (dto.TimedLyricsResponse, error) {
+	var resp dto.TimedLyricsResponse
+
+	lines, err := s.timedLines(ctx, songId)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Lines = lines
+	for _, line := range lines {
+		if line.TimeMs != nil {
+			resp.TimedLines++
+		}
+	}
+	resp.UntimedLines = len(lines) - resp.TimedLines
+	return resp, nil
+}
+
+// ExportLrc возвращает тайминги песни в формате LRC.
+func (s *SongSrvc) ExportLrc(ctx context.Context, songId uuid.UUID) (string, error) {
+	song, err := s.getSong(ctx, songId)
+	if err != nil {
+		return "", err
+	}
+
+	lines, err := s.timedLines(ctx, songId)
+	if err != nil {
+		return "", err
+	}
+
+	return lrc.Format(song, lines), nil
+}
+
+// GetLyricsAt возвращает строку, которая звучит в момент t, и несколько
+// следующих за ней, чтобы плеер мог показать текущую строку и подготовить
+// следующие.
+func (s *SongSrvc) GetLyricsAt(ctx context.Context, request dto.LyricsAtRequest) (dto.LyricsAtResponse, error) {
+	resp := dto.LyricsAtResponse{Time: request.Time}
+
+	timeMs := int64(math.Round(request.Time * 1000))
+	current, next, err := s.LyricsRepo.GetLinesAt(ctx, request.SongId, timeMs, request.Next)
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to get lyrics at position",
+			"error", err,
+			"song_id", request.SongId,
+			"t", request.Time)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Current = current
+	resp.Next = next
+	return resp, nil
+}
+
+// alignedLines переносит существующие тайминги песни на строки нового
+// текста. nil означает, что таймингов у песни нет и трогать их не нужно.
+func (s *SongSrvc) alignedLines(ctx context.Context, songId uuid.UUID, text string) ([]models.LyricLine, error) {
+	existing, err := s.LyricsRepo.GetLines(ctx, songId)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) == 0 {
+		return nil, nil
+	}
+
+	lines, _ := lrc.Align(songId, lrc.Entries(existing), text)
+	return lines, nil
+}
+
+// realignLines переносит тайминги песни на текст, пересобранный из
+// куплетов после их правки. Вызывается в транзакции правки куплетов.
+func realignLines(songId uuid.UUID, existing []models.LyricLine, text string) []models.LyricLine {
+	lines, _ := lrc.Align(songId, lrc.Entries(existing), text)
+	return lines
+}
+
+func (s *SongSrvc) timedLines(ctx context.Context, songId uuid.UUID) ([]models.LyricLine, error) {
+	lines, err := s.LyricsRepo.GetLines(ctx, songId)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, repository.ErrLyricsNotFound
+	}
+	return lines, nil
+}
+
+func (s *SongSrvc) getSong(ctx context.Context, songId uuid.UUID) (models.Song, error) {
+	song, err := s.SongRepo.GetSongById(ctx, songId)
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to get song by ID",
+			"error", err,
+			"song_id", songId)
+		return models.Song{}, err
+	}
+	return song, nil
+}
@@ -0,0 +1,222 @@
+// Package ratelimit ограничивает частоту запросов алгоритмом token bucket:
+// у каждого клиента есть корзина на Burst токенов, запрос забирает токен,
+// а корзина равномерно пополняется на Burst токенов за Period.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sweepInterval - как часто MemoryStore удаляет корзины неактивных клиентов
+const sweepInterval = time.Minute
+
+// Limit - ёмкость корзины и время её полного пополнения. Нулевой Limit
+// означает отсутствие ограничения.
+type Limit struct {
+	Burst  int
+	Period time.Duration
+}
+
+// ParseLimit разбирает лимит вида "60/m": число запросов и период s, m или h.
+// "off" выключает ограничение.
+func ParseLimit(raw string) (Limit, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "off" {
+		return Limit{}, nil
+	}
+
+	count, unit, ok := strings.Cut(raw, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("limit %q must look like 60/m", raw)
+	}
+	burst, err := strconv.Atoi(count)
+	if err != nil || burst <= 0 {
+		return Limit{}, fmt.Errorf("limit %q: request count must be a positive integer", raw)
+	}
+
+	periods := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+	period, ok := periods[unit]
+	if !ok {
+		return Limit{}, fmt.Errorf("limit %q: period must be s, m or h", raw)
+	}
+	return Limit{Burst: burst, Period: period}, nil
+}
+
+func (l Limit) Unlimited() bool {
+	return l.Burst == 0
+}
+
+// interval - за сколько в корзину добавляется один токен.
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Burst)
+}
+
+// Result - решение по запросу и состояние корзины для заголовков X-RateLimit-*.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter - через сколько появится следующий токен, если запрос отклонён
+	RetryAfter time.Duration
+	// Reset - через сколько корзина пополнится полностью
+	Reset time.Duration
+}
+
+// Store хранит корзины клиентов. MemoryStore держит их в памяти процесса;
+// для нескольких экземпляров сервиса можно подключить общее хранилище.
+type Store interface {
+	// Take забирает токен из корзины key, если он есть.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+	// Peek возвращает состояние корзины key, не забирая токен.
+	Peek(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// refill пополняет корзину за время, прошедшее с последнего запроса.
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated)
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens = min(float64(b.limit.Burst), b.tokens+float64(elapsed)/float64(b.limit.interval()))
+	b.updated = now
+}
+
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Burst), updated: now, limit: limit}
+		s.buckets[key] = b
+	}
+	b.refill(now)
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return b.result(allowed), nil
+}
+
+func (s *MemoryStore) Peek(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Отсутствующая корзина не создаётся: она была бы полной
+	b, ok := s.buckets[key]
+	if !ok || b.limit != limit {
+		return Result{Allowed: true, Limit: limit.Burst, Remaining: limit.Burst}, nil
+	}
+	b.refill(now)
+
+	return b.result(b.tokens >= 1), nil
+}
+
+// result описывает состояние корзины после решения по запросу.
+func (b *bucket) result(allowed bool) Result {
+	interval := float64(b.limit.interval())
+	result := Result{Allowed: allowed, Limit: b.limit.Burst}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - b.tokens) * interval)
+	}
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = time.Duration((float64(b.limit.Burst) - b.tokens) * interval)
+	return result
+}
+
+// sweep удаляет корзины, которые уже пополнились полностью: такая корзина
+// ничем не отличается от новой, а без очистки память росла бы с каждым
+// новым IP.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// Limiter - ограничение для группы маршрутов. У каждой группы свои корзины,
+// так что запросы на чтение не расходуют лимит записи.
+type Limiter struct {
+	Name   string
+	Limit  Limit
+	store  Store
+	logger *logger.Logger
+}
+
+func NewLimiter(name string, limit Limit, store Store, logger *logger.Logger) *Limiter {
+	return &Limiter{
+		Name:   name,
+		Limit:  limit,
+		store:  store,
+		logger: logger,
+	}
+}
+
+// Allow решает, пропустить ли запрос клиента. Если хранилище недоступно,
+// запрос пропускается: ограничение частоты не должно останавливать API.
+func (l *Limiter) Allow(ctx context.Context, client string, now time.Time) Result {
+	if l == nil || l.Limit.Unlimited() {
+		return Result{Allowed: true}
+	}
+
+	result, err := l.store.Take(ctx, l.Name+":"+client, l.Limit, now)
+	if err != nil {
+		l.logger.Info.ErrorContext(ctx, "Failed to check rate limit",
+			"error", err,
+			"group", l.Name,
+			"client", client)
+		return Result{Allowed: true}
+	}
+	return result
+}
+
+// Check сообщает, остались ли у клиента токены, не расходуя их. Так лимит
+// считает только часть запросов: сначала проверяется Check, а Allow
+// вызывается, когда запрос нужно учесть. Как и Allow, при недоступном
+// хранилище запрос пропускается.
+func (l *Limiter) Check(ctx context.Context, client string, now time.Time) Result {
+	if l == nil || l.Limit.Unlimited() {
+		return Result{Allowed: true}
+	}
+
+	result, err := l.store.Peek(ctx, l.Name+":"+client, l.Limit, now)
+	if err != nil {
+		l.logger.Info.ErrorContext(ctx, "Failed to check rate limit",
+			"error", err,
+			"group", l.Name,
+			"client", client)
+		return Result{Allowed: true}
+	}
+	return result
+}
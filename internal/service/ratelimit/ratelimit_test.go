@@ -0,0 +1,259 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    Limit
+		wantErr bool
+	}{
+		{raw: "60/m", want: Limit{Burst: 60, Period: time.Minute}},
+		{raw: " 10/s ", want: Limit{Burst: 10, Period: time.Second}},
+		{raw: "1000/h", want: Limit{Burst: 1000, Period: time.Hour}},
+		{raw: "off", want: Limit{}},
+		{raw: "60", wantErr: true},
+		{raw: "0/m", wantErr: true},
+		{raw: "-1/m", wantErr: true},
+		{raw: "x/m", wantErr: true},
+		{raw: "60/d", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := ParseLimit(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLimit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLimit() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	// 3 токена за 3 секунды: один токен в секунду
+	limit := Limit{Burst: 3, Period: 3 * time.Second}
+	start := time.Unix(1_700_000_000, 0)
+
+	type take struct {
+		at   time.Duration
+		want Result
+	}
+	tests := []struct {
+		name  string
+		takes []take
+	}{
+		{
+			name: "корзина расходуется до нуля",
+			takes: []take{
+				{at: 0, want: Result{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second}},
+				{at: 0, want: Result{Allowed: true, Limit: 3, Remaining: 1, Reset: 2 * time.Second}},
+				{at: 0, want: Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 3 * time.Second}},
+				{at: 0, want: Result{Allowed: false, Limit: 3, Remaining: 0, RetryAfter: time.Second, Reset: 3 * time.Second}},
+			},
+		},
+		{
+			name: "RetryAfter - время до следующего целого токена",
+			takes: []take{
+				{at: 0, want: Result{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second}},
+				{at: 0, want: Result{Allowed: true, Limit: 3, Remaining: 1, Reset: 2 * time.Second}},
+				{at: 0, want: Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 3 * time.Second}},
+				{at: 250 * time.Millisecond, want: Result{Allowed: false, Limit: 3, Remaining: 0, RetryAfter: 750 * time.Millisecond, Reset: 2750 * time.Millisecond}},
+			},
+		},
+		{
+			name: "корзина пополняется равномерно",
+			takes: []take{
+				{at: 0, want: Result{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second}},
+				{at: 0, want: Result{Allowed: true, Limit: 3, Remaining: 1, Reset: 2 * time.Second}},
+				{at: 0, want: Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 3 * time.Second}},
+				{at: 1500 * time.Millisecond, want: Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 2500 * time.Millisecond}},
+				{at: 1500 * time.Millisecond, want: Result{Allowed: false, Limit: 3, Remaining: 0, RetryAfter: 500 * time.Millisecond, Reset: 2500 * time.Millisecond}},
+			},
+		},
+		{
+			name: "пополнение не превышает Burst",
+			takes: []take{
+				{at: 0, want: Result{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second}},
+				{at: time.Hour, want: Result{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			for i, take := range tt.takes {
+				got, err := store.Take(context.Background(), "client", limit, start.Add(take.at))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != take.want {
+					t.Errorf("take %d: Take() = %+v, want %+v", i, got, take.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreKeysAreIndependent(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Burst: 1, Period: time.Minute}
+	now := time.Unix(1_700_000_000, 0)
+
+	if result, _ := store.Take(context.Background(), "a", limit, now); !result.Allowed {
+		t.Fatal("first request of a was rejected")
+	}
+	if result, _ := store.Take(context.Background(), "a", limit, now); result.Allowed {
+		t.Fatal("second request of a was allowed")
+	}
+	if result, _ := store.Take(context.Background(), "b", limit, now); !result.Allowed {
+		t.Fatal("request of b was rejected because of a")
+	}
+}
+
+func TestMemoryStoreLimitChange(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Unix(1_700_000_000, 0)
+	strict := Limit{Burst: 1, Period: time.Minute}
+	relaxed := Limit{Burst: 5, Period: time.Minute}
+
+	store.Take(context.Background(), "client", strict, now)
+	if result, _ := store.Take(context.Background(), "client", strict, now); result.Allowed {
+		t.Fatal("request over the strict limit was allowed")
+	}
+
+	// Корзина с другим лимитом создаётся заново, полной
+	result, _ := store.Take(context.Background(), "client", relaxed, now)
+	want := Result{Allowed: true, Limit: 5, Remaining: 4, Reset: 12 * time.Second}
+	if result != want {
+		t.Errorf("Take() after limit change = %+v, want %+v", result, want)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Burst: 2, Period: time.Minute}
+	start := time.Unix(1_700_000_000, 0)
+
+	store.Take(context.Background(), "idle", limit, start)
+	store.Take(context.Background(), "busy", limit, start.Add(50*time.Second))
+	store.Take(context.Background(), "busy", limit, start.Add(50*time.Second))
+
+	// К следующей очистке корзина idle пополнилась полностью и удаляется,
+	// а busy ещё нет
+	store.Take(context.Background(), "trigger", limit, start.Add(sweepInterval))
+
+	if _, ok := store.buckets["idle"]; ok {
+		t.Error("full bucket was not swept")
+	}
+	if _, ok := store.buckets["busy"]; !ok {
+		t.Error("partially empty bucket was swept")
+	}
+	if !store.lastSweep.Equal(start.Add(sweepInterval)) {
+		t.Errorf("lastSweep = %v, want %v", store.lastSweep, start.Add(sweepInterval))
+	}
+}
+
+func TestMemoryStorePeek(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Burst: 2, Period: 2 * time.Second}
+	now := time.Unix(1_700_000_000, 0)
+
+	// Peek не создаёт корзину и не расходует токены
+	want := Result{Allowed: true, Limit: 2, Remaining: 2}
+	if result, _ := store.Peek(context.Background(), "client", limit, now); result != want {
+		t.Errorf("Peek() of a new client = %+v, want %+v", result, want)
+	}
+	if _, ok := store.buckets["client"]; ok {
+		t.Error("Peek() created a bucket")
+	}
+
+	store.Take(context.Background(), "client", limit, now)
+	store.Take(context.Background(), "client", limit, now)
+	want = Result{Allowed: false, Limit: 2, Remaining: 0, RetryAfter: time.Second, Reset: 2 * time.Second}
+	for i := 0; i < 2; i++ {
+		if result, _ := store.Peek(context.Background(), "client", limit, now); result != want {
+			t.Errorf("Peek() of an empty bucket = %+v, want %+v", result, want)
+		}
+	}
+
+	want = Result{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}
+	if result, _ := store.Peek(context.Background(), "client", limit, now.Add(time.Second)); result != want {
+		t.Errorf("Peek() after refill = %+v, want %+v", result, want)
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	return Result{}, errors.New("store is down")
+}
+
+func (failingStore) Peek(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	return Result{}, errors.New("store is down")
+}
+
+func TestLimiterAllow(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	t.Run("без лимита", func(t *testing.T) {
+		limiter := NewLimiter("read", Limit{}, failingStore{}, nil)
+		if result := limiter.Allow(context.Background(), "client", now); !result.Allowed {
+			t.Error("unlimited limiter rejected a request")
+		}
+	})
+
+	t.Run("хранилище недоступно", func(t *testing.T) {
+		log := &logger.Logger{Info: slog.New(slog.NewTextHandler(io.Discard, nil))}
+		limiter := NewLimiter("read", Limit{Burst: 1, Period: time.Minute}, failingStore{}, log)
+		if result := limiter.Allow(context.Background(), "client", now); !result.Allowed {
+			t.Error("request was rejected while the store is down")
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var limiter *Limiter
+		if result := limiter.Allow(context.Background(), "client", now); !result.Allowed {
+			t.Error("nil limiter rejected a request")
+		}
+	})
+
+	t.Run("Check не расходует лимит", func(t *testing.T) {
+		limiter := NewLimiter("auth", Limit{Burst: 1, Period: time.Minute}, NewMemoryStore(), nil)
+		for i := 0; i < 3; i++ {
+			if result := limiter.Check(context.Background(), "client", now); !result.Allowed {
+				t.Fatal("Check() rejected a client with a full bucket")
+			}
+		}
+		limiter.Allow(context.Background(), "client", now)
+		if result := limiter.Check(context.Background(), "client", now); result.Allowed {
+			t.Error("Check() allowed a client with an empty bucket")
+		}
+	})
+
+	t.Run("группы не делят корзины", func(t *testing.T) {
+		store := NewMemoryStore()
+		limit := Limit{Burst: 1, Period: time.Minute}
+		read := NewLimiter("read", limit, store, nil)
+		write := NewLimiter("write", limit, store, nil)
+
+		read.Allow(context.Background(), "client", now)
+		if result := read.Allow(context.Background(), "client", now); result.Allowed {
+			t.Error("read limit was not applied")
+		}
+		if result := write.Allow(context.Background(), "client", now); !result.Allowed {
+			t.Error("write request was rejected because of read requests")
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/postgres/repository"
+	"github.com/wiqwi12/effective-mobile-test/internal/service/diff"
+)
+
+const (
+	DefaultRevisionsLimit = 20
+	MaxRevisionsLimit     = 100
+)
+
+func (s *SongSrvc) ListRevisions(ctx context.Context, request dto.RevisionsListRequest) (dto.RevisionsResponse, error) {
+	var resp dto.RevisionsResponse
+
+	if request.Limit > MaxRevisionsLimit {
+		request.Limit = MaxRevisionsLimit
+	}
+
+	// История песни из корзины не видна, как и сама песня
+	_, err := s.getSong(ctx, request.SongId)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	revisions, total, err := s.SongRepo.ListRevisions(ctx, request.SongId, request.Page, request.Limit)
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to list song revisions",
+			"error", err,
+			"song_id", request.SongId)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Revisions = revisions
+	resp.Page = request.Page
+	resp.Limit = request.Limit
+	resp.Total = total
+	return resp, nil
+}
+
+// DiffRevisions сравнивает две ревизии песни: поля - целиком, текст - построчно.
+// Без To берётся последняя ревизия, без From - предыдущая перед To; у первой
+// ревизии предыдущей нет, и весь её текст считается добавленным.
+func (s *SongSrvc) DiffRevisions(ctx context.Context, request dto.RevisionDiffRequest) (dto.RevisionDiffResponse, error) {
+	var resp dto.RevisionDiffResponse
+
+	_, err := s.getSong(ctx, request.SongId)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	if request.To == 0 {
+		latest, _, err := s.SongRepo.ListRevisions(ctx, request.SongId, 1, 1)
+		if err != nil {
+			resp.Message = "some error occured"
+			resp.Error = err.Error()
+			return resp, err
+		}
+		if len(latest) == 0 {
+			resp.Message = "some error occured"
+			resp.Error = repository.ErrRevisionNotFound.Error()
+			return resp, repository.ErrRevisionNotFound
+		}
+		request.To = latest[0].Revision
+	}
+	if request.From == 0 {
+		request.From = request.To - 1
+	}
+
+	to, err := s.SongRepo.GetRevision(ctx, request.SongId, request.To)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	var from models.SongRevision
+	if request.From > 0 {
+		from, err = s.SongRepo.GetRevision(ctx, request.SongId, request.From)
+		if err != nil {
+			resp.Message = "some error occured"
+			resp.Error = err.Error()
+			return resp, err
+		}
+	}
+
+	resp.From = request.From
+	resp.To = request.To
+	resp.Fields = changedFields(from.Snapshot, to.Snapshot)
+	resp.Lines = []dto.DiffLine{}
+	for _, line := range diff.Lines(diff.SplitLines(from.Snapshot.Text), diff.SplitLines(to.Snapshot.Text)) {
+		switch line.Op {
+		case diff.Insert:
+			resp.Added++
+		case diff.Delete:
+			resp.Removed++
+		}
+		resp.Lines = append(resp.Lines, dto.DiffLine{
+			Op:       string(line.Op),
+			Text:     line.Text,
+			FromLine: line.From,
+			ToLine:   line.To,
+		})
+	}
+
+	return resp, nil
+}
+
+// changedFields перечисляет отличающиеся поля песни, кроме текста: его
+// изменения показывает построчный дифф.
+func changedFields(from, to models.SongSnapshot) []dto.FieldChange {
+	fields := []dto.FieldChange{}
+	compare := func(field, a, b string) {
+		if a != b {
+			fields = append(fields, dto.FieldChange{Field: field, From: a, To: b})
+		}
+	}
+	compare("group", from.GroupName, to.GroupName)
+	compare("title", from.Title, to.Title)
+	compare("release_date", from.ReleaseDate, to.ReleaseDate)
+	compare("link", from.Link, to.Link)
+	compare("split_strategy", from.SplitStrategy, to.SplitStrategy)
+	return fields
+}
+
+// RevertSong возвращает песне поля из ревизии и пересобирает куплеты и
+// тайминги, как при изменении текста. Откат сам становится новой ревизией.
+// Если группы из ревизии уже нет (удалена или влита в другую), песня
+// привязывается к группе по её названию, как при создании.
+func (s *SongSrvc) RevertSong(ctx context.Context, request dto.RevertSongRequest) (dto.StandartResponse, error) {
+	resp := dto.StandartResponse{}
+
+	song, err := s.getSong(ctx, request.SongId)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	if !request.Precondition.Matches(song.Version) {
+		resp.Song = song
+		resp.Message = "song was modified, current version returned"
+		resp.Error = repository.ErrSongModified.Error()
+		return resp, repository.ErrSongModified
+	}
+
+	revision, err := s.SongRepo.GetRevision(ctx, request.SongId, request.Revision)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+	snapshot := revision.Snapshot
+
+	group, err := s.GroupRepo.GetGroupById(ctx, snapshot.GroupId)
+	if errors.Is(err, repository.ErrGroupNotFound) {
+		group, err = s.resolveGroup(ctx, snapshot.GroupName)
+	}
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to get revision group",
+			"error", err,
+			"song_id", request.SongId,
+			"revision", request.Revision)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	song.GroupId = group.Id
+	song.GroupName = group.Name
+	song.Title = snapshot.Title
+	song.ReleaseDate = snapshot.ReleaseDate
+	song.Text = snapshot.Text
+	song.Link = snapshot.Link
+	song.SplitStrategy = snapshot.SplitStrategy
+
+	resp, err = s.saveSong(ctx, song, true, true)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Message = fmt.Sprintf("Song reverted to revision %d", request.Revision)
+	return resp, nil
+}
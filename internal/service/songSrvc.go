@@ -3,71 +3,85 @@ package service
 import (
 	"context"
 	"errors"
-	"fmt"
 	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
 	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
 	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
 	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/externalServices"
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/metrics"
 	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/postgres/repository"
+	"github.com/wiqwi12/effective-mobile-test/internal/service/splitter"
 	"github.com/wiqwi12/effective-mobile-test/pkg"
 	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
-	"log/slog"
-	"strings"
 	"time"
 )
 
+const (
+	DefaultSongsLimit = 20
+	MaxSongsLimit     = 100
+
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 50
+
+	DefaultVersesLimit = 10
+	MaxVersesLimit     = 50
+
+	rebuildBatchSize = 500
+
+	// defaultSplitStrategy в UpdateSongRequest сбрасывает стратегию песни на глобальную
+	defaultSplitStrategy = "default"
+)
+
+var ErrEmptyFilter = apperr.New(apperr.Validation, "empty_filter", "request must contain at least one filter")
+
 type SongSrvc struct {
 	SongRepo          *repository.SongRepository
 	GroupRepo         *repository.GroupRepository
 	MusicMetadataRepo *externalServices.MusicMetadataRepo
 	VerseRepo         *repository.VerseRepository
+	LyricsRepo        *repository.LyricsRepository
+	TranslationRepo   *repository.TranslationRepository
+	SplitConfig       splitter.Config
 	Logger            *logger.Logger
 }
 
-func NewSongSrvc(songRepo *repository.SongRepository, groupRepo *repository.GroupRepository, metaDataRepo *externalServices.MusicMetadataRepo, verseRepo *repository.VerseRepository, logger *logger.Logger) *SongSrvc {
+func NewSongSrvc(songRepo *repository.SongRepository, groupRepo *repository.GroupRepository, metaDataRepo *externalServices.MusicMetadataRepo, verseRepo *repository.VerseRepository, lyricsRepo *repository.LyricsRepository, translationRepo *repository.TranslationRepository, splitConfig splitter.Config, logger *logger.Logger) *SongSrvc {
 	return &SongSrvc{
 		SongRepo:          songRepo,
 		GroupRepo:         groupRepo,
 		MusicMetadataRepo: metaDataRepo,
 		VerseRepo:         verseRepo,
+		LyricsRepo:        lyricsRepo,
+		TranslationRepo:   translationRepo,
+		SplitConfig:       splitConfig,
 		Logger:            logger,
 	}
 }
 
 func (s *SongSrvc) CreateSong(ctx context.Context, request dto.CreateSongRequest) (dto.StandartResponse, error) {
-	var song models.Song
-
 	details, err := s.MusicMetadataRepo.GetSongDetails(ctx, request)
 	if err != nil {
-		s.Logger.Info.Error("Failed to get song metadata",
+		s.Logger.Info.ErrorContext(ctx, "Failed to get song metadata",
 			"error", err,
 			"group", request.Group,
 			"title", request.Title)
 		return dto.StandartResponse{}, err
 	}
 
-	groupExsist, err := s.GroupRepo.GroupExsist(ctx, request.Group)
+	return s.storeSong(ctx, request, details)
+}
+
+// storeSong сохраняет песню с уже полученными метаданными и разбивает её
+// текст на куплеты.
+func (s *SongSrvc) storeSong(ctx context.Context, request dto.CreateSongRequest, details dto.SongDetailResponse) (dto.StandartResponse, error) {
+	var song models.Song
+
+	group, err := s.resolveGroup(ctx, request.Group)
 	if err != nil {
-		s.Logger.Info.Error("Failed to get song group",
+		s.Logger.Info.ErrorContext(ctx, "Failed to get song group",
 			"error", err)
 		return dto.StandartResponse{}, err
 	}
-	fmt.Println(groupExsist)
-
-	var group models.Group
-	if !groupExsist {
-		group.Name = request.Group
-		group.Id = uuid.New()
-		err := s.GroupRepo.CreateGroup(group)
-		if err != nil {
-			s.Logger.Info.Error("Failed to create group",
-				"error", err)
-			return dto.StandartResponse{}, err
-		}
-		fmt.Println("Created group", group)
-	}
-
-	group, err = s.GroupRepo.GetGroupByName(request.Group)
 
 	song.GroupId = group.Id
 	song.GroupName = group.Name
@@ -78,28 +92,30 @@ func (s *SongSrvc) CreateSong(ctx context.Context, request dto.CreateSongRequest
 	song.Text = details.Text
 	song.Title = request.Title
 	song.ReleaseDate = details.ReleaseDate
-
-	slog.Info("text:", song.Text)
+	song.Version = 1
 
 	exists, err := s.SongRepo.SongExistsByDetails(ctx, song)
 	if err != nil {
-		s.Logger.Info.Error("Error checking if song exists",
+		s.Logger.Info.ErrorContext(ctx, "Error checking if song exists",
 			"error", err)
 		return dto.StandartResponse{
 			Message: "something went wrong",
 			Error:   err.Error(),
 		}, err
 	}
-	fmt.Println("song exsists", exists)
 	if exists {
+		metrics.DuplicateRejected()
 		return dto.StandartResponse{
-			Error: errors.New("song already exists").Error(),
-		}, errors.New("song already exists")
+			Error: repository.ErrSongExists.Error(),
+		}, repository.ErrSongExists
 	}
 
 	err = s.SongRepo.CreateSong(ctx, song)
 	if err != nil {
-		s.Logger.Info.Error("Failed to create song in database",
+		if errors.Is(err, repository.ErrSongExists) {
+			metrics.DuplicateRejected()
+		}
+		s.Logger.Info.ErrorContext(ctx, "Failed to create song in database",
 			"error", err)
 		return dto.StandartResponse{
 			Message: "something went wrong",
@@ -107,15 +123,17 @@ func (s *SongSrvc) CreateSong(ctx context.Context, request dto.CreateSongRequest
 		}, err
 	}
 
-	err = s.ProcessVerses(ctx, song)
+	err = s.ProcessVerses(ctx, song.Id)
 	if err != nil {
-		s.Logger.Info.Error("Failed to process verses", "error", err.Error())
+		s.Logger.Info.ErrorContext(ctx, "Failed to process verses", "error", err.Error())
 		return dto.StandartResponse{
 			Message: "something vent wrong",
 			Error:   err.Error(),
 		}, err
 	}
 
+	metrics.SongCreated()
+
 	resp := dto.StandartResponse{
 		Song:    song,
 		Message: "Songs succsessfully created",
@@ -129,7 +147,17 @@ func (s *SongSrvc) GetSongById(ctx context.Context, request dto.GetSongByIdReque
 
 	song, err := s.SongRepo.GetSongById(ctx, request.Id)
 	if err != nil {
-		s.Logger.Info.Error("Failed to get song by ID",
+		s.Logger.Info.ErrorContext(ctx, "Failed to get song by ID",
+			"error", err,
+			"song_id", request.Id)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Lang, err = s.translateSong(ctx, &song, request.Langs)
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to get song translation",
 			"error", err,
 			"song_id", request.Id)
 		resp.Message = "some error occured"
@@ -145,28 +173,20 @@ func (s *SongSrvc) GetSongById(ctx context.Context, request dto.GetSongByIdReque
 func (s *SongSrvc) UpdateSong(ctx context.Context, request dto.UpdateSongRequest, songId uuid.UUID) (dto.StandartResponse, error) {
 	resp := dto.StandartResponse{}
 
-	exists, err := s.SongRepo.SongExsistsById(ctx, songId)
+	originalSong, err := s.getSong(ctx, songId)
 	if err != nil {
-		s.Logger.Info.Error("Error checking if song exists",
-			"error", err,
-			"song_id", songId)
 		resp.Message = "some error occured"
 		resp.Error = err.Error()
 		return resp, err
 	}
-	if !exists {
-		s.Logger.Info.Error("Song does not exist",
-			"song_id", songId)
-		resp.Message = "song does not exist"
-		return resp, errors.New("song does not exist")
-	}
 
-	originalSong, err := s.SongRepo.GetSongById(ctx, songId)
-	if err != nil {
-		s.Logger.Info.Error("Failed to get song by ID", err)
-		resp.Message = "some error occured"
-		resp.Error = err.Error()
-		return resp, err
+	// Песню успели изменить после того, как клиент её прочитал: отдаём
+	// текущую версию, чтобы он мог повторить правку поверх неё
+	if !request.Precondition.Matches(originalSong.Version) {
+		resp.Song = originalSong
+		resp.Message = "song was modified, current version returned"
+		resp.Error = repository.ErrSongModified.Error()
+		return resp, repository.ErrSongModified
 	}
 
 	if request.Link != "" {
@@ -186,68 +206,171 @@ func (s *SongSrvc) UpdateSong(ctx context.Context, request dto.UpdateSongRequest
 	}
 
 	if request.GroupName != "" {
-		groupExsists, err := s.GroupRepo.GroupExsist(context.Background(), request.GroupName)
+		group, err := s.resolveGroup(ctx, request.GroupName)
 		if err != nil {
-			s.Logger.Info.Error("some error",
+			s.Logger.Info.ErrorContext(ctx, "Failed to get song group",
 				"error", err)
 			resp.Message = "some error occured"
 			resp.Error = err.Error()
 			return resp, err
 		}
-		if groupExsists {
-			group, err := s.GroupRepo.GetGroupByName(request.GroupName)
-			if err != nil {
-				s.Logger.Info.Error("some error",
-					"error", err)
-				resp.Message = "some error occured"
-				resp.Error = err.Error()
-			}
-			originalSong.GroupName = group.Name
-			originalSong.GroupId = group.Id
-		} else {
-			group := models.Group{
-				Id:   uuid.New(),
-				Name: request.GroupName,
-			}
-			err := s.GroupRepo.CreateGroup(group)
+		originalSong.GroupName = group.Name
+		originalSong.GroupId = group.Id
+	}
+
+	if request.SplitStrategy != "" {
+		originalSong.SplitStrategy, err = parseSplitStrategy(request.SplitStrategy)
+		if err != nil {
+			resp.Message = "Validation failed"
+			resp.Error = err.Error()
+			return resp, err
+		}
+	}
+
+	return s.saveSong(ctx, originalSong, request.Text != "" || request.SplitStrategy != "", request.Text != "")
+}
+
+// PatchSong применяет JSON Merge Patch: переданные поля заменяются,
+// null очищает поле, отсутствующие поля не меняются.
+func (s *SongSrvc) PatchSong(ctx context.Context, songId uuid.UUID, patch dto.SongPatch) (dto.StandartResponse, error) {
+	resp := dto.StandartResponse{}
+
+	song, err := s.getSong(ctx, songId)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	if !patch.Precondition.Matches(song.Version) {
+		resp.Song = song
+		resp.Message = "song was modified, current version returned"
+		resp.Error = repository.ErrSongModified.Error()
+		return resp, repository.ErrSongModified
+	}
+
+	if patch.Title != nil {
+		song.Title = *patch.Title
+	}
+	if patch.ReleaseDate != nil {
+		song.ReleaseDate = *patch.ReleaseDate
+	}
+	if patch.Link != nil {
+		song.Link = *patch.Link
+	}
+	if patch.Text != nil {
+		song.Text = *patch.Text
+	}
+	if patch.GroupName != nil {
+		group, err := s.resolveGroup(ctx, *patch.GroupName)
+		if err != nil {
+			s.Logger.Info.ErrorContext(ctx, "Failed to get song group",
+				"error", err)
+			resp.Message = "some error occured"
+			resp.Error = err.Error()
+			return resp, err
+		}
+		song.GroupName = group.Name
+		song.GroupId = group.Id
+	}
+	if patch.SplitStrategy != nil {
+		song.SplitStrategy = ""
+		if *patch.SplitStrategy != "" {
+			song.SplitStrategy, err = parseSplitStrategy(*patch.SplitStrategy)
 			if err != nil {
-				s.Logger.Info.Error("Failed to create group",
-					"error", err)
-				resp.Message = "some error occured"
+				resp.Message = "Validation failed"
 				resp.Error = err.Error()
 				return resp, err
 			}
-			originalSong.GroupId = group.Id
-			originalSong.GroupName = group.Name
 		}
+	}
+
+	return s.saveSong(ctx, song, patch.Text != nil || patch.SplitStrategy != nil, patch.Text != nil)
+}
+
+// saveSong сохраняет изменённую песню в её текущей версии. Новый текст или
+// новую стратегию сразу же применяем к куплетам, иначе /api/verses продолжит
+// отдавать старые; тайминги строк переносятся на новый текст в той же транзакции.
+func (s *SongSrvc) saveSong(ctx context.Context, song models.Song, rebuildVerses, textChanged bool) (dto.StandartResponse, error) {
+	var resp dto.StandartResponse
+	var err error
 
+	song.UpdatedAt = time.Now()
+
+	var verses []models.Verse
+	if rebuildVerses {
+		verses = s.buildVerses(ctx, song)
 	}
 
-	originalSong.UpdatedAt = time.Now()
-	originalSong.Id = songId
+	var lines []models.LyricLine
+	if textChanged {
+		lines, err = s.alignedLines(ctx, song.Id, song.Text)
+		if err != nil {
+			s.Logger.Info.ErrorContext(ctx, "Failed to align lyric lines",
+				"error", err,
+				"song_id", song.Id)
+			resp.Message = "some error occured"
+			resp.Error = err.Error()
+			return resp, err
+		}
+	}
 
-	err = s.SongRepo.UpdateSong(ctx, originalSong)
+	err = s.SongRepo.UpdateSong(ctx, song, verses, lines)
 	if err != nil {
-		s.Logger.Info.Error("Failed to update song",
+		s.Logger.Info.ErrorContext(ctx, "Failed to update song",
 			"error", err,
-			"song_id", songId)
+			"song_id", song.Id)
+		if errors.Is(err, repository.ErrSongModified) {
+			resp.Song, _ = s.SongRepo.GetSongById(ctx, song.Id)
+		}
 		resp.Message = "some error occured"
 		resp.Error = err.Error()
 		return resp, err
 	}
+	metrics.VersesProcessed(len(verses))
 
 	resp.Message = "Songs succsessfully updated"
-	resp.Song = originalSong
+	song.Version++
+	resp.Song = song
 
 	return resp, nil
 }
 
+// parseSplitStrategy проверяет стратегию разбиения из запроса. "default"
+// сбрасывает стратегию песни на глобальную.
+func parseSplitStrategy(raw string) (string, error) {
+	if raw == defaultSplitStrategy {
+		return "", nil
+	}
+	splitCfg, err := splitter.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	return splitCfg.String(), nil
+}
+
 func (s *SongSrvc) DeleteSong(ctx context.Context, req dto.DeleteSongByIdRequest) (dto.StandartResponse, error) {
 	resp := dto.StandartResponse{}
 
-	err := s.SongRepo.DeleteSong(ctx, req.Id)
+	song, err := s.getSong(ctx, req.Id)
+	if err != nil {
+		resp.Message = "Some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+	if !req.Precondition.Matches(song.Version) {
+		resp.Song = song
+		resp.Message = "song was modified, current version returned"
+		resp.Error = repository.ErrSongModified.Error()
+		return resp, repository.ErrSongModified
+	}
+
+	err = s.SongRepo.DeleteSong(ctx, req.Id, song.Version)
 	if err != nil {
-		s.Logger.Info.Error("Failed to delete song",
+		if errors.Is(err, repository.ErrSongModified) {
+			resp.Song, _ = s.SongRepo.GetSongById(ctx, req.Id)
+		}
+		s.Logger.Info.ErrorContext(ctx, "Failed to delete song",
 			"error", err,
 			"song_id", req.Id)
 		resp.Message = "Some error occured"
@@ -255,7 +378,7 @@ func (s *SongSrvc) DeleteSong(ctx context.Context, req dto.DeleteSongByIdRequest
 		return resp, err
 	}
 
-	resp.Message = "Songs succsessfully deleted"
+	resp.Message = "Song moved to trash"
 	return resp, nil
 }
 
@@ -263,15 +386,22 @@ func (s *SongSrvc) GetSongWithFilter(ctx context.Context, req dto.FilteredReques
 	var resp dto.SongsResponse
 
 	if pkg.IsEmpty(req) {
-		s.Logger.Info.Error("Empty filters provided")
+		s.Logger.Info.ErrorContext(ctx, "Empty filters provided")
 		resp.Message = "request must contain at least one filer"
-		resp.Error = errors.New("empty filters").Error()
-		return resp, errors.New("empty filters")
+		resp.Error = ErrEmptyFilter.Error()
+		return resp, ErrEmptyFilter
 	}
 
-	songs, err := s.SongRepo.GetSongsWithFilter(ctx, req)
+	if req.Limit <= 0 {
+		req.Limit = DefaultSongsLimit
+	}
+	if req.Limit > MaxSongsLimit {
+		req.Limit = MaxSongsLimit
+	}
+
+	songs, nextCursor, err := s.SongRepo.GetSongsWithFilter(ctx, req)
 	if err != nil {
-		s.Logger.Info.Error("Failed to get songs with filter",
+		s.Logger.Info.ErrorContext(ctx, "Failed to get songs with filter",
 			"error", err)
 		resp.Message = "some error occured"
 		resp.Error = err.Error()
@@ -279,34 +409,228 @@ func (s *SongSrvc) GetSongWithFilter(ctx context.Context, req dto.FilteredReques
 	}
 
 	resp.Songs = songs
+	resp.NextCursor = nextCursor
+	resp.HasMore = nextCursor != ""
 	return resp, nil
 }
 
-func (s *SongSrvc) ProcessVerses(ctx context.Context, song models.Song) error {
+// ProcessVerses разбивает текущий текст песни на куплеты и заменяет ими
+// сохранённые.
+func (s *SongSrvc) ProcessVerses(ctx context.Context, songId uuid.UUID) error {
+	count, err := s.VerseRepo.AddVerses(ctx, songId, func(song models.Song) []models.Verse {
+		return s.buildVerses(ctx, song)
+	})
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, err.Error())
+		return err
+	}
+	metrics.VersesProcessed(count)
+	return nil
+}
 
-	var req dto.AddVersesRequest
+// splitVerses разбивает текст стратегией песни, а если она не задана -
+// глобальной из VERSE_SPLIT_STRATEGY.
+func (s *SongSrvc) splitVerses(ctx context.Context, song models.Song) []string {
+	return splitter.New(s.splitConfigFor(ctx, song)).Split(song.Text)
+}
 
-	req.Verses = strings.Split(song.Text, "\\n\\n")
+// buildVerses разбивает текст песни и размечает куплеты: тип секции по
+// заголовку вида [Chorus] и ссылку на исходный куплет для повторов.
+func (s *SongSrvc) buildVerses(ctx context.Context, song models.Song) []models.Verse {
+	sections := splitter.Classify(s.splitVerses(ctx, song))
+
+	verses := make([]models.Verse, len(sections))
+	for i, section := range sections {
+		verses[i] = models.Verse{
+			Id:          uuid.New(),
+			SongId:      song.Id,
+			VerseNumber: i + 1,
+			Text:        section.Text,
+			SectionType: section.Type,
+		}
+		if section.RepeatOf > 0 {
+			verses[i].RepeatOf = &verses[section.RepeatOf-1].Id
+		}
+	}
+	return verses
+}
 
-	req.Song = song
+func (s *SongSrvc) splitConfigFor(ctx context.Context, song models.Song) splitter.Config {
+	if song.SplitStrategy == "" {
+		return s.SplitConfig
+	}
+	splitCfg, err := splitter.Parse(song.SplitStrategy)
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Invalid song split strategy, using default",
+			"error", err,
+			"song_id", song.Id,
+			"split_strategy", song.SplitStrategy)
+		return s.SplitConfig
+	}
+	return splitCfg
+}
 
-	err := s.VerseRepo.AddVerses(ctx, req)
+// PreviewVerses показывает, как текст будет разбит на куплеты, ничего не сохраняя.
+func (s *SongSrvc) PreviewVerses(request dto.PreviewVersesRequest) (dto.PreviewVersesResponse, error) {
+	var resp dto.PreviewVersesResponse
+
+	splitCfg := s.SplitConfig
+	if request.Strategy != "" {
+		var err error
+		splitCfg, err = splitter.Parse(request.Strategy)
+		if err != nil {
+			resp.Message = "Validation failed"
+			resp.Error = err.Error()
+			return resp, err
+		}
+	}
+
+	resp.Strategy = splitCfg.String()
+	resp.Verses = splitter.New(splitCfg).Split(request.Text)
+	return resp, nil
+}
+
+// RebuildVerses заново разбивает текст песни на куплеты. Нужен для песен,
+// у которых куплеты разошлись с текстом.
+func (s *SongSrvc) RebuildVerses(ctx context.Context, songId uuid.UUID) (dto.RebuildVersesResponse, error) {
+	var resp dto.RebuildVersesResponse
+
+	err := s.ProcessVerses(ctx, songId)
 	if err != nil {
-		s.Logger.Info.Error(err.Error())
-		return err
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
 	}
-	return nil
+
+	resp.Processed = 1
+	resp.Message = "Verses succsessfully rebuilt"
+	return resp, nil
+}
+
+// RebuildAllVerses обходит всю библиотеку пачками по id и пересобирает
+// куплеты каждой песни. Ошибка одной песни не останавливает обход. После
+// каждой пачки вызывается progress; отмена ctx прерывает обход перед
+// следующей песней.
+func (s *SongSrvc) RebuildAllVerses(ctx context.Context, progress func(dto.RebuildProgressLine)) (dto.RebuildVersesResponse, error) {
+	resp := dto.RebuildVersesResponse{Failed: []uuid.UUID{}}
+
+	after := uuid.Nil
+	for {
+		ids, err := s.SongRepo.GetSongIdsAfter(ctx, after, rebuildBatchSize)
+		if err != nil {
+			resp.Message = "some error occured"
+			resp.Error = err.Error()
+			return resp, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		for _, id := range ids {
+			if err := ctx.Err(); err != nil {
+				s.Logger.Info.InfoContext(ctx, "Verse rebuild interrupted",
+					"processed", resp.Processed,
+					"failed", len(resp.Failed))
+				resp.Message = "rebuild interrupted"
+				resp.Error = err.Error()
+				return resp, err
+			}
+
+			err := s.ProcessVerses(ctx, id)
+			if err != nil {
+				s.Logger.Info.ErrorContext(ctx, "Failed to rebuild verses",
+					"error", err,
+					"song_id", id)
+				resp.Failed = append(resp.Failed, id)
+				continue
+			}
+			resp.Processed++
+		}
+
+		after = ids[len(ids)-1]
+		progress(dto.RebuildProgressLine{Processed: resp.Processed, Failed: len(resp.Failed)})
+	}
+
+	s.Logger.Info.InfoContext(ctx, "Verses rebuilt for all songs",
+		"processed", resp.Processed,
+		"failed", len(resp.Failed))
+
+	resp.Message = "Verses succsessfully rebuilt"
+	return resp, nil
 }
 
 // В тз к заданию ничего не было сказано, поэтому сделал page based
 func (s *SongSrvc) GetPaginatedVerses(ctx context.Context, request dto.PaginatedVersesRequest) (dto.PaginatedVersesResponse, error) {
 
+	if request.Limit > MaxVersesLimit {
+		request.Limit = MaxVersesLimit
+	}
+
 	resp, err := s.VerseRepo.GetPaginatedVerses(ctx, request)
+	if err == nil {
+		resp.Lang, err = s.translateVerses(ctx, request.SongId, resp.Verses, request.Langs, request.SideBySide)
+	}
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to get paginated verses",
+			"error", err,
+			"song_id", request.SongId)
+		resp.Message = "Failed to get paginated verses"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	return resp, nil
+
+}
+
+func (s *SongSrvc) SearchLyrics(ctx context.Context, request dto.LyricsSearchRequest) (dto.LyricsSearchResponse, error) {
+	var resp dto.LyricsSearchResponse
+
+	if request.Limit <= 0 {
+		request.Limit = DefaultSearchLimit
+	}
+	if request.Limit > MaxSearchLimit {
+		request.Limit = MaxSearchLimit
+	}
+
+	results, err := s.SongRepo.SearchLyrics(ctx, request)
 	if err != nil {
-		s.Logger.Info.Error(err.Error())
-		return dto.PaginatedVersesResponse{}, err
+		s.Logger.Info.ErrorContext(ctx, "Failed to search lyrics",
+			"error", err,
+			"query", request.Query)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
 	}
 
+	resp.Results = results
 	return resp, nil
+}
+
+// resolveGroup находит группу по названию или алиасу и создаёт новую,
+// если такого исполнителя ещё нет.
+func (s *SongSrvc) resolveGroup(ctx context.Context, name string) (models.Group, error) {
+	group, err := s.GroupRepo.ResolveGroup(ctx, name)
+	if err == nil {
+		return group, nil
+	}
+	if !errors.Is(err, repository.ErrGroupNotFound) {
+		return models.Group{}, err
+	}
+
+	group = models.Group{
+		Id:   uuid.New(),
+		Name: name,
+	}
+	// Группу с тем же названием мог одновременно создать другой запрос,
+	// тогда песня попадает в неё
+	group, err = s.GroupRepo.CreateGroup(ctx, group)
+	if err != nil && !errors.Is(err, repository.ErrGroupExists) {
+		s.Logger.Info.ErrorContext(ctx, "Failed to create group",
+			"error", err,
+			"group_name", name)
+		return models.Group{}, err
+	}
 
+	return group, nil
 }
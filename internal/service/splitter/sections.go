@@ -0,0 +1,121 @@
+package splitter
+
+import (
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"strings"
+	"unicode"
+)
+
+// sectionNames сопоставляет названия из заголовков вида [Chorus] с типом
+// секции. Сравнение идёт по первому слову заголовка без учёта регистра,
+// поэтому "[Chorus x2]" и "[Припев: Иван]" тоже распознаются.
+var sectionNames = map[string]string{
+	"verse":      models.SectionVerse,
+	"куплет":     models.SectionVerse,
+	"chorus":     models.SectionChorus,
+	"refrain":    models.SectionChorus,
+	"hook":       models.SectionChorus,
+	"припев":     models.SectionChorus,
+	"pre-chorus": models.SectionPreChorus,
+	"prechorus":  models.SectionPreChorus,
+	"pre_chorus": models.SectionPreChorus,
+	"предприпев": models.SectionPreChorus,
+	"bridge":     models.SectionBridge,
+	"бридж":      models.SectionBridge,
+	"intro":      models.SectionIntro,
+	"вступление": models.SectionIntro,
+	"интро":      models.SectionIntro,
+	"outro":      models.SectionOutro,
+	"концовка":   models.SectionOutro,
+	"аутро":      models.SectionOutro,
+}
+
+// Section - куплет с распознанным типом. RepeatOf - номер (с 1) куплета,
+// который повторяется в этом, 0 - не повтор.
+type Section struct {
+	Text     string
+	Type     string
+	RepeatOf int
+}
+
+// IsHeader сообщает, является ли строка заголовком секции вида [Chorus].
+func IsHeader(line string) bool {
+	return sectionTitle.MatchString(line)
+}
+
+// SectionType определяет тип куплета по заголовку в первой строке.
+// ok = false, если заголовка нет или он не распознан, тогда тип - verse.
+func SectionType(block string) (string, bool) {
+	first, _, _ := strings.Cut(strings.TrimSpace(block), "\n")
+	if !IsHeader(first) {
+		return models.SectionVerse, false
+	}
+
+	name := strings.Trim(strings.TrimSpace(first), "[]")
+	name = strings.ToLower(strings.TrimSpace(name))
+	if i := strings.IndexFunc(name, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsDigit(r) || r == ':'
+	}); i >= 0 {
+		name = name[:i]
+	}
+
+	sectionType, ok := sectionNames[name]
+	if !ok {
+		return models.SectionVerse, false
+	}
+	return sectionType, true
+}
+
+// Classify размечает куплеты: тип берётся из заголовка, повторы находятся
+// по совпадению текста без заголовка. Повторяющийся куплет без заголовка
+// считается припевом. Куплет из одного заголовка ("[Chorus]") ссылается
+// на первый куплет того же типа.
+func Classify(blocks []string) []Section {
+	sections := make([]Section, len(blocks))
+	firstByBody := make(map[string]int)
+	firstByType := make(map[string]int)
+	explicit := make([]bool, len(blocks))
+
+	for i, block := range blocks {
+		sectionType, ok := SectionType(block)
+		sections[i] = Section{Text: block, Type: sectionType}
+		explicit[i] = ok
+
+		key := bodyKey(block)
+		if key == "" {
+			if j, found := firstByType[sectionType]; found && ok {
+				sections[i].RepeatOf = j + 1
+			}
+		} else if j, found := firstByBody[key]; found {
+			sections[i].RepeatOf = j + 1
+			if !explicit[j] && !ok {
+				sections[j].Type = models.SectionChorus
+				sections[i].Type = models.SectionChorus
+				if _, found := firstByType[models.SectionChorus]; !found {
+					firstByType[models.SectionChorus] = j
+				}
+			} else if !ok {
+				sections[i].Type = sections[j].Type
+			}
+		} else {
+			firstByBody[key] = i
+		}
+
+		if _, found := firstByType[sections[i].Type]; !found && key != "" && sections[i].RepeatOf == 0 {
+			firstByType[sections[i].Type] = i
+		}
+	}
+
+	return sections
+}
+
+// bodyKey возвращает текст куплета без заголовка, приведённый к нижнему
+// регистру и с единообразными пробелами, чтобы повторы находились
+// независимо от форматирования.
+func bodyKey(block string) string {
+	lines := strings.Split(Normalize(block), "\n")
+	if len(lines) > 0 && IsHeader(lines[0]) {
+		lines = lines[1:]
+	}
+	return strings.ToLower(strings.Join(strings.Fields(strings.Join(lines, " ")), " "))
+}
@@ -0,0 +1,106 @@
+package splitter
+
+import (
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"reflect"
+	"testing"
+)
+
+func TestSectionType(t *testing.T) {
+	tests := []struct {
+		block  string
+		want   string
+		wantOk bool
+	}{
+		{block: "[Chorus]\nLa la", want: models.SectionChorus, wantOk: true},
+		{block: "[Chorus x2]\nLa la", want: models.SectionChorus, wantOk: true},
+		{block: "[Припев: Иван]\nЛа ла", want: models.SectionChorus, wantOk: true},
+		{block: "[Verse 2]\nLine", want: models.SectionVerse, wantOk: true},
+		{block: "[Pre-Chorus]\nLine", want: models.SectionPreChorus, wantOk: true},
+		{block: "[Bridge]", want: models.SectionBridge, wantOk: true},
+		{block: "[Solo]\nLine", want: models.SectionVerse, wantOk: false},
+		{block: "Line\n[Chorus]", want: models.SectionVerse, wantOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.block, func(t *testing.T) {
+			got, ok := SectionType(tt.block)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("SectionType() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		blocks []string
+		want   []Section
+	}{
+		{
+			name:   "повтор без заголовков становится припевом",
+			blocks: []string{"Verse one", "La la", "Verse two", "la  LA"},
+			want: []Section{
+				{Text: "Verse one", Type: models.SectionVerse},
+				{Text: "La la", Type: models.SectionChorus},
+				{Text: "Verse two", Type: models.SectionVerse},
+				{Text: "la  LA", Type: models.SectionChorus, RepeatOf: 2},
+			},
+		},
+		{
+			name:   "повтор под заголовком сохраняет его тип",
+			blocks: []string{"[Bridge]\nOh oh", "Verse", "[Bridge]\nOh oh"},
+			want: []Section{
+				{Text: "[Bridge]\nOh oh", Type: models.SectionBridge},
+				{Text: "Verse", Type: models.SectionVerse},
+				{Text: "[Bridge]\nOh oh", Type: models.SectionBridge, RepeatOf: 1},
+			},
+		},
+		{
+			name:   "повтор без заголовка берёт тип первого куплета",
+			blocks: []string{"[Chorus]\nLa la", "Verse", "La la"},
+			want: []Section{
+				{Text: "[Chorus]\nLa la", Type: models.SectionChorus},
+				{Text: "Verse", Type: models.SectionVerse},
+				{Text: "La la", Type: models.SectionChorus, RepeatOf: 1},
+			},
+		},
+		{
+			name:   "куплет из одного заголовка ссылается на первый куплет того же типа",
+			blocks: []string{"Verse", "[Chorus]\nLa la", "Verse two", "[Chorus]"},
+			want: []Section{
+				{Text: "Verse", Type: models.SectionVerse},
+				{Text: "[Chorus]\nLa la", Type: models.SectionChorus},
+				{Text: "Verse two", Type: models.SectionVerse},
+				{Text: "[Chorus]", Type: models.SectionChorus, RepeatOf: 2},
+			},
+		},
+		{
+			name:   "заголовок без куплета такого типа не повтор",
+			blocks: []string{"Verse", "[Chorus]"},
+			want: []Section{
+				{Text: "Verse", Type: models.SectionVerse},
+				{Text: "[Chorus]", Type: models.SectionChorus},
+			},
+		},
+		{
+			name:   "повтор ссылается на первое вхождение",
+			blocks: []string{"La la", "La la", "La la"},
+			want: []Section{
+				{Text: "La la", Type: models.SectionChorus},
+				{Text: "La la", Type: models.SectionChorus, RepeatOf: 1},
+				{Text: "La la", Type: models.SectionChorus, RepeatOf: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Classify(tt.blocks)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Classify() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
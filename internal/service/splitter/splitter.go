@@ -0,0 +1,176 @@
+package splitter
+
+import (
+	"fmt"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	StrategyBlankLine      = "blank_line"
+	StrategyFixedLines     = "fixed_lines"
+	StrategySectionHeaders = "section_headers"
+
+	DefaultFixedLines = 4
+)
+
+var ErrUnknownStrategy = apperr.New(apperr.Validation, "unknown_split_strategy", "unknown split strategy")
+
+var (
+	blankLines   = regexp.MustCompile(`\n[ \t]*\n`)
+	sectionTitle = regexp.MustCompile(`^\s*\[[^\[\]]+\]\s*$`)
+)
+
+// Splitter разбивает текст песни на куплеты.
+type Splitter interface {
+	Split(text string) []string
+}
+
+// Config задаёт стратегию разбиения. В строковом виде это "blank_line",
+// "section_headers" или "fixed_lines:N".
+type Config struct {
+	Strategy string
+	Lines    int
+}
+
+func Parse(spec string) (Config, error) {
+	name, arg, hasArg := strings.Cut(strings.TrimSpace(spec), ":")
+	switch name {
+	case "", StrategyBlankLine:
+		if hasArg {
+			return Config{}, fmt.Errorf("%w: %s does not take arguments", ErrUnknownStrategy, name)
+		}
+		return Config{Strategy: StrategyBlankLine}, nil
+	case StrategySectionHeaders:
+		if hasArg {
+			return Config{}, fmt.Errorf("%w: %s does not take arguments", ErrUnknownStrategy, name)
+		}
+		return Config{Strategy: StrategySectionHeaders}, nil
+	case StrategyFixedLines:
+		lines := DefaultFixedLines
+		if hasArg {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 {
+				return Config{}, fmt.Errorf("%w: line count must be a positive integer", ErrUnknownStrategy)
+			}
+			lines = n
+		}
+		return Config{Strategy: StrategyFixedLines, Lines: lines}, nil
+	default:
+		return Config{}, fmt.Errorf("%w: %q", ErrUnknownStrategy, name)
+	}
+}
+
+func (c Config) String() string {
+	if c.Strategy == StrategyFixedLines {
+		return fmt.Sprintf("%s:%d", c.Strategy, c.Lines)
+	}
+	return c.Strategy
+}
+
+func New(cfg Config) Splitter {
+	switch cfg.Strategy {
+	case StrategyFixedLines:
+		return fixedLines{lines: cfg.Lines}
+	case StrategySectionHeaders:
+		return sectionHeaders{}
+	default:
+		return blankLine{}
+	}
+}
+
+// Normalize приводит переводы строк к \n, в том числе экранированные
+// (внешний API отдаёт текст с литералами \n), и убирает пробелы в конце строк.
+func Normalize(text string) string {
+	text = strings.NewReplacer(`\r\n`, "\n", `\n`, "\n", `\r`, "\n").Replace(text)
+	text = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// blankLine делит текст по пустым строкам. Несколько пустых строк подряд
+// считаются одним разделителем.
+type blankLine struct{}
+
+func (blankLine) Split(text string) []string {
+	return compact(blankLines.Split(Normalize(text), -1))
+}
+
+// fixedLines делит текст на куплеты по lines непустых строк.
+type fixedLines struct {
+	lines int
+}
+
+func (s fixedLines) Split(text string) []string {
+	var verses []string
+	var current []string
+	for _, line := range strings.Split(Normalize(text), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		current = append(current, line)
+		if len(current) == s.lines {
+			verses = append(verses, strings.Join(current, "\n"))
+			current = nil
+		}
+	}
+	if len(current) > 0 {
+		verses = append(verses, strings.Join(current, "\n"))
+	}
+	return verses
+}
+
+// sectionHeaders начинает новый куплет с каждой строки вида [Chorus].
+// Заголовок остаётся первой строкой куплета, пустые строки внутри секции
+// отбрасываются. Если заголовков в тексте нет, текст делится по пустым строкам.
+type sectionHeaders struct{}
+
+func (sectionHeaders) Split(text string) []string {
+	normalized := Normalize(text)
+	lines := strings.Split(normalized, "\n")
+
+	hasHeaders := false
+	for _, line := range lines {
+		if sectionTitle.MatchString(line) {
+			hasHeaders = true
+			break
+		}
+	}
+	if !hasHeaders {
+		return blankLine{}.Split(normalized)
+	}
+
+	var blocks []string
+	var current []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if sectionTitle.MatchString(line) && len(current) > 0 {
+			blocks = append(blocks, strings.Join(current, "\n"))
+			current = nil
+		}
+		current = append(current, line)
+	}
+	blocks = append(blocks, strings.Join(current, "\n"))
+
+	return compact(blocks)
+}
+
+// compact убирает пустые блоки и пустые строки по краям блоков.
+func compact(blocks []string) []string {
+	verses := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		block = strings.TrimSpace(block)
+		if block != "" {
+			verses = append(verses, block)
+		}
+	}
+	return verses
+}
@@ -0,0 +1,152 @@
+package splitter
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Config
+		wantErr bool
+	}{
+		{spec: "", want: Config{Strategy: StrategyBlankLine}},
+		{spec: "blank_line", want: Config{Strategy: StrategyBlankLine}},
+		{spec: " section_headers ", want: Config{Strategy: StrategySectionHeaders}},
+		{spec: "fixed_lines", want: Config{Strategy: StrategyFixedLines, Lines: DefaultFixedLines}},
+		{spec: "fixed_lines:2", want: Config{Strategy: StrategyFixedLines, Lines: 2}},
+		{spec: "fixed_lines:0", wantErr: true},
+		{spec: "fixed_lines:x", wantErr: true},
+		{spec: "blank_line:2", wantErr: true},
+		{spec: "section_headers:1", wantErr: true},
+		{spec: "paragraphs", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := Parse(tt.spec)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnknownStrategy) {
+					t.Fatalf("Parse() error = %v, want %v", err, ErrUnknownStrategy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigString(t *testing.T) {
+	for _, spec := range []string{"blank_line", "section_headers", "fixed_lines:3"} {
+		cfg, err := Parse(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cfg.String(); got != spec {
+			t.Errorf("Parse(%q).String() = %q", spec, got)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "экранированные переводы строк", text: `a\nb\r\nc`, want: "a\nb\nc"},
+		{name: "CRLF и CR", text: "a\r\nb\rc", want: "a\nb\nc"},
+		{name: "пробелы в конце строк и по краям", text: "  \na \t\nb  \n\n", want: "a\nb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.text); got != tt.want {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	const withHeaders = "[Verse 1]\nLine 1\n\nLine 2\n[Chorus]\nLa la\n\n\n[Verse 2]\nLine 3"
+
+	tests := []struct {
+		name string
+		spec string
+		text string
+		want []string
+	}{
+		{
+			name: "blank_line: несколько пустых строк - один разделитель",
+			spec: "blank_line",
+			text: "a\nb\n\n\n \nc\n\nd",
+			want: []string{"a\nb", "c", "d"},
+		},
+		{
+			name: "blank_line: экранированные переводы строк",
+			spec: "blank_line",
+			text: `a\nb\n\nc`,
+			want: []string{"a\nb", "c"},
+		},
+		{
+			name: "blank_line: пустой текст",
+			spec: "blank_line",
+			text: " \n\n ",
+			want: []string{},
+		},
+		{
+			name: "section_headers: куплет начинается с заголовка",
+			spec: "section_headers",
+			text: withHeaders,
+			want: []string{"[Verse 1]\nLine 1\nLine 2", "[Chorus]\nLa la", "[Verse 2]\nLine 3"},
+		},
+		{
+			name: "section_headers: текст до первого заголовка",
+			spec: "section_headers",
+			text: "Intro line\n[Chorus]\nLa la",
+			want: []string{"Intro line", "[Chorus]\nLa la"},
+		},
+		{
+			name: "section_headers: без заголовков делит по пустым строкам",
+			spec: "section_headers",
+			text: "a\n\nb",
+			want: []string{"a", "b"},
+		},
+		{
+			name: "fixed_lines: пустые строки не считаются",
+			spec: "fixed_lines:2",
+			text: "1\n2\n\n3\n4\n5",
+			want: []string{"1\n2", "3\n4", "5"},
+		},
+		{
+			name: "fixed_lines: по умолчанию 4 строки",
+			spec: "fixed_lines",
+			text: "1\n2\n3\n4\n5",
+			want: []string{"1\n2\n3\n4", "5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := New(cfg).Split(tt.text)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidLang = apperr.New(apperr.Validation, "invalid_lang", "lang must be a language tag like en or pt-br")
+
+var langTag = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{1,8})*$`)
+
+// NormalizeLang приводит языковой тег к нижнему регистру и проверяет его.
+func NormalizeLang(lang string) (string, error) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if !langTag.MatchString(lang) {
+		return "", ErrInvalidLang
+	}
+	return lang, nil
+}
+
+func (s *SongSrvc) ListTranslations(ctx context.Context, songId uuid.UUID) (dto.TranslationsResponse, error) {
+	var resp dto.TranslationsResponse
+
+	if _, err := s.getSong(ctx, songId); err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	translations, err := s.TranslationRepo.ListTranslations(ctx, songId)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Translations = translations
+	return resp, nil
+}
+
+func (s *SongSrvc) GetTranslation(ctx context.Context, songId uuid.UUID, lang string) (dto.TranslationResponse, error) {
+	var resp dto.TranslationResponse
+
+	lang, err := NormalizeLang(lang)
+	if err != nil {
+		resp.Message = "Validation failed"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	translation, err := s.TranslationRepo.GetTranslation(ctx, songId, lang)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	verses, err := s.TranslationRepo.GetVerseTranslations(ctx, songId, lang, nil)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Translation = translation
+	resp.Verses = verses
+	return resp, nil
+}
+
+// SaveTranslation создаёт или заменяет перевод. Текст перевода разбивается
+// на куплеты стратегией песни, чтобы номера совпали с куплетами оригинала.
+func (s *SongSrvc) SaveTranslation(ctx context.Context, songId uuid.UUID, lang string, request dto.SaveTranslationRequest) (dto.TranslationResponse, error) {
+	var resp dto.TranslationResponse
+
+	lang, err := NormalizeLang(lang)
+	if err != nil {
+		resp.Message = "Validation failed"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	song, err := s.getSong(ctx, songId)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	var verses []string
+	if len(request.Verses) > 0 {
+		for _, verse := range request.Verses {
+			text, err := normalizeVerseText(verse)
+			if err != nil {
+				resp.Message = "Validation failed"
+				resp.Error = err.Error()
+				return resp, err
+			}
+			verses = append(verses, text)
+		}
+	} else {
+		verses = s.splitVerses(ctx, models.Song{
+			Id:            songId,
+			Text:          request.Text,
+			SplitStrategy: song.SplitStrategy,
+		})
+	}
+
+	translation, err := s.TranslationRepo.SaveTranslation(ctx, models.SongTranslation{
+		SongId: songId,
+		Lang:   lang,
+		Title:  request.Title,
+		Text:   strings.Join(verses, models.VerseSeparator),
+	}, verses)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Translation = translation
+	resp.Verses = make([]models.VerseTranslation, len(verses))
+	for i, verse := range verses {
+		resp.Verses[i] = models.VerseTranslation{
+			VerseNumber: i + 1,
+			Lang:        lang,
+			Text:        verse,
+		}
+	}
+	resp.Message = "Translation succsessfully saved"
+	return resp, nil
+}
+
+func (s *SongSrvc) DeleteTranslation(ctx context.Context, songId uuid.UUID, lang string) (dto.TranslationResponse, error) {
+	var resp dto.TranslationResponse
+
+	lang, err := NormalizeLang(lang)
+	if err != nil {
+		resp.Message = "Validation failed"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	err = s.TranslationRepo.DeleteTranslation(ctx, songId, lang)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Message = "Translation succsessfully deleted"
+	return resp, nil
+}
+
+// pickLang выбирает из переводов песни первый подходящий язык из prefs.
+// "en-us" подходит к переводу "en", "en" - к "en-gb". Пустая строка
+// означает, что подходящего перевода нет и нужен оригинал.
+func (s *SongSrvc) pickLang(ctx context.Context, songId uuid.UUID, prefs []string) (string, error) {
+	if len(prefs) == 0 {
+		return "", nil
+	}
+
+	available, err := s.TranslationRepo.GetLangs(ctx, songId)
+	if err != nil || len(available) == 0 {
+		return "", err
+	}
+
+	for _, pref := range prefs {
+		primary, _, _ := strings.Cut(pref, "-")
+		for _, lang := range available {
+			if lang == pref {
+				return lang, nil
+			}
+		}
+		for _, lang := range available {
+			if lang == primary || strings.HasPrefix(lang, primary+"-") {
+				return lang, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// translateSong заменяет название и текст песни переводом на подходящий
+// язык. Возвращает язык перевода или пустую строку, если остался оригинал.
+func (s *SongSrvc) translateSong(ctx context.Context, song *models.Song, prefs []string) (string, error) {
+	lang, err := s.pickLang(ctx, song.Id, prefs)
+	if err != nil || lang == "" {
+		return "", err
+	}
+
+	translation, err := s.TranslationRepo.GetTranslation(ctx, song.Id, lang)
+	if err != nil {
+		return "", err
+	}
+
+	if translation.Title != "" {
+		song.Title = translation.Title
+	}
+	song.Text = translation.Text
+	return lang, nil
+}
+
+// translateVerses подставляет переводы куплетов: вместо текста оригинала
+// или рядом с ним, если sideBySide. Куплеты без перевода остаются как есть.
+func (s *SongSrvc) translateVerses(ctx context.Context, songId uuid.UUID, verses []models.Verse, prefs []string, sideBySide bool) (string, error) {
+	if len(verses) == 0 {
+		return "", nil
+	}
+
+	lang, err := s.pickLang(ctx, songId, prefs)
+	if err != nil || lang == "" {
+		return "", err
+	}
+
+	numbers := make([]int, len(verses))
+	for i, verse := range verses {
+		numbers[i] = verse.VerseNumber
+	}
+	translations, err := s.TranslationRepo.GetVerseTranslations(ctx, songId, lang, numbers)
+	if err != nil {
+		return "", err
+	}
+
+	byNumber := make(map[int]models.VerseTranslation, len(translations))
+	for _, translation := range translations {
+		byNumber[translation.VerseNumber] = translation
+	}
+	for i := range verses {
+		translation, ok := byNumber[verses[i].VerseNumber]
+		if !ok {
+			continue
+		}
+		if sideBySide {
+			verses[i].Translation = &translation
+		} else {
+			verses[i].Text = translation.Text
+		}
+	}
+	return lang, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/postgres/repository"
+	"github.com/wiqwi12/effective-mobile-test/pkg/logger"
+	"time"
+)
+
+const (
+	DefaultTrashRetention = 30 * 24 * time.Hour
+
+	DefaultTrashLimit = 20
+	MaxTrashLimit     = 100
+)
+
+type TrashSrvc struct {
+	SongRepo  *repository.SongRepository
+	Retention time.Duration
+	Logger    *logger.Logger
+}
+
+func NewTrashSrvc(songRepo *repository.SongRepository, retention time.Duration, logger *logger.Logger) *TrashSrvc {
+	if retention <= 0 {
+		retention = DefaultTrashRetention
+	}
+	return &TrashSrvc{
+		SongRepo:  songRepo,
+		Retention: retention,
+		Logger:    logger,
+	}
+}
+
+func (s *TrashSrvc) ListTrash(ctx context.Context, request dto.TrashListRequest) (dto.TrashResponse, error) {
+	var resp dto.TrashResponse
+
+	if request.Limit > MaxTrashLimit {
+		request.Limit = MaxTrashLimit
+	}
+
+	songs, total, err := s.SongRepo.ListTrash(ctx, request.Page, request.Limit)
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to list trash",
+			"error", err)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	for i := range songs {
+		songs[i].PurgeAt = songs[i].DeletedAt.Add(s.Retention)
+	}
+
+	resp.Songs = songs
+	resp.Page = request.Page
+	resp.Limit = request.Limit
+	resp.Total = total
+	return resp, nil
+}
+
+func (s *TrashSrvc) RestoreSong(ctx context.Context, songId uuid.UUID) (dto.StandartResponse, error) {
+	var resp dto.StandartResponse
+
+	song, err := s.SongRepo.RestoreSong(ctx, songId)
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to restore song",
+			"error", err,
+			"song_id", songId)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Song = song
+	resp.Message = "Song succsessfully restored"
+	return resp, nil
+}
+
+// RunPurge раз в interval окончательно удаляет песни, пролежавшие в
+// корзине дольше Retention, пока не отменён ctx.
+func (s *TrashSrvc) RunPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.SongRepo.PurgeTrash(ctx, s.Retention)
+			if err != nil {
+				continue
+			}
+			if purged > 0 {
+				s.Logger.Debug.Info("trashed songs purged", "count", purged)
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/apperr"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models"
+	"github.com/wiqwi12/effective-mobile-test/internal/domain/models/dto"
+	"github.com/wiqwi12/effective-mobile-test/internal/infrastructure/metrics"
+	"github.com/wiqwi12/effective-mobile-test/internal/service/splitter"
+	"strings"
+)
+
+var ErrInvalidVerseText = apperr.New(apperr.Validation, "invalid_verse_text", "verse text must not be empty or contain blank lines")
+
+// normalizeVerseText приводит текст куплета к тому виду, в котором его
+// вернёт splitter, и не даёт записать в куплет пустую строку: иначе после
+// пересборки songs.text куплет при следующем разбиении превратится в два.
+func normalizeVerseText(text string) (string, error) {
+	text = splitter.Normalize(text)
+	if text == "" || strings.Contains(text, models.VerseSeparator) {
+		return "", ErrInvalidVerseText
+	}
+	return text, nil
+}
+
+// verseSectionType возвращает явно переданный тип секции, а если он не
+// задан - тип по заголовку в тексте куплета.
+func verseSectionType(text, sectionType string) string {
+	if sectionType != "" {
+		return sectionType
+	}
+	sectionType, _ = splitter.SectionType(text)
+	return sectionType
+}
+
+// GetVerse возвращает куплет, при наличии перевода на один из langs -
+// переведённый или вместе с переводом, если sideBySide.
+func (s *SongSrvc) GetVerse(ctx context.Context, songId uuid.UUID, number int, langs []string, sideBySide bool) (dto.VerseResponse, error) {
+	var resp dto.VerseResponse
+
+	verse, err := s.VerseRepo.GetVerse(ctx, songId, number)
+	if err == nil {
+		verses := []models.Verse{verse}
+		resp.Lang, err = s.translateVerses(ctx, songId, verses, langs, sideBySide)
+		verse = verses[0]
+	}
+	if err != nil {
+		s.Logger.Info.ErrorContext(ctx, "Failed to get verse",
+			"error", err,
+			"song_id", songId,
+			"verse_number", number)
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Verse = verse
+	return resp, nil
+}
+
+func (s *SongSrvc) UpdateVerse(ctx context.Context, songId uuid.UUID, number int, request dto.UpdateVerseRequest) (dto.VerseResponse, error) {
+	var resp dto.VerseResponse
+
+	text, err := normalizeVerseText(request.Text)
+	if err != nil {
+		resp.Message = "Validation failed"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	verse, err := s.VerseRepo.UpdateVerse(ctx, songId, number, text, verseSectionType(text, request.SectionType), realignLines)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Verse = verse
+	metrics.VersesProcessed(1)
+
+	resp.Message = "Verse succsessfully updated"
+	return resp, nil
+}
+
+func (s *SongSrvc) InsertVerse(ctx context.Context, songId uuid.UUID, request dto.InsertVerseRequest) (dto.VerseResponse, error) {
+	var resp dto.VerseResponse
+
+	text, err := normalizeVerseText(request.Text)
+	if err != nil {
+		resp.Message = "Validation failed"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	verse, err := s.VerseRepo.InsertVerse(ctx, songId, request.Position, text, verseSectionType(text, request.SectionType), realignLines)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Verse = verse
+	metrics.VersesProcessed(1)
+
+	resp.Message = "Verse succsessfully created"
+	return resp, nil
+}
+
+func (s *SongSrvc) DeleteVerse(ctx context.Context, songId uuid.UUID, number int) (dto.VerseResponse, error) {
+	var resp dto.VerseResponse
+
+	err := s.VerseRepo.DeleteVerse(ctx, songId, number, realignLines)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Message = "Verse succsessfully deleted"
+	return resp, nil
+}
+
+func (s *SongSrvc) MoveVerse(ctx context.Context, songId uuid.UUID, number int, request dto.MoveVerseRequest) (dto.VerseResponse, error) {
+	var resp dto.VerseResponse
+
+	verse, err := s.VerseRepo.MoveVerse(ctx, songId, number, request.To, realignLines)
+	if err != nil {
+		resp.Message = "some error occured"
+		resp.Error = err.Error()
+		return resp, err
+	}
+
+	resp.Verse = verse
+	resp.Message = "Verse succsessfully moved"
+	return resp, nil
+}
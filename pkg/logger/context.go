@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+// RequestInfo - данные HTTP-запроса, которые добавляются к каждой записи
+// лога, сделанной с контекстом этого запроса. Route и User заполняются по
+// мере обработки: после выбора маршрута и аутентификации.
+type RequestInfo struct {
+	RequestId string
+	Method    string
+	Route     string
+	User      string
+}
+
+type requestInfoKey struct{}
+
+func WithRequest(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+func RequestFromContext(ctx context.Context) (*RequestInfo, bool) {
+	info, ok := ctx.Value(requestInfoKey{}).(*RequestInfo)
+	return info, ok
+}
+
+// contextHandler добавляет к записи данные запроса из контекста, поэтому
+// сервисам и репозиториям достаточно логировать через ErrorContext(ctx, ...).
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if info, ok := RequestFromContext(ctx); ok {
+		record.AddAttrs(
+			slog.String("request_id", info.RequestId),
+			slog.String("method", info.Method),
+		)
+		if info.Route != "" {
+			record.AddAttrs(slog.String("route", info.Route))
+		}
+		if info.User != "" {
+			record.AddAttrs(slog.String("user", info.User))
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
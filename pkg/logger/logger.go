@@ -45,7 +45,7 @@ func NewLogger(cfg cfg.Config) (*Logger, error) {
 		Level:     slog.LevelDebug,
 		AddSource: true,
 	})
-	debugLogger = slog.New(debugHandler)
+	debugLogger = slog.New(contextHandler{debugHandler})
 
 	infoDir := filepath.Dir(cfg.InfoFilePath)
 	if err := os.MkdirAll(infoDir, 0755); err != nil {
@@ -61,7 +61,7 @@ func NewLogger(cfg cfg.Config) (*Logger, error) {
 		Level:     slog.LevelInfo,
 		AddSource: true,
 	})
-	infoLogger = slog.New(infoHandler)
+	infoLogger = slog.New(contextHandler{infoHandler})
 
 	if cfg.ConsoleOutput == "true" {
 		debugMultiWriter := io.MultiWriter(debugFile, os.Stdout)
@@ -69,14 +69,14 @@ func NewLogger(cfg cfg.Config) (*Logger, error) {
 			Level:     slog.LevelDebug,
 			AddSource: true,
 		})
-		debugLogger = slog.New(debugMultiHandler)
+		debugLogger = slog.New(contextHandler{debugMultiHandler})
 
 		infoMultiWriter := io.MultiWriter(infoFile, os.Stdout)
 		infoMultiHandler := slog.NewJSONHandler(infoMultiWriter, &slog.HandlerOptions{
 			Level:     slog.LevelInfo,
 			AddSource: true,
 		})
-		infoLogger = slog.New(infoMultiHandler)
+		infoLogger = slog.New(contextHandler{infoMultiHandler})
 	}
 
 	return &Logger{
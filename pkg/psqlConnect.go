@@ -15,8 +15,6 @@ func NewDbConn(cfg cfg.PSQLconfig) (*sql.DB, error) {
 		cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.Database,
 	)
 
-	fmt.Println(dsn)
-
 	db, err := sql.Open("pgx", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при подключении к PostgreSQL: %w", err)